@@ -0,0 +1,76 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"sync"
+
+	"github.com/badboycxcc/dnsvalidator_go/pkg/dnsvalidator"
+)
+
+// 压测方式，对应 -bench、-bench-max-qps、-bench-step、-bench-parallel 和 -bench-output
+type benchOptions struct {
+	bench    dnsvalidator.BenchOptions
+	parallel int    // 同时压测的服务器数
+	output   string // 按吞吐量排序的服务器列表文件，为空不写出
+}
+
+// 对可用的 UDP 服务器逐台压测，列出按吞吐量排序的结果，并按需写出排序后的服务器列表；
+// 其他传输协议的服务器不压测
+func runBench(ctx context.Context, results []*dnsvalidator.Result, opts *benchOptions) error {
+	var servers []*dnsvalidator.Result
+	for _, r := range results {
+		if r.Transport == "udp" && r.IP != "" {
+			servers = append(servers, r)
+		}
+	}
+	if len(servers) == 0 {
+		fmt.Println("没有可以压测的 UDP 服务器")
+		return nil
+	}
+	fmt.Printf("开始压测 %d 台服务器，每秒最多 %d 个查询\n", len(servers), opts.bench.MaxQPS)
+
+	benched := make([]*dnsvalidator.BenchResult, len(servers))
+	sem := make(chan struct{}, opts.parallel)
+	var wg sync.WaitGroup
+	for i, r := range servers {
+		sem <- struct{}{}
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			defer func() { <-sem }()
+			// 中途取消时保留已完成的级别；结果中使用与输出相同的服务器名称，默认端口不写出
+			benched[i], _ = dnsvalidator.Bench(ctx, net.JoinHostPort(r.IP, r.Port), opts.bench)
+			benched[i].Server = r.Server
+		}()
+	}
+	wg.Wait()
+
+	dnsvalidator.RankBench(benched)
+	fmt.Println("按持续吞吐量排序的压测结果:")
+	for _, b := range benched {
+		fmt.Printf("  %-40s %5d qps  丢失 %.1f%%\n", b.Server, b.QPS, b.Loss*100)
+	}
+	if opts.output == "" {
+		return nil
+	}
+	f, err := createAtomic(opts.output)
+	if err != nil {
+		return err
+	}
+	for _, b := range benched {
+		if b.QPS == 0 {
+			continue
+		}
+		if _, err := fmt.Fprintln(f, b.Server); err != nil {
+			f.abort()
+			return err
+		}
+	}
+	if err := f.commit(); err != nil {
+		return err
+	}
+	fmt.Println("排序后的服务器列表已保存到", opts.output)
+	return nil
+}
@@ -0,0 +1,378 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"os"
+	"os/signal"
+	"sort"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/badboycxcc/dnsvalidator_go/pkg/dnsvalidator"
+)
+
+const (
+	shardAttempts = 3                      // 每个分片最多尝试的次数，失败后交给其他 worker
+	pollInterval  = 500 * time.Millisecond // 查询 worker 任务状态的间隔
+	workerBackoff = 5 * time.Second        // worker 出错后暂停领取分片的时间
+)
+
+// 协调模式下的一个分片
+type shard struct {
+	index    int
+	servers  []string
+	attempts int
+}
+
+// 协调模式：将列表切分为分片，通过 REST API 交给多个 -serve 实例检查并汇总结果。
+// 每个 worker 同一时间只处理一个分片，分片失败时交给其他 worker 重试
+type coordinator struct {
+	workers []string   // worker 的 REST API 地址，如 http://10.0.0.2:8080
+	request jobRequest // 各分片共用的检查参数
+	client  *http.Client
+
+	mu       sync.Mutex
+	tested   int
+	valid    int
+	failures map[string]int
+}
+
+// 从 worker 返回的任务状态
+type remoteJob struct {
+	ID       string         `json:"id"`
+	Status   string         `json:"status"`
+	Tested   int            `json:"tested"`
+	Valid    int            `json:"valid"`
+	Failures map[string]int `json:"failures"`
+	Error    string         `json:"error"`
+}
+
+func newCoordinator(workers []string, request jobRequest) *coordinator {
+	for i, w := range workers {
+		workers[i] = strings.TrimRight(w, "/")
+	}
+	return &coordinator{
+		workers:  workers,
+		request:  request,
+		client:   &http.Client{Timeout: 30 * time.Second},
+		failures: make(map[string]int),
+	}
+}
+
+// 读取列表并分片分发，通过检查的服务器写入返回的通道；通道关闭后调用返回的函数获取错误
+func (c *coordinator) run(ctx context.Context, sources dnsvalidator.Sources, shardSize int) (<-chan *dnsvalidator.Result, func() error) {
+	ctx, cancel := context.WithCancel(ctx)
+	results := make(chan *dnsvalidator.Result, 1024)
+	shards := make(chan *shard, len(c.workers))
+	retry := make(chan *shard, len(c.workers))
+
+	var mu sync.Mutex
+	var runErr error
+	fail := func(err error) {
+		mu.Lock()
+		if runErr == nil {
+			runErr = err
+		}
+		mu.Unlock()
+		cancel()
+	}
+
+	// 读取列表并切分；pending 为尚未完成的分片数，读取结束且全部完成后关闭 retry
+	var pending sync.WaitGroup
+	pending.Add(1)
+	go func() {
+		defer close(shards)
+		defer pending.Done()
+		next := &shard{}
+		send := func() bool {
+			pending.Add(1)
+			select {
+			case shards <- next:
+			case <-ctx.Done():
+				pending.Done()
+				return false
+			}
+			next = &shard{index: next.index + 1}
+			return true
+		}
+		// 取消后丢弃剩余的条目
+		stopped := false
+		err := dnsvalidator.ReadSources(ctx, sources, func(entry, source string) {
+			if stopped {
+				return
+			}
+			next.servers = append(next.servers, entry)
+			if len(next.servers) >= shardSize {
+				stopped = !send()
+			}
+		})
+		if err == nil && !stopped && len(next.servers) > 0 {
+			send()
+		}
+		if err != nil {
+			fail(err)
+		}
+	}()
+
+	var workers sync.WaitGroup
+	for _, w := range c.workers {
+		workers.Add(1)
+		go func(worker string) {
+			defer workers.Done()
+			c.work(ctx, worker, shards, retry, results, &pending, fail)
+		}(w)
+	}
+	go func() {
+		pending.Wait()
+		close(retry)
+	}()
+	done := make(chan struct{})
+	go func() {
+		workers.Wait()
+		cancel()
+		close(results)
+		close(done)
+	}()
+	return results, func() error {
+		<-done
+		mu.Lock()
+		defer mu.Unlock()
+		return runErr
+	}
+}
+
+// 一个 worker 的分发循环：优先领取需要重试的分片
+func (c *coordinator) work(ctx context.Context, worker string, shards, retry chan *shard, results chan<- *dnsvalidator.Result, pending *sync.WaitGroup, fail func(error)) {
+	for {
+		var s *shard
+		var ok bool
+		select {
+		case s, ok = <-retry:
+			if !ok {
+				return
+			}
+		default:
+			select {
+			case s, ok = <-retry:
+				if !ok {
+					return
+				}
+			case s, ok = <-shards:
+				if !ok {
+					// 列表已读完，继续等待其他 worker 失败的分片
+					shards = nil
+					continue
+				}
+			case <-ctx.Done():
+				return
+			}
+		}
+
+		s.attempts++
+		err := c.runShard(ctx, worker, s, results)
+		if err == nil {
+			pending.Done()
+			continue
+		}
+		if ctx.Err() != nil {
+			pending.Done()
+			return
+		}
+		log.Printf("worker %s 处理第 %d 个分片 (%d 台) 失败: %v", worker, s.index+1, len(s.servers), err)
+		if s.attempts >= shardAttempts {
+			pending.Done()
+			fail(fmt.Errorf("第 %d 个分片重试 %d 次后仍然失败: %v", s.index+1, s.attempts, err))
+			return
+		}
+		go func() { retry <- s }()
+		select {
+		case <-time.After(workerBackoff):
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+// 将一个分片提交给 worker，等待完成后读取结果并累计统计
+func (c *coordinator) runShard(ctx context.Context, worker string, s *shard, results chan<- *dnsvalidator.Result) error {
+	req := c.request
+	req.Servers = s.servers
+	var job remoteJob
+	if err := c.call(ctx, http.MethodPost, worker+"/jobs", req, &job); err != nil {
+		return err
+	}
+	for job.Status == jobQueued || job.Status == jobRunning {
+		select {
+		case <-time.After(pollInterval):
+		case <-ctx.Done():
+			// 尽量取消 worker 上的任务，不等待结果
+			cancelCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+			c.call(cancelCtx, http.MethodDelete, worker+"/jobs/"+job.ID, nil, nil)
+			cancel()
+			return ctx.Err()
+		}
+		if err := c.call(ctx, http.MethodGet, worker+"/jobs/"+job.ID, nil, &job); err != nil {
+			return err
+		}
+	}
+	if job.Status != jobDone {
+		return fmt.Errorf("任务 %s %s: %s", job.ID, job.Status, job.Error)
+	}
+
+	var shardResults []*dnsvalidator.Result
+	if err := c.call(ctx, http.MethodGet, worker+"/jobs/"+job.ID+"/results", nil, &shardResults); err != nil {
+		return err
+	}
+	c.mu.Lock()
+	c.tested += job.Tested
+	c.valid += job.Valid
+	for category, n := range job.Failures {
+		c.failures[category] += n
+	}
+	c.mu.Unlock()
+	for _, r := range shardResults {
+		select {
+		case results <- r:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+	return nil
+}
+
+// 调用 worker 的 REST API，body 不为空时以 JSON 发送，响应解码到 out
+func (c *coordinator) call(ctx context.Context, method, url string, body, out interface{}) error {
+	var reader io.Reader
+	if body != nil {
+		data, err := json.Marshal(body)
+		if err != nil {
+			return err
+		}
+		reader = bytes.NewReader(data)
+	}
+	req, err := http.NewRequestWithContext(ctx, method, url, reader)
+	if err != nil {
+		return err
+	}
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		var e struct {
+			Error string `json:"error"`
+		}
+		json.NewDecoder(io.LimitReader(resp.Body, 4096)).Decode(&e)
+		return fmt.Errorf("%s %s: HTTP %d %s", method, url, resp.StatusCode, e.Error)
+	}
+	if out == nil {
+		return nil
+	}
+	return json.NewDecoder(resp.Body).Decode(out)
+}
+
+// 将所有分片合计的汇总写到 w，格式与单机运行相同
+func (c *coordinator) print(w io.Writer) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	fmt.Fprintf(w, "共检查 %d 台服务器，可用 %d 台，失败 %d 台\n", c.tested, c.valid, c.tested-c.valid)
+	categories := make([]string, 0, len(c.failures))
+	for category := range c.failures {
+		categories = append(categories, category)
+	}
+	sort.Slice(categories, func(i, j int) bool {
+		a, b := categories[i], categories[j]
+		if c.failures[a] != c.failures[b] {
+			return c.failures[a] > c.failures[b]
+		}
+		return a < b
+	})
+	for _, category := range categories {
+		fmt.Fprintf(w, "  %-16s %d\n", category, c.failures[category])
+	}
+}
+
+// 所有分片合计的汇总，用于 Webhook 和聊天通知
+func (c *coordinator) summary(elapsed time.Duration, output string, threshold int) *runSummary {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if output == "" {
+		output = "-"
+	}
+	s := &runSummary{
+		Event:      eventFinished,
+		Time:       time.Now(),
+		Tested:     c.tested,
+		Valid:      c.valid,
+		Failed:     c.tested - c.valid,
+		Failures:   c.failures,
+		DurationMs: elapsed.Milliseconds(),
+		Output:     output,
+		Threshold:  threshold,
+	}
+	if threshold > 0 && s.Valid < threshold {
+		s.Event = eventBelowThreshold
+	}
+	return s
+}
+
+// 运行协调模式并写出结果，汇总和提示信息写到 info，收到 SIGINT/SIGTERM 时取消 worker 上的任务并放弃输出
+func (c *coordinator) coordinate(sources dnsvalidator.Sources, shardSize int, outputFile string, out *outputOptions, info io.Writer, webhook string, webhookMin int, notifier *chatNotifier) error {
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	output, err := openOutput(outputFile, out)
+	if err != nil {
+		return fmt.Errorf("无法创建输出文件：%v", err)
+	}
+
+	start := time.Now()
+	log.Printf("协调模式: 每个分片 %d 台，分发给 %d 个 worker", shardSize, len(c.workers))
+	results, wait := c.run(ctx, sources, shardSize)
+	written, skipped, err := writeResults(output, results, out)
+	if err != nil {
+		cancel()
+		for range results {
+		}
+		output.abort()
+		return fmt.Errorf("写入输出文件时出错：%v", err)
+	}
+	if err := wait(); err != nil {
+		output.abort()
+		return err
+	}
+	if ctx.Err() != nil {
+		output.abort()
+		return errors.New("运行被中断，已取消 worker 上的任务")
+	}
+	if err := output.commit(); err != nil {
+		return fmt.Errorf("保存输出文件时出错：%v", err)
+	}
+
+	c.print(info)
+	if skipped > 0 {
+		fmt.Fprintf(info, "按 -per-prefix 限制省略了 %d 台可用服务器\n", skipped)
+	}
+	if outputFile != "" {
+		fmt.Fprintln(info, "所有可用的 DNS 服务器已保存到", output.describe(outputFile))
+	}
+	summary := c.summary(time.Since(start), outputFile, webhookMin)
+	notifyWebhook(webhook, summary)
+	notifier.notify(summary, written)
+	return nil
+}
@@ -0,0 +1,190 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"net"
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/badboycxcc/dnsvalidator_go/pkg/dnsvalidator"
+)
+
+// 守护模式的配置
+type daemonOptions struct {
+	interval   time.Duration // 两轮检查开始之间的间隔，为 0 时只在列表文件变化时检查
+	schedule   *cronSchedule // 按 cron 表达式检查，与 interval 二选一
+	watch      []string      // 变化时立即重新检查的列表文件
+	output     string        // 持续更新的可用服务器列表，{date} 和 {time} 替换为本轮开始的时间
+	report     string        // 报告格式，为空时不生成报告
+	reportFile string
+	metrics    string // 提供 /metrics 的地址，为空时不提供
+	webhook    string // 每轮完成后接收汇总的 URL
+	webhookMin int
+	notifier   *chatNotifier // 每轮完成后发送聊天通知，为空时不发送
+	allowEmpty bool          // 上一轮有可用服务器时也允许用没有可用服务器的结果替换输出文件
+	lastValid  int           // 上一轮成功完成时的可用服务器数
+}
+
+// 以守护模式运行：每隔 interval、按 cron 计划或在监视的列表文件变化时重新读取列表来源并检查一轮，
+// 完成后原子地替换输出文件，下游程序随时读到的都是最近一轮完整的结果。同一时间只运行一轮，
+// 上一轮未结束时错过的计划时间会被跳过。某一轮出错、被中断或在上一轮有可用服务器时一台都没有找到
+// (多半是列表来源或本机网络故障) 时保留上一轮的输出；收到 SIGINT/SIGTERM 时取消进行中的检查并退出
+func daemon(dopts *daemonOptions, cfg dnsvalidator.Options, sources dnsvalidator.Sources, out *outputOptions) error {
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	var m *metrics
+	if dopts.metrics != "" {
+		m = newMetrics()
+		cfg.Middleware = append(append([]dnsvalidator.Middleware(nil), cfg.Middleware...), m.middleware())
+		ln, err := net.Listen("tcp", dopts.metrics)
+		if err != nil {
+			return err
+		}
+		go func() {
+			if err := serveMetrics(ctx, ln, m); err != nil {
+				log.Fatal("无法提供指标服务：", err)
+			}
+		}()
+		log.Printf("指标服务已启动: http://%s/metrics", dopts.metrics)
+	}
+
+	var changed <-chan struct{}
+	if len(dopts.watch) > 0 {
+		var err error
+		if changed, err = watchFiles(ctx, dopts.watch); err != nil {
+			return err
+		}
+		log.Printf("正在监视列表文件: %s", strings.Join(dopts.watch, ", "))
+	}
+	switch {
+	case dopts.schedule != nil:
+		log.Printf("守护模式已启动: 下一次检查在 %s，结果保存到 %s", dopts.schedule.next(time.Now()).Format(time.DateTime), dopts.output)
+	case dopts.interval > 0:
+		log.Printf("守护模式已启动: 每 %v 检查一次，结果保存到 %s", dopts.interval, dopts.output)
+	default:
+		log.Printf("守护模式已启动: 列表文件变化时重新检查，结果保存到 %s", dopts.output)
+	}
+
+	// 按计划运行时等到第一个计划时间，其他方式立即开始第一轮
+	if dopts.schedule == nil || dopts.wait(ctx, time.Now(), changed) {
+		for round := 1; ; round++ {
+			start := time.Now()
+			if err := revalidate(ctx, round, dopts, cfg, sources, out, m, start); err != nil {
+				if ctx.Err() != nil {
+					break
+				}
+				log.Printf("第 %d 轮检查失败，保留上一轮的结果: %v", round, err)
+			}
+			if !dopts.wait(ctx, start, changed) {
+				break
+			}
+		}
+	}
+	log.Println("收到中断信号，守护模式已退出")
+	return nil
+}
+
+// 等待下一轮开始，ctx 取消时返回 false。按 interval 运行时下一轮从上一轮开始后的 interval 开始，
+// 上一轮耗时超过 interval 时立即开始；按计划运行时等到当前时间之后的下一个计划时间；
+// 列表文件在检查期间或之后变化时也立即开始
+func (dopts *daemonOptions) wait(ctx context.Context, start time.Time, changed <-chan struct{}) bool {
+	var next <-chan time.Time
+	switch {
+	case dopts.schedule != nil:
+		now := time.Now()
+		at := dopts.schedule.next(now)
+		if missed := dopts.schedule.next(start); missed.Before(now) {
+			log.Printf("上一轮检查仍在运行，跳过了 %s 的计划检查", missed.Format(time.DateTime))
+		}
+		next = time.After(time.Until(at))
+	case dopts.interval > 0:
+		next = time.After(time.Until(start.Add(dopts.interval)))
+	}
+	select {
+	case <-ctx.Done():
+	case <-next:
+	case <-changed:
+		log.Println("列表文件已变化，重新检查")
+	}
+	return ctx.Err() == nil
+}
+
+// 本轮的输出文件路径，{date} 和 {time} 替换为 start 的日期 (20060102) 和时间 (20060102-150405)
+func (dopts *daemonOptions) outputPath(start time.Time) string {
+	return strings.NewReplacer("{date}", start.Format("20060102"), "{time}", start.Format("20060102-150405")).Replace(dopts.output)
+}
+
+// 检查一轮并替换输出文件
+func revalidate(ctx context.Context, round int, dopts *daemonOptions, cfg dnsvalidator.Options, sources dnsvalidator.Sources, out *outputOptions, m *metrics, start time.Time) error {
+	output := dopts.outputPath(start)
+	v, err := dnsvalidator.New(cfg)
+	if err != nil {
+		return err
+	}
+	f, err := openOutput(output, out)
+	if err != nil {
+		return err
+	}
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	m.watch(v)
+	out.promote.begin()
+	results, err := v.Run(ctx, sources)
+	if err != nil {
+		f.abort()
+		return err
+	}
+	written, _, err := writeResults(f, results, out)
+	if err != nil {
+		// 写入出错时取消检查并等待运行结束
+		cancel()
+		for range results {
+		}
+		f.abort()
+		return err
+	}
+
+	switch {
+	case v.Err() != nil:
+		err = v.Err()
+	case ctx.Err() != nil:
+		err = ctx.Err()
+	case v.Read() == 0:
+		err = errors.New("DNS 服务器列表为空")
+	case v.Stats().Valid() == 0 && dopts.lastValid > 0 && !dopts.allowEmpty:
+		err = fmt.Errorf("本轮没有可用的服务器而上一轮有 %d 台，可能是列表来源或网络故障 (-allow-empty 允许写入空的结果)", dopts.lastValid)
+	}
+	if err != nil {
+		f.abort()
+		return err
+	}
+	if err := f.commit(); err != nil {
+		return err
+	}
+
+	stats := v.Stats()
+	dopts.lastValid = stats.Valid()
+	m.finished(stats.Valid())
+	log.Printf("第 %d 轮检查完成: 检查 %d 台，可用 %d 台，用时 %v，已更新 %s", round, stats.Tested(), stats.Valid(), time.Since(start).Round(time.Millisecond), f.describe(output))
+	if out.promote != nil {
+		promoted, demoted := out.promote.commit()
+		log.Printf("第 %d 轮输出 %d 台最近 %d 轮中至少通过 %d 轮的服务器，新进入 %d 台，移出 %d 台",
+			round, len(out.promote.published), out.promote.window, out.promote.need, promoted, demoted)
+	}
+	summary := newRunSummary(stats, time.Since(start), output, dopts.webhookMin)
+	notifyWebhook(dopts.webhook, summary)
+	dopts.notifier.notify(summary, written)
+	if dopts.report != "" {
+		if err := dnsvalidator.WriteReport(dopts.reportFile, dopts.report, stats, written); err != nil {
+			return fmt.Errorf("写入报告时出错: %v", err)
+		}
+	}
+	return nil
+}
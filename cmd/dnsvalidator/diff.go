@@ -0,0 +1,88 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/badboycxcc/dnsvalidator_go/pkg/dnsvalidator"
+)
+
+// dnsvalidator diff 子命令：比较两次运行的 JSON/JSONL 输出，列出新增、移除和有变化的服务器。
+// 返回进程退出码：没有差异为 0，有差异为 1，出错为 2，与 diff 命令相同
+func runDiff(args []string) int {
+	fs := flag.NewFlagSet("diff", flag.ContinueOnError)
+	jsonOut := fs.Bool("json", false, "以 JSON 输出差异")
+	ratio := fs.Float64("latency-ratio", 2, "新延迟至少是旧延迟的该倍数才算变慢")
+	minMs := fs.Float64("latency-min", 20, "且至少增加该毫秒数")
+	fs.Usage = func() {
+		fmt.Fprintln(os.Stderr, "用法: dns_checker diff [-json] [-latency-ratio 2] [-latency-min 20] <旧结果.json> <新结果.json>")
+		fmt.Fprintln(os.Stderr, "  比较两次运行的 -format json 或 jsonl 输出，列出新增、移除和有变化 (延迟变慢、开始过滤、开始篡改答案) 的服务器")
+		fmt.Fprintln(os.Stderr, "  使用 -include-failed 输出的结果还能说明服务器不再可用的原因；没有差异时退出码为 0，有差异时为 1")
+		fs.PrintDefaults()
+	}
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+	if fs.NArg() != 2 {
+		fs.Usage()
+		return 2
+	}
+	var runs [2][]*dnsvalidator.Result
+	for i, path := range fs.Args() {
+		f, err := os.Open(path)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, "错误:", err)
+			return 2
+		}
+		runs[i], err = dnsvalidator.ReadResults(f)
+		f.Close()
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "错误: %s: %v\n", path, err)
+			return 2
+		}
+	}
+
+	d := dnsvalidator.DiffResults(runs[0], runs[1], dnsvalidator.DiffOptions{LatencyRatio: *ratio, LatencyMin: *minMs})
+	if *jsonOut {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		if err := enc.Encode(d); err != nil {
+			fmt.Fprintln(os.Stderr, "错误:", err)
+			return 2
+		}
+	} else {
+		printDiff(d)
+	}
+	if len(d.Added)+len(d.Removed)+len(d.Changed) > 0 {
+		return 1
+	}
+	return 0
+}
+
+// 变化类型的中文说明
+var changeNames = map[string]string{
+	dnsvalidator.ChangeLatency:   "延迟变慢",
+	dnsvalidator.ChangeFiltering: "开始过滤",
+	dnsvalidator.ChangePoisoned:  "开始篡改答案",
+	dnsvalidator.ChangeAnswers:   "答案换到了不同的网段",
+}
+
+// 以文本列出差异
+func printDiff(d *dnsvalidator.ResultDiff) {
+	fmt.Printf("新增 %d 台，移除 %d 台，变化 %d 项\n", len(d.Added), len(d.Removed), len(d.Changed))
+	for _, r := range d.Added {
+		fmt.Printf("+ %s\n", r.Server)
+	}
+	for _, r := range d.Removed {
+		if r.Error != "" {
+			fmt.Printf("- %s [%s] %s\n", r.Server, r.Category, r.Error)
+		} else {
+			fmt.Printf("- %s\n", r.Server)
+		}
+	}
+	for _, c := range d.Changed {
+		fmt.Printf("~ %s %s: %s\n", c.Server, changeNames[c.Kind], c.Detail)
+	}
+}
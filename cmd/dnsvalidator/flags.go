@@ -0,0 +1,47 @@
+package main
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/badboycxcc/dnsvalidator_go/pkg/dnsvalidator"
+)
+
+// 可重复指定、也可用逗号分隔多个值的命令行参数
+type listFlag []string
+
+func (l *listFlag) String() string {
+	return strings.Join(*l, ",")
+}
+
+func (l *listFlag) Set(value string) error {
+	*l = append(*l, dnsvalidator.SplitList(value)...)
+	return nil
+}
+
+// -t 参数：固定的线程数或 auto
+type threadsFlag struct {
+	n    int
+	auto bool
+}
+
+func (t *threadsFlag) String() string {
+	if t.auto {
+		return "auto"
+	}
+	return strconv.Itoa(t.n)
+}
+
+func (t *threadsFlag) Set(value string) error {
+	if value == "auto" {
+		t.auto = true
+		return nil
+	}
+	n, err := strconv.Atoi(value)
+	if err != nil {
+		return fmt.Errorf("线程数只能是正整数或 auto")
+	}
+	t.n, t.auto = n, false
+	return nil
+}
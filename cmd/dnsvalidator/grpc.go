@@ -0,0 +1,126 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/timestamppb"
+
+	"github.com/badboycxcc/dnsvalidator_go/pkg/dnsvalidator"
+	pb "github.com/badboycxcc/dnsvalidator_go/pkg/dnsvalidatorpb"
+)
+
+// gRPC 服务，与 REST API 共用任务队列，见 proto/dnsvalidator.proto
+type grpcService struct {
+	pb.UnimplementedDNSValidatorServer
+	s *server
+}
+
+// 按 ID 查找任务，不存在时返回 NotFound
+func (g *grpcService) lookup(id string) (*job, error) {
+	j := g.s.job(id)
+	if j == nil {
+		return nil, status.Error(codes.NotFound, errJobNotFound.Error())
+	}
+	return j, nil
+}
+
+func (g *grpcService) SubmitJob(ctx context.Context, req *pb.SubmitJobRequest) (*pb.SubmitJobResponse, error) {
+	j, err := g.s.submit(&jobRequest{
+		Servers:    req.Servers,
+		URLs:       req.Urls,
+		Domains:    req.Domains,
+		Checks:     req.Checks,
+		SkipChecks: req.SkipChecks,
+		Transport:  req.Transport,
+		Timeout:    req.Timeout,
+		StopAfter:  int(req.StopAfter),
+	})
+	if errors.Is(err, errQueueFull) {
+		return nil, status.Error(codes.ResourceExhausted, err.Error())
+	}
+	if errors.Is(err, errURLsDenied) {
+		return nil, status.Error(codes.PermissionDenied, err.Error())
+	}
+	if err != nil {
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+	return &pb.SubmitJobResponse{Id: j.ID}, nil
+}
+
+func (g *grpcService) StreamResults(req *pb.StreamResultsRequest, stream pb.DNSValidator_StreamResultsServer) error {
+	j, err := g.lookup(req.Id)
+	if err != nil {
+		return err
+	}
+	err = j.follow(stream.Context(), 0, func(r *dnsvalidator.Result) error {
+		return stream.Send(resultProto(r))
+	})
+	if err != nil && stream.Context().Err() != nil {
+		return status.FromContextError(err).Err()
+	}
+	return err
+}
+
+func (g *grpcService) GetSummary(ctx context.Context, req *pb.GetSummaryRequest) (*pb.Summary, error) {
+	j, err := g.lookup(req.Id)
+	if err != nil {
+		return nil, err
+	}
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	j.refresh()
+	summary := &pb.Summary{
+		Id:       j.ID,
+		Status:   j.Status,
+		Entries:  int32(j.Entries),
+		Created:  timestamppb.New(j.Created),
+		Started:  timestampProto(j.Started),
+		Finished: timestampProto(j.Finished),
+		Tested:   int32(j.Tested),
+		Valid:    int32(j.Valid),
+		Error:    j.Error,
+	}
+	if len(j.Failures) > 0 {
+		summary.Failures = make(map[string]int32, len(j.Failures))
+		for category, n := range j.Failures {
+			summary.Failures[category] = int32(n)
+		}
+	}
+	return summary, nil
+}
+
+// 转换检查结果
+func resultProto(r *dnsvalidator.Result) *pb.Result {
+	return &pb.Result{
+		Server:      r.Server,
+		Ip:          r.IP,
+		Port:        r.Port,
+		Transport:   r.Transport,
+		LatencyMs:   r.LatencyMs,
+		Reliability: r.Reliability,
+		Attempts:    int32(r.Attempts),
+		Retries:     int32(r.Retries),
+		Rcode:       r.RCode,
+		Answers:     r.Answers,
+		Flags:       r.Flags,
+		Checks:      r.Checks,
+		Details:     r.Attributes(),
+		Protocol:    r.Protocol,
+		Provider:    r.Provider,
+		Hostname:    r.Hostname,
+		Source:      r.Source,
+		Timestamp:   timestamppb.New(r.Timestamp),
+	}
+}
+
+// 转换可选的时间，为空时返回空
+func timestampProto(t *time.Time) *timestamppb.Timestamp {
+	if t == nil {
+		return nil
+	}
+	return timestamppb.New(*t)
+}
@@ -0,0 +1,299 @@
+package main
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/badboycxcc/dnsvalidator_go/pkg/dnsvalidator"
+)
+
+// 内存中最多保留的任务数，超过时删除最早完成的任务
+const maxJobs = 100
+
+// 任务状态
+const (
+	jobQueued   = "queued"
+	jobRunning  = "running"
+	jobDone     = "done"
+	jobFailed   = "failed"
+	jobCanceled = "canceled"
+)
+
+var (
+	errNoServers   = errors.New("需要提供 servers 或 urls")
+	errQueueFull   = errors.New("排队的任务过多")
+	errJobNotFound = errors.New("任务不存在")
+	errURLsDenied  = errors.New("服务未允许提交在线列表 URL，需要以 -serve-urls 启动")
+)
+
+// 提交任务的请求，未填写的字段使用启动服务时的命令行参数
+type jobRequest struct {
+	Servers    []string `json:"servers"`     // 待检查的条目
+	URLs       []string `json:"urls"`        // 在线列表 URL
+	Domains    []string `json:"domains"`     // 检查域名
+	Checks     []string `json:"checks"`      // 按名称启用的内置检查
+	SkipChecks []string `json:"skip_checks"` // 按名称跳过的检查
+	Transport  string   `json:"transport"`   // udp、tcp、both 或 dot
+	Timeout    string   `json:"timeout"`     // 单次查询的超时，如 2s
+	StopAfter  int      `json:"stop_after"`  // 找到该数量的可用服务器后停止
+}
+
+// 服务模式下的一个检查任务
+type job struct {
+	mu       sync.Mutex
+	ID       string         `json:"id"`
+	Status   string         `json:"status"`
+	Entries  int            `json:"entries"` // 提交的条目数，不含在线列表
+	Created  time.Time      `json:"created"`
+	Started  *time.Time     `json:"started,omitempty"`
+	Finished *time.Time     `json:"finished,omitempty"`
+	Tested   int            `json:"tested"`
+	Valid    int            `json:"valid"`
+	Failures map[string]int `json:"failures,omitempty"`
+	Error    string         `json:"error,omitempty"`
+
+	cfg     dnsvalidator.Options
+	sources dnsvalidator.Sources
+	v       *dnsvalidator.Validator
+	cancel  context.CancelFunc
+	results []*dnsvalidator.Result
+	updated chan struct{} // 有新结果或任务结束时关闭并替换
+}
+
+// 更新任务的统计
+func (j *job) refresh() {
+	if j.v == nil {
+		return
+	}
+	stats := j.v.Stats()
+	j.Tested, j.Valid, j.Failures = stats.Tested(), stats.Valid(), stats.Failures()
+}
+
+// 通知等待新结果的读取者，调用时需持有 j.mu
+func (j *job) notify() {
+	close(j.updated)
+	j.updated = make(chan struct{})
+}
+
+// 从第 from 条开始依次读取结果直到任务结束，fn 返回错误或 ctx 取消时停止
+func (j *job) follow(ctx context.Context, from int, fn func(*dnsvalidator.Result) error) error {
+	for {
+		j.mu.Lock()
+		results := j.results[from:]
+		ended := j.Finished != nil
+		updated := j.updated
+		j.mu.Unlock()
+
+		for _, r := range results {
+			if err := fn(r); err != nil {
+				return err
+			}
+		}
+		from += len(results)
+		if ended {
+			return nil
+		}
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-updated:
+		}
+	}
+}
+
+// 任务队列：任务排队后逐个运行，同一时间只运行一个任务
+type server struct {
+	base    dnsvalidator.Options
+	queue   chan *job
+	metrics *metrics
+	// 是否允许任务提交在线列表 URL；任何能访问 API 的调用者都可以借此让服务请求内网或云平台元数据地址，默认不允许
+	allowURLs bool
+
+	mu    sync.Mutex
+	jobs  map[string]*job
+	order []string // 按创建顺序排列的任务 ID
+}
+
+func newServer(base dnsvalidator.Options, m *metrics) *server {
+	base.Middleware = append(append([]dnsvalidator.Middleware(nil), base.Middleware...), m.middleware())
+	return &server{base: base, queue: make(chan *job, maxJobs), metrics: m, jobs: make(map[string]*job)}
+}
+
+// 依次运行队列中的任务，直到 ctx 取消
+func (s *server) runJobs(ctx context.Context) {
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case j := <-s.queue:
+			s.runJob(ctx, j)
+		}
+	}
+}
+
+// 运行一个任务并保存结果
+func (s *server) runJob(ctx context.Context, j *job) {
+	j.mu.Lock()
+	if j.Status != jobQueued {
+		j.mu.Unlock()
+		return
+	}
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	now := time.Now()
+	j.Status, j.Started, j.v, j.cancel = jobRunning, &now, nil, cancel
+	v, err := dnsvalidator.New(j.cfg)
+	if err == nil {
+		j.v = v
+		s.metrics.watch(v)
+	}
+	j.mu.Unlock()
+
+	var results <-chan *dnsvalidator.Result
+	if err == nil {
+		results, err = v.Run(ctx, j.sources)
+	}
+	if err == nil {
+		for r := range results {
+			j.mu.Lock()
+			j.results = append(j.results, r)
+			j.notify()
+			j.mu.Unlock()
+		}
+		err = v.Err()
+	}
+
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	finished := time.Now()
+	j.Finished = &finished
+	j.refresh()
+	switch {
+	case err != nil:
+		j.Status, j.Error = jobFailed, err.Error()
+	case ctx.Err() != nil:
+		j.Status = jobCanceled
+	default:
+		j.Status = jobDone
+		s.metrics.finished(j.Valid)
+	}
+	j.notify()
+	log.Printf("任务 %s %s: 检查 %d 台，可用 %d 台", j.ID, j.Status, j.Tested, j.Valid)
+}
+
+// 按请求生成任务的配置
+func (s *server) jobOptions(req *jobRequest) (dnsvalidator.Options, error) {
+	cfg := s.base
+	if len(req.Domains) > 0 {
+		cfg.Domains = req.Domains
+	}
+	if len(req.Checks) > 0 {
+		cfg.EnableChecks = append(append([]string(nil), cfg.EnableChecks...), req.Checks...)
+	}
+	if len(req.SkipChecks) > 0 {
+		cfg.DisableChecks = append(append([]string(nil), cfg.DisableChecks...), req.SkipChecks...)
+	}
+	if req.Transport != "" {
+		cfg.Transport = req.Transport
+	}
+	if req.Timeout != "" {
+		d, err := time.ParseDuration(req.Timeout)
+		if err != nil || d <= 0 {
+			return cfg, fmt.Errorf("无效的 timeout: %s", req.Timeout)
+		}
+		cfg.Timeout = d
+	}
+	if req.StopAfter > 0 {
+		cfg.StopAfter = req.StopAfter
+	}
+	return cfg, nil
+}
+
+// 检查请求并将任务加入队列；队列已满时返回 errQueueFull，其他错误为无效的请求
+func (s *server) submit(req *jobRequest) (*job, error) {
+	if len(req.Servers) == 0 && len(req.URLs) == 0 {
+		return nil, errNoServers
+	}
+	if len(req.URLs) > 0 && !s.allowURLs {
+		return nil, errURLsDenied
+	}
+	cfg, err := s.jobOptions(req)
+	if err != nil {
+		return nil, err
+	}
+	// 提前检查配置，使无效的请求直接返回错误
+	if _, err := dnsvalidator.New(cfg); err != nil {
+		return nil, err
+	}
+
+	j := &job{
+		ID:      newJobID(),
+		Status:  jobQueued,
+		Entries: len(req.Servers),
+		Created: time.Now(),
+		cfg:     cfg,
+		sources: dnsvalidator.Sources{Servers: req.Servers, URLs: req.URLs},
+		updated: make(chan struct{}),
+	}
+	select {
+	case s.queue <- j:
+	default:
+		return nil, errQueueFull
+	}
+	s.add(j)
+	return j, nil
+}
+
+// 记录新任务，超过 maxJobs 时删除最早的已结束任务
+func (s *server) add(j *job) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.jobs[j.ID] = j
+	s.order = append(s.order, j.ID)
+	for i := 0; len(s.order) > maxJobs && i < len(s.order); {
+		old := s.jobs[s.order[i]]
+		old.mu.Lock()
+		ended := old.Finished != nil
+		old.mu.Unlock()
+		if !ended {
+			i++
+			continue
+		}
+		delete(s.jobs, old.ID)
+		s.order = append(s.order[:i], s.order[i+1:]...)
+	}
+}
+
+// 按 ID 查找任务，不存在时为空
+func (s *server) job(id string) *job {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.jobs[id]
+}
+
+// 取消排队或运行中的任务
+func (s *server) cancel(j *job) {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	switch j.Status {
+	case jobQueued:
+		now := time.Now()
+		j.Status, j.Finished = jobCanceled, &now
+		j.notify()
+	case jobRunning:
+		j.cancel()
+	}
+}
+
+// 生成随机的任务 ID
+func newJobID() string {
+	b := make([]byte, 8)
+	rand.Read(b)
+	return hex.EncodeToString(b)
+}
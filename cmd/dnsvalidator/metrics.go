@@ -0,0 +1,206 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/http"
+	"sort"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/badboycxcc/dnsvalidator_go/pkg/dnsvalidator"
+)
+
+// 单台服务器检查耗时直方图的桶上限，单位为秒
+var durationBuckets = []float64{0.005, 0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10, 30}
+
+// 守护模式和服务模式下的运行指标，以 Prometheus 文本格式在 /metrics 输出
+type metrics struct {
+	queries atomic.Int64 // 发出的查询数，包括重试
+
+	mu       sync.Mutex
+	tested   int64
+	failures map[string]int64
+	buckets  []int64 // 与 durationBuckets 对应的累计计数
+	count    int64
+	sum      float64
+	pool     int       // 最近一次完成的运行中通过检查的服务器数
+	lastRun  time.Time // 最近一次运行完成的时间
+	runs     int64
+	checked  bool // 服务模式下启动自检通过：各服务已开始监听，默认配置可以创建 Validator
+}
+
+func newMetrics() *metrics {
+	return &metrics{failures: make(map[string]int64), buckets: make([]int64, len(durationBuckets))}
+}
+
+// 统计发出查询数的中间件
+func (m *metrics) middleware() dnsvalidator.Middleware {
+	return dnsvalidator.Middleware{
+		Query: func(ctx context.Context, info dnsvalidator.QueryInfo, req []byte) ([]byte, error) {
+			m.queries.Add(1)
+			return req, nil
+		},
+	}
+}
+
+// 订阅 v 的事件，记录每台服务器的检查耗时和失败类别；需在 v.Run 之前调用。
+// 事件读取过慢时事件总线会丢弃事件，此时指标略少于实际值
+func (m *metrics) watch(v *dnsvalidator.Validator) {
+	if m == nil {
+		return
+	}
+	events, _ := v.Subscribe(4096)
+	go func() {
+		started := make(map[string]time.Time)
+		for e := range events {
+			switch e.Type {
+			case dnsvalidator.EventStarted:
+				started[e.Server] = e.Time
+			case dnsvalidator.EventResolved, dnsvalidator.EventFailed:
+				category := ""
+				if e.Type == dnsvalidator.EventFailed {
+					category = "other"
+					if ce, ok := e.Err.(*dnsvalidator.CheckError); ok {
+						category = ce.Category
+					}
+				}
+				var elapsed time.Duration
+				if start, ok := started[e.Server]; ok {
+					elapsed = e.Time.Sub(start)
+					delete(started, e.Server)
+				}
+				m.observe(category, elapsed)
+			}
+		}
+	}()
+}
+
+// 记录一台服务器的检查结果，category 为空表示通过检查
+func (m *metrics) observe(category string, elapsed time.Duration) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.tested++
+	if category != "" {
+		m.failures[category]++
+	}
+	seconds := elapsed.Seconds()
+	for i, le := range durationBuckets {
+		if seconds <= le {
+			m.buckets[i]++
+		}
+	}
+	m.count++
+	m.sum += seconds
+}
+
+// 记录一次完成的运行及其可用服务器数
+func (m *metrics) finished(valid int) {
+	if m == nil {
+		return
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.pool = valid
+	m.lastRun = time.Now()
+	m.runs++
+}
+
+// GET /metrics：Prometheus 文本格式的指标
+func (m *metrics) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+
+	fmt.Fprintln(w, "# HELP dnsvalidator_queries_total DNS queries sent, including retries.")
+	fmt.Fprintln(w, "# TYPE dnsvalidator_queries_total counter")
+	fmt.Fprintf(w, "dnsvalidator_queries_total %d\n", m.queries.Load())
+
+	fmt.Fprintln(w, "# HELP dnsvalidator_servers_tested_total Servers that finished validation.")
+	fmt.Fprintln(w, "# TYPE dnsvalidator_servers_tested_total counter")
+	fmt.Fprintf(w, "dnsvalidator_servers_tested_total %d\n", m.tested)
+
+	fmt.Fprintln(w, "# HELP dnsvalidator_failures_total Servers that failed validation, by failure category.")
+	fmt.Fprintln(w, "# TYPE dnsvalidator_failures_total counter")
+	categories := make([]string, 0, len(m.failures))
+	for category := range m.failures {
+		categories = append(categories, category)
+	}
+	sort.Strings(categories)
+	for _, category := range categories {
+		fmt.Fprintf(w, "dnsvalidator_failures_total{category=%q} %d\n", category, m.failures[category])
+	}
+
+	fmt.Fprintln(w, "# HELP dnsvalidator_validation_duration_seconds Time spent validating a single server.")
+	fmt.Fprintln(w, "# TYPE dnsvalidator_validation_duration_seconds histogram")
+	for i, le := range durationBuckets {
+		fmt.Fprintf(w, "dnsvalidator_validation_duration_seconds_bucket{le=\"%g\"} %d\n", le, m.buckets[i])
+	}
+	fmt.Fprintf(w, "dnsvalidator_validation_duration_seconds_bucket{le=\"+Inf\"} %d\n", m.count)
+	fmt.Fprintf(w, "dnsvalidator_validation_duration_seconds_sum %g\n", m.sum)
+	fmt.Fprintf(w, "dnsvalidator_validation_duration_seconds_count %d\n", m.count)
+
+	fmt.Fprintln(w, "# HELP dnsvalidator_valid_resolvers Valid servers found by the last completed run.")
+	fmt.Fprintln(w, "# TYPE dnsvalidator_valid_resolvers gauge")
+	fmt.Fprintf(w, "dnsvalidator_valid_resolvers %d\n", m.pool)
+
+	fmt.Fprintln(w, "# HELP dnsvalidator_runs_total Completed validation runs.")
+	fmt.Fprintln(w, "# TYPE dnsvalidator_runs_total counter")
+	fmt.Fprintf(w, "dnsvalidator_runs_total %d\n", m.runs)
+
+	fmt.Fprintln(w, "# HELP dnsvalidator_last_run_timestamp_seconds Unix time the last run completed, 0 before the first run.")
+	fmt.Fprintln(w, "# TYPE dnsvalidator_last_run_timestamp_seconds gauge")
+	var last float64
+	if !m.lastRun.IsZero() {
+		last = float64(m.lastRun.UnixNano()) / 1e9
+	}
+	fmt.Fprintf(w, "dnsvalidator_last_run_timestamp_seconds %.3f\n", last)
+}
+
+// GET /healthz：进程存活即返回 200
+func handleHealthz(w http.ResponseWriter, r *http.Request) {
+	fmt.Fprintln(w, "ok")
+}
+
+// 记录服务模式的启动自检已通过
+func (m *metrics) selfChecked() {
+	m.mu.Lock()
+	m.checked = true
+	m.mu.Unlock()
+}
+
+// GET /readyz：守护模式下第一轮检查完成后、服务模式下启动自检通过或完成第一个任务后返回 200，否则返回 503
+func (m *metrics) handleReadyz(w http.ResponseWriter, r *http.Request) {
+	m.mu.Lock()
+	ready := m.checked || m.runs > 0
+	m.mu.Unlock()
+	if !ready {
+		http.Error(w, "waiting for the first validation pass", http.StatusServiceUnavailable)
+		return
+	}
+	fmt.Fprintln(w, "ok")
+}
+
+// 注册 /metrics、/healthz 和 /readyz
+func (m *metrics) register(mux *http.ServeMux) {
+	mux.Handle("GET /metrics", m)
+	mux.HandleFunc("GET /healthz", handleHealthz)
+	mux.HandleFunc("GET /readyz", m.handleReadyz)
+}
+
+// 在已开始监听的 ln 上单独提供 /metrics、/healthz 和 /readyz，直到 ctx 取消
+func serveMetrics(ctx context.Context, ln net.Listener, m *metrics) error {
+	mux := http.NewServeMux()
+	m.register(mux)
+	srv := &http.Server{Handler: mux, ReadHeaderTimeout: 10 * time.Second}
+	go func() {
+		<-ctx.Done()
+		srv.Close()
+	}()
+	if err := srv.Serve(ln); err != http.ErrServerClosed {
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,29 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+// 没有完成检查或启动自检之前 /readyz 返回 503
+func TestReadyz(t *testing.T) {
+	tests := []struct {
+		name  string
+		setup func(m *metrics)
+		code  int
+	}{
+		{"刚启动", func(m *metrics) {}, http.StatusServiceUnavailable},
+		{"服务模式自检通过", func(m *metrics) { m.selfChecked() }, http.StatusOK},
+		{"完成第一轮检查", func(m *metrics) { m.finished(3) }, http.StatusOK},
+	}
+	for _, tt := range tests {
+		m := newMetrics()
+		tt.setup(m)
+		w := httptest.NewRecorder()
+		m.handleReadyz(w, httptest.NewRequest(http.MethodGet, "/readyz", nil))
+		if w.Code != tt.code {
+			t.Errorf("%s: /readyz 返回 %d，应为 %d", tt.name, w.Code, tt.code)
+		}
+	}
+}
@@ -0,0 +1,185 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/badboycxcc/dnsvalidator_go/pkg/dnsvalidator"
+)
+
+// Telegram Bot API 的默认地址
+const telegramAPI = "https://api.telegram.org"
+
+// -notify 配置文件，未配置的渠道不发送
+//
+//	{
+//	  "slack":    {"webhook_url": "https://hooks.slack.com/services/..."},
+//	  "discord":  {"webhook_url": "https://discord.com/api/webhooks/..."},
+//	  "telegram": {"bot_token": "123456:ABC...", "chat_id": "-100123456"},
+//	  "top": 5,
+//	  "state_file": "notify-state.json"
+//	}
+type notifyConfig struct {
+	Slack *struct {
+		WebhookURL string `json:"webhook_url"`
+	} `json:"slack"`
+	Discord *struct {
+		WebhookURL string `json:"webhook_url"`
+	} `json:"discord"`
+	Telegram *struct {
+		BotToken string `json:"bot_token"`
+		ChatID   string `json:"chat_id"`
+		APIURL   string `json:"api_url"` // 默认是 https://api.telegram.org
+	} `json:"telegram"`
+	Top       int    `json:"top"`        // 汇总中列出的最快服务器数，默认 5
+	StateFile string `json:"state_file"` // 保存上次运行的可用服务器，用于对比；为空时只在守护模式的相邻两轮之间对比
+}
+
+// 向聊天渠道发送运行汇总
+type chatNotifier struct {
+	cfg  notifyConfig
+	last map[string]bool // 上次运行的可用服务器，为空表示没有上次运行
+}
+
+// 读取 -notify 配置文件
+func newChatNotifier(path string) (*chatNotifier, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	n := &chatNotifier{}
+	if err := json.Unmarshal(data, &n.cfg); err != nil {
+		return nil, fmt.Errorf("无效的通知配置文件 %s: %v", path, err)
+	}
+	if n.cfg.Slack != nil && n.cfg.Slack.WebhookURL == "" {
+		return nil, fmt.Errorf("通知配置文件 %s: slack 需要 webhook_url", path)
+	}
+	if n.cfg.Discord != nil && n.cfg.Discord.WebhookURL == "" {
+		return nil, fmt.Errorf("通知配置文件 %s: discord 需要 webhook_url", path)
+	}
+	if t := n.cfg.Telegram; t != nil && (t.BotToken == "" || t.ChatID == "") {
+		return nil, fmt.Errorf("通知配置文件 %s: telegram 需要 bot_token 和 chat_id", path)
+	}
+	if n.cfg.Top <= 0 {
+		n.cfg.Top = 5
+	}
+	if n.cfg.StateFile != "" {
+		var servers []string
+		data, err := os.ReadFile(n.cfg.StateFile)
+		if err == nil {
+			err = json.Unmarshal(data, &servers)
+		}
+		if err == nil {
+			n.last = serverSet(servers)
+		} else if !os.IsNotExist(err) {
+			return nil, fmt.Errorf("无法读取通知状态文件 %s: %v", n.cfg.StateFile, err)
+		}
+	}
+	return n, nil
+}
+
+func serverSet(servers []string) map[string]bool {
+	set := make(map[string]bool, len(servers))
+	for _, s := range servers {
+		set[s] = true
+	}
+	return set
+}
+
+// 生成汇总消息：可用和不可用数量、最快的服务器以及与上次运行相比的变化，results 为可用的服务器
+func (n *chatNotifier) message(s *runSummary, results []*dnsvalidator.Result) string {
+	var b strings.Builder
+	title := "dnsvalidator 检查完成"
+	if s.Event == eventBelowThreshold {
+		title = fmt.Sprintf("dnsvalidator 警告: 可用服务器少于 %d 台", s.Threshold)
+	}
+	fmt.Fprintf(&b, "%s\n可用 %d 台，不可用 %d 台，用时 %v\n", title, s.Valid, s.Failed, time.Duration(s.DurationMs)*time.Millisecond)
+
+	fastest := append([]*dnsvalidator.Result(nil), results...)
+	sort.SliceStable(fastest, func(i, j int) bool { return fastest[i].LatencyMs < fastest[j].LatencyMs })
+	if len(fastest) > n.cfg.Top {
+		fastest = fastest[:n.cfg.Top]
+	}
+	if len(fastest) > 0 {
+		b.WriteString("最快:")
+		for _, r := range fastest {
+			fmt.Fprintf(&b, " %s (%.1fms)", r.Server, r.LatencyMs)
+		}
+		b.WriteString("\n")
+	}
+
+	if n.last != nil {
+		current := make(map[string]bool, len(results))
+		for _, r := range results {
+			current[r.Server] = true
+		}
+		added, removed := 0, 0
+		for server := range current {
+			if !n.last[server] {
+				added++
+			}
+		}
+		for server := range n.last {
+			if !current[server] {
+				removed++
+			}
+		}
+		fmt.Fprintf(&b, "与上次相比: 新增 %d 台，减少 %d 台\n", added, removed)
+	}
+	if s.Output != "-" {
+		fmt.Fprintf(&b, "输出: %s\n", s.Output)
+	}
+	return strings.TrimRight(b.String(), "\n")
+}
+
+// 向所有配置的渠道发送汇总并记录本次的可用服务器；n 为空时不发送，发送失败只记录日志
+func (n *chatNotifier) notify(s *runSummary, results []*dnsvalidator.Result) {
+	if n == nil {
+		return
+	}
+	valid := make([]*dnsvalidator.Result, 0, len(results))
+	for _, r := range results {
+		if r.Error == "" {
+			valid = append(valid, r)
+		}
+	}
+	text := n.message(s, valid)
+	if c := n.cfg.Slack; c != nil {
+		if err := postJSON(c.WebhookURL, map[string]string{"text": text}); err != nil {
+			log.Printf("发送 Slack 通知失败: %v", err)
+		}
+	}
+	if c := n.cfg.Discord; c != nil {
+		if err := postJSON(c.WebhookURL, map[string]string{"content": text}); err != nil {
+			log.Printf("发送 Discord 通知失败: %v", err)
+		}
+	}
+	if c := n.cfg.Telegram; c != nil {
+		api := c.APIURL
+		if api == "" {
+			api = telegramAPI
+		}
+		url := strings.TrimRight(api, "/") + "/bot" + c.BotToken + "/sendMessage"
+		if err := postJSON(url, map[string]string{"chat_id": c.ChatID, "text": text}); err != nil {
+			// 错误中的 URL 含有 bot token，记录前隐去
+			log.Printf("发送 Telegram 通知失败: %v", strings.ReplaceAll(err.Error(), c.BotToken, "***"))
+		}
+	}
+
+	servers := make([]string, 0, len(valid))
+	for _, r := range valid {
+		servers = append(servers, r.Server)
+	}
+	n.last = serverSet(servers)
+	if n.cfg.StateFile != "" {
+		data, _ := json.Marshal(servers)
+		if err := os.WriteFile(n.cfg.StateFile, data, 0o644); err != nil {
+			log.Printf("无法保存通知状态文件: %v", err)
+		}
+	}
+}
@@ -0,0 +1,238 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"text/template"
+	"time"
+
+	"github.com/badboycxcc/dnsvalidator_go/pkg/dnsvalidator"
+)
+
+// 结果的输出方式，对应 -format、-fields、-template、-sort、-top、-per-prefix 和 -filter-stage post
+type outputOptions struct {
+	format    string
+	fields    []string
+	template  *template.Template
+	sortBy    string
+	top       int
+	perPrefix int
+	filter    *dnsvalidator.GeoFilter // 检查后按国家和 ASN 筛选，为空不筛选
+	keep      bool                    // 保留写出的结果，用于生成报告
+	promote   *promotion              // 守护模式下按最近几轮的结果决定是否输出，为空全部输出
+	db        *dnsvalidator.ResultDB  // -o 为数据库地址时写入的结果数据库，为空写入文件或标准输出
+}
+
+// 写出结果的 dnsvalidator.Writer 或 dnsvalidator.ResultRun
+type resultSink interface {
+	Write(r *dnsvalidator.Result) error
+	Count() int
+	Close() error
+}
+
+// 一次运行的输出目标：标准输出、先写入临时文件再重命名的输出文件，或结果数据库中的一次运行
+type resultOutput struct {
+	file *atomicFile
+	run  *dnsvalidator.ResultRun
+}
+
+// 打开一次运行的输出，path 为空时输出到标准输出，设置了 out.db 时在数据库中开始新的一次运行
+func openOutput(path string, out *outputOptions) (*resultOutput, error) {
+	switch {
+	case out.db != nil:
+		run, err := out.db.Begin(dnsvalidator.NewRunID(time.Now()))
+		if err != nil {
+			return nil, err
+		}
+		return &resultOutput{run: run}, nil
+	case path != "":
+		f, err := createAtomic(path)
+		if err != nil {
+			return nil, err
+		}
+		return &resultOutput{file: f}, nil
+	}
+	return &resultOutput{}, nil
+}
+
+// 按输出方式创建写出结果的 resultSink
+func (o *resultOutput) sink(out *outputOptions) resultSink {
+	if o.run != nil {
+		return o.run
+	}
+	var w io.Writer = os.Stdout
+	if o.file != nil {
+		w = o.file
+	}
+	return dnsvalidator.NewWriter(w, out.format, out.fields, out.template)
+}
+
+// 保存输出：重命名临时文件或提交数据库事务
+func (o *resultOutput) commit() error {
+	switch {
+	case o.run != nil:
+		return o.run.Commit()
+	case o.file != nil:
+		return o.file.commit()
+	}
+	return nil
+}
+
+// 放弃输出：删除临时文件或回滚数据库事务
+func (o *resultOutput) abort() {
+	switch {
+	case o.run != nil:
+		o.run.Rollback()
+	case o.file != nil:
+		o.file.abort()
+	}
+}
+
+// 描述保存的位置，写入数据库时附带运行 ID
+func (o *resultOutput) describe(path string) string {
+	if o.run != nil {
+		return fmt.Sprintf("%s (run_id %s)", path, o.run.RunID())
+	}
+	return path
+}
+
+// 按输出方式将结果写入 dst，返回保留的结果和按网段省略的数量；写入出错时立即返回
+func writeResults(dst *resultOutput, results <-chan *dnsvalidator.Result, out *outputOptions) ([]*dnsvalidator.Result, int, error) {
+	limiter := dnsvalidator.NewPrefixLimiter(out.perPrefix)
+	writer := dst.sink(out)
+	if out.sortBy != "none" {
+		results = dnsvalidator.SortResults(results, out.sortBy)
+	}
+	var written []*dnsvalidator.Result
+	for r := range results {
+		if !out.filter.Allow(r) {
+			continue
+		}
+		if !out.promote.allow(r) {
+			continue
+		}
+		if out.top > 0 && writer.Count() >= out.top {
+			continue
+		}
+		if !limiter.Allow(r.Server) {
+			continue
+		}
+		if err := writer.Write(r); err != nil {
+			return nil, 0, err
+		}
+		if out.keep {
+			written = append(written, r)
+		}
+	}
+	return written, limiter.Skipped(), writer.Close()
+}
+
+// 按国家列出审查探测的汇总，未标注国家的服务器归入 "-"
+func printCensorship(w io.Writer, results []*dnsvalidator.Result) {
+	counts := dnsvalidator.CensorshipByCountry(results)
+	if len(counts) == 0 {
+		return
+	}
+	fmt.Fprintln(w, "按国家统计的审查情况:")
+	for _, c := range counts {
+		country := c.Country
+		if country == "" {
+			country = "-"
+		}
+		domains := make([]string, 0, len(c.Domains))
+		for domain := range c.Domains {
+			domains = append(domains, domain)
+		}
+		sort.Slice(domains, func(i, j int) bool {
+			if c.Domains[domains[i]] != c.Domains[domains[j]] {
+				return c.Domains[domains[i]] > c.Domains[domains[j]]
+			}
+			return domains[i] < domains[j]
+		})
+		for i, domain := range domains {
+			domains[i] = fmt.Sprintf("%s(%d)", domain, c.Domains[domain])
+		}
+		line := fmt.Sprintf("  %-4s 审查 %d/%d 台  %s", country, c.Censoring, c.Tested, strings.Join(domains, " "))
+		fmt.Fprintln(w, strings.TrimRight(line, " "))
+	}
+}
+
+// 输出文件先写入同目录下的临时文件，全部完成后再原子地重命名为目标文件，
+// 运行中断时不会留下被截断的结果列表
+type atomicFile struct {
+	*os.File
+	path string
+}
+
+// 在目标文件所在目录创建临时文件
+func createAtomic(path string) (*atomicFile, error) {
+	dir, base := filepath.Split(path)
+	if dir == "" {
+		dir = "."
+	}
+	f, err := os.CreateTemp(dir, "."+base+".tmp-*")
+	if err != nil {
+		return nil, err
+	}
+	return &atomicFile{File: f, path: path}, nil
+}
+
+// 将临时文件落盘并重命名为目标文件
+func (f *atomicFile) commit() error {
+	if err := f.Chmod(0o644); err != nil {
+		f.abort()
+		return err
+	}
+	if err := f.Sync(); err != nil {
+		f.abort()
+		return err
+	}
+	if err := f.Close(); err != nil {
+		os.Remove(f.Name())
+		return err
+	}
+	return os.Rename(f.Name(), f.path)
+}
+
+// 放弃写入并删除临时文件，输出到标准输出时 f 为空
+func (f *atomicFile) abort() {
+	if f == nil {
+		return
+	}
+	f.Close()
+	os.Remove(f.Name())
+}
+
+// 将历史记录数据库中的全部记录导出为 JSON 文件
+func exportHistory(store *dnsvalidator.ReputationStore, path string) error {
+	reps, err := store.All()
+	if err != nil {
+		return err
+	}
+	f, err := createAtomic(path)
+	if err != nil {
+		return err
+	}
+	if err := dnsvalidator.WriteReputation(f, reps); err != nil {
+		f.abort()
+		return err
+	}
+	return f.commit()
+}
+
+// 将运行汇总以 JSON 写入 path
+func writeSummary(path string, summary *dnsvalidator.Summary) error {
+	f, err := createAtomic(path)
+	if err != nil {
+		return err
+	}
+	if err := summary.WriteJSON(f); err != nil {
+		f.abort()
+		return err
+	}
+	return f.commit()
+}
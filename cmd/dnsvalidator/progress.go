@@ -0,0 +1,52 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/badboycxcc/dnsvalidator_go/pkg/dnsvalidator"
+)
+
+// 每隔 interval 向 w 写一行进度：已检查、可用和失败的服务器数，最近一个间隔的检查速率，
+// 以及按该速率估计的剩余时间。返回的函数停止输出
+func reportProgress(v *dnsvalidator.Validator, interval time.Duration, w io.Writer) (stop func()) {
+	done := make(chan struct{})
+	stopped := make(chan struct{})
+	go func() {
+		defer close(stopped)
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		last := 0
+		for {
+			select {
+			case <-done:
+				return
+			case <-ticker.C:
+			}
+			p := v.Progress()
+			rate := float64(p.Tested-last) / interval.Seconds()
+			last = p.Tested
+			fmt.Fprintln(w, progressLine(p, rate))
+		}
+	}()
+	return func() {
+		close(done)
+		<-stopped
+	}
+}
+
+// 格式化一行进度，rate 为每秒完成检查的服务器数
+func progressLine(p dnsvalidator.Progress, rate float64) string {
+	line := fmt.Sprintf("进度: 已读取 %d 条，已检查 %d 台，可用 %d 台，失败 %d 台，%.1f 台/秒，已用时 %v",
+		p.Read, p.Tested, p.Valid, p.Tested-p.Valid, rate, p.Elapsed.Round(time.Second))
+	switch {
+	case p.Remaining < 0:
+		return line
+	case rate > 0:
+		eta := time.Duration(float64(p.Remaining) / rate * float64(time.Second))
+		return fmt.Sprintf("%s，剩余约 %d 台，预计还需 %v", line, p.Remaining, eta.Round(time.Second))
+	default:
+		return fmt.Sprintf("%s，剩余约 %d 台", line, p.Remaining)
+	}
+}
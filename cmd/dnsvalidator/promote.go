@@ -0,0 +1,103 @@
+package main
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/badboycxcc/dnsvalidator_go/pkg/dnsvalidator"
+)
+
+// 守护模式下按最近几轮的结果决定服务器是否进入输出列表：最近 window 轮中至少通过 need 轮才输出，
+// 偶尔可用的服务器不会进入列表，已输出的服务器通过的轮数不足时自动移出
+type promotion struct {
+	need, window int
+
+	runs      map[string][]bool // 每台服务器最近 window 轮是否通过，最后一个为最近一轮
+	published map[string]bool   // 上一轮输出的服务器
+	passed    map[string]bool   // 本轮通过的服务器
+	promoted  map[string]bool   // 本轮输出的服务器
+}
+
+// 解析 M/N 形式的 -promote 参数
+func parsePromotion(s string) (*promotion, error) {
+	m, n, ok := strings.Cut(s, "/")
+	need, err1 := strconv.Atoi(m)
+	window, err2 := strconv.Atoi(n)
+	if !ok || err1 != nil || err2 != nil || need < 1 || window < need {
+		return nil, fmt.Errorf("无效的 -promote %q，应为 M/N，如 3/5，且 1 <= M <= N", s)
+	}
+	return &promotion{need: need, window: window, runs: make(map[string][]bool), published: make(map[string]bool)}, nil
+}
+
+// 开始新的一轮，丢弃上一轮未提交的结果；p 为空时不做任何事
+func (p *promotion) begin() {
+	if p == nil {
+		return
+	}
+	p.passed, p.promoted = make(map[string]bool), make(map[string]bool)
+}
+
+// 记录本轮通过的服务器，返回是否输出：之前 window-1 轮加上本轮至少通过 need 轮；
+// p 为空时全部输出，-include-failed 送出的失败结果照常输出且不计为通过
+func (p *promotion) allow(r *dnsvalidator.Result) bool {
+	if p == nil || r.Error != "" {
+		return true
+	}
+	p.passed[r.Server] = true
+	runs := p.runs[r.Server]
+	if len(runs) >= p.window {
+		runs = runs[len(runs)-p.window+1:]
+	}
+	n := 1
+	for _, ok := range runs {
+		if ok {
+			n++
+		}
+	}
+	if n < p.need {
+		return false
+	}
+	p.promoted[r.Server] = true
+	return true
+}
+
+// 本轮成功完成后记入历史，本轮未通过的已知服务器记为失败，最近 window 轮都未通过的服务器不再记录。
+// 返回本轮新进入和移出输出列表的服务器数
+func (p *promotion) commit() (promoted, demoted int) {
+	if p == nil {
+		return 0, 0
+	}
+	for server := range p.passed {
+		if _, ok := p.runs[server]; !ok {
+			p.runs[server] = nil
+		}
+	}
+	for server, runs := range p.runs {
+		runs = append(runs, p.passed[server])
+		if len(runs) > p.window {
+			runs = runs[len(runs)-p.window:]
+		}
+		alive := false
+		for _, ok := range runs {
+			alive = alive || ok
+		}
+		if !alive {
+			delete(p.runs, server)
+			continue
+		}
+		p.runs[server] = runs
+	}
+	for server := range p.promoted {
+		if !p.published[server] {
+			promoted++
+		}
+	}
+	for server := range p.published {
+		if !p.promoted[server] {
+			demoted++
+		}
+	}
+	p.published = p.promoted
+	return promoted, demoted
+}
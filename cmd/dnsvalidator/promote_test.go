@@ -0,0 +1,90 @@
+package main
+
+import (
+	"slices"
+	"sort"
+	"testing"
+
+	"github.com/badboycxcc/dnsvalidator_go/pkg/dnsvalidator"
+)
+
+func TestParsePromotion(t *testing.T) {
+	tests := []struct {
+		arg          string
+		need, window int
+		ok           bool
+	}{
+		{"3/5", 3, 5, true},
+		{"1/1", 1, 1, true},
+		{"0/3", 0, 0, false},
+		{"4/3", 0, 0, false},
+		{"3", 0, 0, false},
+		{"a/b", 0, 0, false},
+	}
+	for _, tt := range tests {
+		p, err := parsePromotion(tt.arg)
+		if (err == nil) != tt.ok {
+			t.Errorf("parsePromotion(%q) 返回 %v", tt.arg, err)
+			continue
+		}
+		if tt.ok && (p.need != tt.need || p.window != tt.window) {
+			t.Errorf("parsePromotion(%q) = %d/%d，应为 %d/%d", tt.arg, p.need, p.window, tt.need, tt.window)
+		}
+	}
+}
+
+// 最近 3 轮中至少通过 2 轮才输出，通过的轮数不足时移出，最近 3 轮都未通过的服务器不再记录
+func TestPromotionWindow(t *testing.T) {
+	p, err := parsePromotion("2/3")
+	if err != nil {
+		t.Fatal(err)
+	}
+	rounds := []struct {
+		passed            []string
+		allowed           []string // 本轮输出的服务器
+		promoted, demoted int
+	}{
+		{[]string{"a", "b"}, nil, 0, 0},
+		{[]string{"a"}, []string{"a"}, 1, 0},
+		{[]string{"b"}, []string{"b"}, 1, 1},
+		// a 最近 3 轮为 通过、通过、未通过，本轮只看之前 2 轮加本轮
+		{[]string{"a"}, []string{"a"}, 1, 1},
+		{nil, nil, 0, 1},
+		{nil, nil, 0, 0},
+		{nil, nil, 0, 0},
+		// a 和 b 都已被遗忘，重新开始计数
+		{[]string{"a"}, nil, 0, 0},
+	}
+	for i, round := range rounds {
+		p.begin()
+		var allowed []string
+		for _, server := range round.passed {
+			if p.allow(&dnsvalidator.Result{Server: server}) {
+				allowed = append(allowed, server)
+			}
+		}
+		// 失败的结果照常输出且不计为通过
+		if !p.allow(&dnsvalidator.Result{Server: "failed", Error: "timeout"}) {
+			t.Errorf("第 %d 轮: 失败的结果没有输出", i+1)
+		}
+		sort.Strings(allowed)
+		if !slices.Equal(allowed, round.allowed) {
+			t.Errorf("第 %d 轮: 输出的服务器为 %v，应为 %v", i+1, allowed, round.allowed)
+		}
+		promoted, demoted := p.commit()
+		if promoted != round.promoted || demoted != round.demoted {
+			t.Errorf("第 %d 轮: 新进入 %d 台、移出 %d 台，应为 %d 台和 %d 台", i+1, promoted, demoted, round.promoted, round.demoted)
+		}
+		if i == 6 && len(p.runs) != 0 {
+			t.Errorf("第 %d 轮: 仍记录着 %v", i+1, p.runs)
+		}
+	}
+
+	// 未提交的一轮不计入历史
+	p.begin()
+	p.allow(&dnsvalidator.Result{Server: "c"})
+	p.begin()
+	if p.allow(&dnsvalidator.Result{Server: "c"}) {
+		t.Error("未提交的一轮被计入了历史")
+	}
+}
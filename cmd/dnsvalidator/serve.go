@@ -0,0 +1,269 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net"
+	"net/http"
+	"os"
+	"os/signal"
+	"sort"
+	"strings"
+	"syscall"
+	"time"
+
+	"google.golang.org/grpc"
+
+	"github.com/badboycxcc/dnsvalidator_go/pkg/dnsvalidator"
+	pb "github.com/badboycxcc/dnsvalidator_go/pkg/dnsvalidatorpb"
+)
+
+// 以 JSON 写出任务状态；持有锁时只生成状态的快照，写出响应时不阻塞运行中的任务
+func (j *job) writeStatus(w http.ResponseWriter, code int) {
+	j.mu.Lock()
+	j.refresh()
+	data, err := json.Marshal(j)
+	j.mu.Unlock()
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+	writeJSON(w, code, json.RawMessage(data))
+}
+
+// 注册 API 路由
+func (s *server) routes() *http.ServeMux {
+	mux := http.NewServeMux()
+	mux.HandleFunc("POST /jobs", s.handleSubmit)
+	mux.HandleFunc("GET /jobs", s.handleList)
+	mux.HandleFunc("GET /jobs/{id}", s.handleStatus)
+	mux.HandleFunc("GET /jobs/{id}/results", s.handleResults)
+	mux.HandleFunc("DELETE /jobs/{id}", s.handleCancel)
+	s.metrics.register(mux)
+	return mux
+}
+
+// 读取请求体：JSON 为 jobRequest，其他类型按每行一个条目解析
+func readJobRequest(r *http.Request) (*jobRequest, error) {
+	body := io.LimitReader(r.Body, 64<<20)
+	req := &jobRequest{}
+	if strings.HasPrefix(r.Header.Get("Content-Type"), "application/json") {
+		if err := json.NewDecoder(body).Decode(req); err != nil {
+			return nil, fmt.Errorf("无效的 JSON 请求: %v", err)
+		}
+		return req, nil
+	}
+	scanner := bufio.NewScanner(body)
+	for scanner.Scan() {
+		if line := strings.TrimSpace(scanner.Text()); line != "" {
+			req.Servers = append(req.Servers, line)
+		}
+	}
+	return req, scanner.Err()
+}
+
+// POST /jobs：提交一个检查任务，返回任务 ID
+func (s *server) handleSubmit(w http.ResponseWriter, r *http.Request) {
+	req, err := readJobRequest(r)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+	j, err := s.submit(req)
+	if errors.Is(err, errQueueFull) {
+		writeError(w, http.StatusServiceUnavailable, err)
+		return
+	}
+	if errors.Is(err, errURLsDenied) {
+		writeError(w, http.StatusForbidden, err)
+		return
+	}
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+	w.Header().Set("Location", "/jobs/"+j.ID)
+	j.writeStatus(w, http.StatusAccepted)
+}
+
+// 按 ID 查找任务，不存在时返回 404
+func (s *server) lookup(w http.ResponseWriter, r *http.Request) *job {
+	j := s.job(r.PathValue("id"))
+	if j == nil {
+		writeError(w, http.StatusNotFound, errJobNotFound)
+	}
+	return j
+}
+
+// GET /jobs：列出所有任务，最新的在前
+func (s *server) handleList(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	jobs := make([]*job, 0, len(s.order))
+	for _, id := range s.order {
+		jobs = append(jobs, s.jobs[id])
+	}
+	s.mu.Unlock()
+	sort.SliceStable(jobs, func(a, b int) bool { return jobs[a].Created.After(jobs[b].Created) })
+
+	list := make([]json.RawMessage, 0, len(jobs))
+	for _, j := range jobs {
+		j.mu.Lock()
+		j.refresh()
+		data, _ := json.Marshal(j)
+		j.mu.Unlock()
+		list = append(list, data)
+	}
+	writeJSON(w, http.StatusOK, list)
+}
+
+// GET /jobs/{id}：任务状态和统计
+func (s *server) handleStatus(w http.ResponseWriter, r *http.Request) {
+	if j := s.lookup(w, r); j != nil {
+		j.writeStatus(w, http.StatusOK)
+	}
+}
+
+// GET /jobs/{id}/results：已通过检查的服务器，运行中的任务返回目前已有的结果；
+// format 参数可以指定 text、jsonl、csv 等输出格式，默认是 json
+func (s *server) handleResults(w http.ResponseWriter, r *http.Request) {
+	j := s.lookup(w, r)
+	if j == nil {
+		return
+	}
+	format := r.URL.Query().Get("format")
+	if format == "" {
+		format = "json"
+	}
+	if !dnsvalidator.ValidFormat(format) || format == "template" {
+		writeError(w, http.StatusBadRequest, fmt.Errorf("不支持的输出格式 %s", format))
+		return
+	}
+	contentType := "text/plain; charset=utf-8"
+	switch format {
+	case "json":
+		contentType = "application/json"
+	case "jsonl":
+		contentType = "application/x-ndjson"
+	case "csv":
+		contentType = "text/csv"
+	}
+	w.Header().Set("Content-Type", contentType)
+
+	// 结果只会追加，取出目前的切片后在锁外写出，读取较慢的客户端不会阻塞任务记录新结果
+	j.mu.Lock()
+	results := j.results
+	j.mu.Unlock()
+	writer := dnsvalidator.NewWriter(w, format, nil, nil)
+	for _, res := range results {
+		if err := writer.Write(res); err != nil {
+			return
+		}
+	}
+	writer.Close()
+}
+
+// DELETE /jobs/{id}：取消排队或运行中的任务
+func (s *server) handleCancel(w http.ResponseWriter, r *http.Request) {
+	j := s.lookup(w, r)
+	if j == nil {
+		return
+	}
+	s.cancel(j)
+	j.writeStatus(w, http.StatusOK)
+}
+
+// 写出 JSON 响应
+func writeJSON(w http.ResponseWriter, code int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(code)
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	enc.Encode(v)
+}
+
+// 写出 JSON 格式的错误
+func writeError(w http.ResponseWriter, code int, err error) {
+	writeJSON(w, code, map[string]string{"error": err.Error()})
+}
+
+// 以服务模式运行：在 httpAddr 上提供 REST API 和 /metrics、/healthz、/readyz，在 grpcAddr 上提供 gRPC 服务，
+// 在 metricsAddr 上单独提供 /metrics、/healthz、/readyz (为空时不提供)，共用任务队列；
+// allowURLs 为 false 时拒绝带在线列表 URL 的任务。收到 SIGINT/SIGTERM 时取消运行中的任务并退出
+func serve(httpAddr, grpcAddr, metricsAddr string, base dnsvalidator.Options, allowURLs bool) error {
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	m := newMetrics()
+	s := newServer(base, m)
+	s.allowURLs = allowURLs
+	go s.runJobs(ctx)
+	errs := make(chan error, 3)
+
+	if metricsAddr != "" {
+		ln, err := net.Listen("tcp", metricsAddr)
+		if err != nil {
+			return err
+		}
+		go func() { errs <- serveMetrics(ctx, ln, s.metrics) }()
+		log.Printf("指标服务已启动: http://%s/metrics", metricsAddr)
+	}
+
+	if httpAddr != "" {
+		ln, err := net.Listen("tcp", httpAddr)
+		if err != nil {
+			return err
+		}
+		srv := &http.Server{Handler: s.routes(), ReadHeaderTimeout: 10 * time.Second}
+		go func() {
+			<-ctx.Done()
+			shutdown, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+			defer cancel()
+			srv.Shutdown(shutdown)
+		}()
+		go func() {
+			if err := srv.Serve(ln); err != nil && !errors.Is(err, http.ErrServerClosed) {
+				errs <- err
+				return
+			}
+			errs <- nil
+		}()
+		log.Printf("REST API 服务已启动: http://%s", httpAddr)
+	}
+
+	if grpcAddr != "" {
+		ln, err := net.Listen("tcp", grpcAddr)
+		if err != nil {
+			return err
+		}
+		srv := grpc.NewServer()
+		pb.RegisterDNSValidatorServer(srv, &grpcService{s: s})
+		go func() {
+			<-ctx.Done()
+			srv.GracefulStop()
+		}()
+		go func() { errs <- srv.Serve(ln) }()
+		log.Printf("gRPC 服务已启动: %s", ln.Addr())
+	}
+
+	// 启动自检：各服务 (包括单独的指标服务) 都已开始监听，且默认配置可以创建 Validator，否则直到有任务完成前 /readyz 都返回 503
+	if _, err := dnsvalidator.New(base); err != nil {
+		log.Printf("服务自检失败，暂不就绪: %v", err)
+	} else {
+		m.selfChecked()
+	}
+
+	// 任一服务出错时退出，正常关闭时等待信号
+	select {
+	case err := <-errs:
+		if err != nil {
+			return err
+		}
+	case <-ctx.Done():
+	}
+	return nil
+}
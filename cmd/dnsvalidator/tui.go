@@ -0,0 +1,207 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+
+	"github.com/badboycxcc/dnsvalidator_go/pkg/dnsvalidator"
+)
+
+// 仪表盘的刷新间隔和保留的最近检查条数
+const (
+	tuiInterval = time.Second
+	tuiRecent   = 200
+)
+
+// 运行中的一个事件，或事件通道已关闭 (运行结束)
+type tuiEventMsg struct {
+	event dnsvalidator.Event
+	ok    bool
+}
+
+type tuiTickMsg time.Time
+
+// -tui 的仪表盘：实时计数、最近检查的滚动日志和延迟分布，可以暂停检查和调整并发
+type dashboard struct {
+	v      *dnsvalidator.Validator
+	events <-chan dnsvalidator.Event
+	grace  time.Duration
+	cancel context.CancelFunc
+
+	recent   []string // 最近的检查，最后一个为最近一次
+	valid    []*dnsvalidator.Result
+	progress dnsvalidator.Progress
+	summary  *dnsvalidator.Summary
+	rate     float64 // 最近一个刷新间隔内每秒完成检查的服务器数
+	last     int     // 上次刷新时已检查的服务器数
+	stopping bool    // 已请求停止，再次按 q 取消进行中的检查
+	width    int
+	height   int
+}
+
+// 运行仪表盘直到事件通道关闭，即所有检查结束。v 应以 Interactive 运行，events 需在 v.Run 之前订阅
+func runTUI(v *dnsvalidator.Validator, events <-chan dnsvalidator.Event, grace time.Duration, cancel context.CancelFunc) error {
+	m := &dashboard{v: v, events: events, grace: grace, cancel: cancel, width: 80, height: 24}
+	_, err := tea.NewProgram(m, tea.WithAltScreen()).Run()
+	return err
+}
+
+func (m *dashboard) Init() tea.Cmd {
+	m.refresh()
+	return tea.Batch(m.waitEvent(), tuiTick())
+}
+
+// 等待下一个事件
+func (m *dashboard) waitEvent() tea.Cmd {
+	return func() tea.Msg {
+		e, ok := <-m.events
+		return tuiEventMsg{event: e, ok: ok}
+	}
+}
+
+func tuiTick() tea.Cmd {
+	return tea.Tick(tuiInterval, func(t time.Time) tea.Msg { return tuiTickMsg(t) })
+}
+
+func (m *dashboard) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case tuiEventMsg:
+		if !msg.ok {
+			return m, tea.Quit
+		}
+		m.record(msg.event)
+		return m, m.waitEvent()
+	case tuiTickMsg:
+		m.refresh()
+		return m, tuiTick()
+	case tea.WindowSizeMsg:
+		m.width, m.height = msg.Width, msg.Height
+	case tea.KeyMsg:
+		m.key(msg.String())
+	}
+	return m, nil
+}
+
+// 处理按键：p 或空格暂停和继续，+ 和 - 按当前值的十分之一调整并发，q 停止运行
+func (m *dashboard) key(key string) {
+	threads, paused := m.v.Threads()
+	step := max(threads/10, 1)
+	switch key {
+	case "p", " ":
+		if paused {
+			m.v.Resume()
+		} else {
+			m.v.Pause()
+		}
+	case "+", "=", "up":
+		m.v.SetThreads(threads + step)
+	case "-", "_", "down":
+		m.v.SetThreads(threads - step)
+	case "q", "ctrl+c":
+		if m.stopping {
+			m.cancel()
+			return
+		}
+		m.stopping = true
+		m.v.Stop(m.grace)
+	}
+}
+
+// 将完成的检查记入滚动日志，通过的服务器用于统计延迟分布
+func (m *dashboard) record(e dnsvalidator.Event) {
+	var line string
+	switch e.Type {
+	case dnsvalidator.EventResolved:
+		m.valid = append(m.valid, e.Result)
+		line = fmt.Sprintf("可用  %-40s %gms", e.Server, e.Result.LatencyMs)
+	case dnsvalidator.EventFailed:
+		category := ""
+		var ce *dnsvalidator.CheckError
+		if errors.As(e.Err, &ce) {
+			category = ce.Category
+		}
+		line = fmt.Sprintf("失败  %-40s [%s] %v", e.Server, category, e.Err)
+	default:
+		return
+	}
+	m.recent = append(m.recent, line)
+	if len(m.recent) > tuiRecent {
+		m.recent = m.recent[len(m.recent)-tuiRecent:]
+	}
+}
+
+// 刷新计数、检查速率和延迟分布
+func (m *dashboard) refresh() {
+	m.progress = m.v.Progress()
+	m.rate = float64(m.progress.Tested-m.last) / tuiInterval.Seconds()
+	m.last = m.progress.Tested
+	m.summary = dnsvalidator.NewSummary(m.v.Stats(), m.valid, m.progress.Elapsed, 0)
+}
+
+func (m *dashboard) View() string {
+	var b strings.Builder
+	p, s := m.progress, m.summary
+	threads, paused := m.v.Threads()
+	state := "运行中"
+	switch {
+	case m.stopping:
+		state = "正在停止"
+	case paused:
+		state = "已暂停"
+	}
+	fmt.Fprintf(&b, "DNS 服务器检查  [%s]\n\n", state)
+	fmt.Fprintf(&b, "已读取 %d 条  已检查 %d 台  可用 %d 台  失败 %d 台\n", p.Read, p.Tested, p.Valid, p.Tested-p.Valid)
+	fmt.Fprintf(&b, "并发 %d  速率 %.1f 台/秒  已用时 %v", threads, m.rate, p.Elapsed.Round(time.Second))
+	if p.Remaining >= 0 {
+		fmt.Fprintf(&b, "  剩余约 %d 台", p.Remaining)
+		if m.rate > 0 {
+			eta := time.Duration(float64(p.Remaining) / m.rate * float64(time.Second))
+			fmt.Fprintf(&b, "  预计还需 %v", eta.Round(time.Second))
+		}
+	}
+	b.WriteString("\n\n")
+
+	lines := 8 // 计数、最近检查的标题和按键说明占用的行数
+	if s.Latency.Max > 0 {
+		fmt.Fprintf(&b, "延迟 (毫秒): p50 %g  p90 %g  p99 %g  最大 %g\n", s.Latency.P50, s.Latency.P90, s.Latency.P99, s.Latency.Max)
+		most := 0
+		for _, bucket := range s.Latency.Buckets {
+			most = max(most, bucket.Count)
+		}
+		width := max(m.width-30, 10)
+		for _, bucket := range s.Latency.Buckets {
+			bar := 0
+			if most > 0 {
+				bar = bucket.Count * width / most
+			}
+			fmt.Fprintf(&b, "  %-12s %6d %s\n", bucket.Label, bucket.Count, strings.Repeat("█", bar))
+		}
+		b.WriteString("\n")
+		lines += len(s.Latency.Buckets) + 2
+	}
+
+	b.WriteString("最近的检查:\n")
+	n := min(max(m.height-lines, 1), len(m.recent))
+	for _, line := range m.recent[len(m.recent)-n:] {
+		b.WriteString("  " + truncate(line, m.width-2) + "\n")
+	}
+	for i := n; i < max(m.height-lines, 1); i++ {
+		b.WriteString("\n")
+	}
+	b.WriteString("\np/空格 暂停或继续  +/- 调整并发  q 停止 (再按一次取消进行中的检查)")
+	return b.String()
+}
+
+// 截断超过 width 个字符的行
+func truncate(s string, width int) string {
+	r := []rune(s)
+	if width <= 0 || len(r) <= width {
+		return s
+	}
+	return string(r[:width])
+}
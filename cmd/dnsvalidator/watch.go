@@ -0,0 +1,70 @@
+package main
+
+import (
+	"context"
+	"log"
+	"path/filepath"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+)
+
+// 列表文件变化后等待的时间，期间的多次写入只触发一次检查
+const watchDebounce = 500 * time.Millisecond
+
+// 监视列表文件，文件被写入、创建或被其他文件替换时向返回的通道发送通知，直到 ctx 取消。
+// 监视的是文件所在的目录，编辑器先写临时文件再重命名的保存方式同样能被发现
+func watchFiles(ctx context.Context, paths []string) (<-chan struct{}, error) {
+	w, err := fsnotify.NewWatcher()
+	if err != nil {
+		return nil, err
+	}
+	names := make(map[string]bool, len(paths))
+	dirs := make(map[string]bool)
+	for _, path := range paths {
+		abs, err := filepath.Abs(path)
+		if err != nil {
+			w.Close()
+			return nil, err
+		}
+		names[abs] = true
+		if dir := filepath.Dir(abs); !dirs[dir] {
+			if err := w.Add(dir); err != nil {
+				w.Close()
+				return nil, err
+			}
+			dirs[dir] = true
+		}
+	}
+
+	changed := make(chan struct{}, 1)
+	go func() {
+		defer w.Close()
+		var debounce <-chan time.Time
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case e, ok := <-w.Events:
+				if !ok {
+					return
+				}
+				if names[e.Name] && (e.Has(fsnotify.Write) || e.Has(fsnotify.Create)) {
+					debounce = time.After(watchDebounce)
+				}
+			case err, ok := <-w.Errors:
+				if !ok {
+					return
+				}
+				log.Println("监视列表文件时出错：", err)
+			case <-debounce:
+				debounce = nil
+				select {
+				case changed <- struct{}{}:
+				default:
+				}
+			}
+		}
+	}()
+	return changed, nil
+}
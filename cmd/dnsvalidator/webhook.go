@@ -0,0 +1,82 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/badboycxcc/dnsvalidator_go/pkg/dnsvalidator"
+)
+
+// Webhook 事件
+const (
+	eventFinished       = "finished"        // 运行完成
+	eventBelowThreshold = "below_threshold" // 运行完成且可用服务器数低于 -webhook-min
+)
+
+// 一次运行的汇总，以 JSON 发送给 Webhook
+type runSummary struct {
+	Event       string         `json:"event"`
+	Time        time.Time      `json:"time"`
+	Tested      int            `json:"tested"`
+	Valid       int            `json:"valid"`
+	Failed      int            `json:"failed"`
+	Failures    map[string]int `json:"failures,omitempty"`
+	DurationMs  int64          `json:"duration_ms"`
+	Output      string         `json:"output"`              // 输出文件路径，输出到标准输出时为 -
+	Threshold   int            `json:"threshold,omitempty"` // -webhook-min
+	Interrupted bool           `json:"interrupted,omitempty"`
+}
+
+// 按运行统计生成汇总，可用服务器数低于 threshold 时事件为 below_threshold
+func newRunSummary(stats *dnsvalidator.Stats, elapsed time.Duration, output string, threshold int) *runSummary {
+	if output == "" {
+		output = "-"
+	}
+	s := &runSummary{
+		Event:      eventFinished,
+		Time:       time.Now(),
+		Tested:     stats.Tested(),
+		Valid:      stats.Valid(),
+		Failures:   stats.Failures(),
+		DurationMs: elapsed.Milliseconds(),
+		Output:     output,
+		Threshold:  threshold,
+	}
+	s.Failed = s.Tested - s.Valid
+	if threshold > 0 && s.Valid < threshold {
+		s.Event = eventBelowThreshold
+	}
+	return s
+}
+
+// 将 v 以 JSON POST 到 url，非 2xx 响应视为失败
+func postJSON(url string, v interface{}) error {
+	body, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	client := &http.Client{Timeout: 10 * time.Second}
+	resp, err := client.Post(url, "application/json", bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return fmt.Errorf("HTTP %d", resp.StatusCode)
+	}
+	return nil
+}
+
+// 发送 Webhook 通知，url 为空时不发送；发送失败只记录日志，不影响运行结果
+func notifyWebhook(url string, s *runSummary) {
+	if url == "" {
+		return
+	}
+	if err := postJSON(url, s); err != nil {
+		log.Printf("发送 Webhook 通知失败: %v", err)
+	}
+}
@@ -0,0 +1,75 @@
+package dnsvalidator
+
+import (
+	"sort"
+	"sync"
+	"time"
+)
+
+// 计算全局中位数前至少需要的 RTT 样本数
+const adaptiveWarmup = 20
+
+// 根据运行中观测到的 RTT 动态调整每次查询的超时：优先使用该服务器自身的 RTT 中位数，
+// 还没有样本时使用所有服务器最近 RTT 的中位数，乘以 factor 后限制在 [min, max] 之间
+type adaptiveTimeout struct {
+	mu      sync.Mutex
+	samples []time.Duration // 最近的 RTT，环形缓冲
+	next    int
+	factor  float64
+	min     time.Duration
+	max     time.Duration
+}
+
+func newAdaptiveTimeout(factor float64, min, max time.Duration) *adaptiveTimeout {
+	return &adaptiveTimeout{samples: make([]time.Duration, 0, 1000), factor: factor, min: min, max: max}
+}
+
+// 记录一次成功查询的 RTT
+func (a *adaptiveTimeout) observe(rtt time.Duration) {
+	if a == nil {
+		return
+	}
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if len(a.samples) < cap(a.samples) {
+		a.samples = append(a.samples, rtt)
+		return
+	}
+	a.samples[a.next] = rtt
+	a.next = (a.next + 1) % len(a.samples)
+}
+
+// 返回下一次查询的超时，own 为该服务器此前的 RTT，样本不足时返回 fallback
+func (a *adaptiveTimeout) timeout(own []time.Duration, fallback time.Duration) time.Duration {
+	if a == nil {
+		return fallback
+	}
+	var base time.Duration
+	if len(own) > 0 {
+		base = median(own)
+	} else {
+		a.mu.Lock()
+		if len(a.samples) >= adaptiveWarmup {
+			base = median(a.samples)
+		}
+		a.mu.Unlock()
+		if base == 0 {
+			return fallback
+		}
+	}
+	t := time.Duration(float64(base) * a.factor)
+	if t < a.min {
+		t = a.min
+	}
+	if t > a.max {
+		t = a.max
+	}
+	return t
+}
+
+// 返回 RTT 的中位数
+func median(rtts []time.Duration) time.Duration {
+	sorted := append([]time.Duration(nil), rtts...)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i] < sorted[j] })
+	return sorted[len(sorted)/2]
+}
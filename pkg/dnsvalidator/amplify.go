@@ -0,0 +1,56 @@
+package dnsvalidator
+
+import "fmt"
+
+// ANY 查询类型
+const typeANY uint16 = 255
+
+// 放大倍数探测：滥用者常用的 ANY、DNSKEY 和 TXT 查询，name 为空时使用检查的域名
+var amplificationProbes = []struct {
+	label string
+	name  string
+	qtype uint16
+}{
+	{"ANY", "", typeANY},
+	{"DNSKEY", ".", typeDNSKEY},
+	{"TXT", "", typeTXT},
+}
+
+// 以 4096 字节的 EDNS0 缓冲区通过 UDP 发送各项探测，返回应答与查询字节数之比的最大值及对应的查询类型；
+// 不自动改用 TCP，截断的应答按实际收到的大小计算
+func amplification(c *client, server, domain string) (float64, string) {
+	var ratio float64
+	var probe string
+	for _, p := range amplificationProbes {
+		name := p.name
+		if name == "" {
+			name = domain
+		}
+		query := newQuery(name, p.qtype)
+		query.setEDNS(4096, true)
+		req, err := query.pack()
+		if err != nil {
+			continue
+		}
+		resp, _, err := exchangeUDP(c.ctx, c.net, server, query, c.timeout)
+		if err != nil {
+			continue
+		}
+		if r := float64(resp.Size) / float64(len(req)); r > ratio {
+			ratio, probe = r, p.label
+		}
+	}
+	return ratio, probe
+}
+
+// 记录放大倍数，超过 max 时返回失败；max 为 0 时只记录，所有探测都没有应答时跳过
+func checkAmplification(c *client, server, domain string, max float64) CheckResult {
+	ratio, probe := amplification(c, server, domain)
+	if probe == "" {
+		return CheckResult{Skip: true}
+	}
+	if max > 0 && ratio > max {
+		return CheckResult{Err: newFailure(failAmplification, "%s 查询的放大倍数为 %.1f，超过 %g", probe, ratio, max)}
+	}
+	return CheckResult{Attrs: []string{fmt.Sprintf("amplification=%.1f", ratio), "amplification_probe=" + probe}}
+}
@@ -0,0 +1,41 @@
+package dnsvalidator
+
+import (
+	"context"
+	"slices"
+	"testing"
+	"time"
+)
+
+// 放大倍数只在 UDP 上检查，其他传输协议不记入通过的检查
+func TestAmplificationTransport(t *testing.T) {
+	mock := NewMockServer()
+	mock.Default = mockResolver()
+	for _, tt := range []struct {
+		transport string
+		checked   bool
+	}{
+		{"udp", true},
+		{"tcp", false},
+	} {
+		v, err := NewValidator(WithDomains("google.com"), WithTimeout(time.Second), WithExchanger(mock),
+			WithTransport(tt.transport), WithChecks("amplification"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		results, err := v.Run(context.Background(), Sources{Servers: []string{"8.8.8.8"}})
+		if err != nil {
+			t.Fatal(err)
+		}
+		n := 0
+		for r := range results {
+			n++
+			if got := slices.Contains(r.Checks, "amplification"); got != tt.checked {
+				t.Errorf("%s: 通过的检查为 %v", tt.transport, r.Checks)
+			}
+		}
+		if n != 1 {
+			t.Errorf("%s: 通过检查的服务器为 %d 台，应为 1 台", tt.transport, n)
+		}
+	}
+}
@@ -0,0 +1,171 @@
+package dnsvalidator
+
+import (
+	"fmt"
+	"net/netip"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/oschwald/maxminddb-golang/v2"
+)
+
+// 查询服务器 IP 所属的自治系统 (ASN) 及其组织名称，数据来自 MaxMind GeoLite2-ASN 数据库
+// 或 Team Cymru 的 DNS 接口。可以在多个 Validator 之间共享
+type ASNLookup struct {
+	db     *maxminddb.Reader // mmdb 数据库，为空时使用 Team Cymru
+	server string            // 查询 Team Cymru 所用的 DNS 服务器
+
+	mu   sync.Mutex
+	orgs map[uint]string // Team Cymru 组织名称的缓存
+}
+
+// GeoLite2-ASN 数据库中的记录
+type asnRecord struct {
+	Number       uint   `maxminddb:"autonomous_system_number"`
+	Organization string `maxminddb:"autonomous_system_organization"`
+}
+
+// 打开 mmdb 格式的 GeoLite2-ASN 或 GeoIP2-ISP 数据库
+func OpenASN(path string) (*ASNLookup, error) {
+	db, err := maxminddb.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("无法打开 ASN 数据库: %v", err)
+	}
+	if t := db.Metadata.DatabaseType; !strings.Contains(t, "ASN") && !strings.Contains(t, "ISP") {
+		db.Close()
+		return nil, fmt.Errorf("%s 不是 ASN 数据库 (%s)", path, t)
+	}
+	return &ASNLookup{db: db}, nil
+}
+
+// 通过 server (如 1.1.1.1 或 1.1.1.1:53，为空时使用 1.1.1.1) 查询 Team Cymru 的 origin.asn.cymru.com，
+// 每台服务器需要额外发出一到两次 TXT 查询
+func CymruASN(server string) *ASNLookup {
+	if server == "" {
+		server = "1.1.1.1"
+	}
+	return &ASNLookup{server: bootstrapAddr(server), orgs: make(map[uint]string)}
+}
+
+// 关闭数据库
+func (a *ASNLookup) Close() error {
+	if a.db == nil {
+		return nil
+	}
+	return a.db.Close()
+}
+
+// 按服务器 IP 填入 ASN 和 ASOrg，并在文本输出中加入 asn 列；
+// 没有本地数据库时通过 c 查询 Team Cymru，a 为空、DoH 服务器或查不到时不做修改
+func (a *ASNLookup) annotate(c *client, r *Result) {
+	if a == nil || r.IP == "" {
+		return
+	}
+	ip, err := netip.ParseAddr(strings.Trim(r.IP, "[]"))
+	if err != nil {
+		return
+	}
+	if r.ASN, r.ASOrg = a.lookup(c, ip); r.ASN != 0 {
+		r.attrs = append(r.attrs, "asn="+strconv.FormatUint(uint64(r.ASN), 10))
+	}
+}
+
+// 返回 ip 所属的 ASN 及组织名称，没有本地数据库时通过 c 查询 Team Cymru，查不到时 ASN 为 0
+func (a *ASNLookup) lookup(c *client, ip netip.Addr) (uint, string) {
+	ip = ip.Unmap()
+	if a.db == nil {
+		return a.cymru(c, ip)
+	}
+	var rec asnRecord
+	res := a.db.Lookup(ip)
+	if !res.Found() || res.Decode(&rec) != nil {
+		return 0, ""
+	}
+	return rec.Number, rec.Organization
+}
+
+// 查询 Team Cymru：<反转地址>.origin.asn.cymru.com 返回 "13335 | 1.1.1.0/24 | AU | apnic | 2011-08-11"，
+// as13335.asn.cymru.com 返回 "13335 | US | arin | 2010-07-14 | CLOUDFLARENET, US"
+func (a *ASNLookup) cymru(c *client, ip netip.Addr) (uint, string) {
+	zone := ".origin.asn.cymru.com"
+	if ip.Is6() {
+		zone = ".origin6.asn.cymru.com"
+	}
+	fields := a.cymruTXT(c, reverseLabels(ip)+zone)
+	if len(fields) == 0 {
+		return 0, ""
+	}
+	// 地址属于多个 AS 时第一个字段为空格分隔的多个 ASN，取第一个
+	asn, err := strconv.ParseUint(strings.Fields(fields[0] + " ")[0], 10, 32)
+	if err != nil || asn == 0 {
+		return 0, ""
+	}
+	a.mu.Lock()
+	org, ok := a.orgs[uint(asn)]
+	a.mu.Unlock()
+	if !ok {
+		if fields := a.cymruTXT(c, "as"+strconv.FormatUint(asn, 10)+".asn.cymru.com"); len(fields) >= 5 {
+			org = fields[4]
+		}
+		a.mu.Lock()
+		a.orgs[uint(asn)] = org
+		a.mu.Unlock()
+	}
+	return uint(asn), org
+}
+
+// 查询 TXT 记录并按 | 拆分为字段
+func (a *ASNLookup) cymruTXT(c *client, name string) []string {
+	resp, _, err := c.exchange(a.server, newQuery(name, typeTXT))
+	if err != nil || resp.RCode != rcodeSuccess {
+		return nil
+	}
+	values := resp.answerValues(typeTXT)
+	if len(values) == 0 {
+		return nil
+	}
+	fields := strings.Split(values[0], "|")
+	for i := range fields {
+		fields[i] = strings.TrimSpace(fields[i])
+	}
+	return fields
+}
+
+// 一个自治系统及其可用服务器数
+type ASNCount struct {
+	ASN   uint   `json:"asn"`
+	Org   string `json:"org,omitempty"`
+	Count int    `json:"count"`
+}
+
+// 按 ASN 统计通过检查的服务器，返回数量最多的 n 个 (n <= 0 时全部返回)；没有 ASN 的结果不计入
+func TopASNs(results []*Result, n int) []ASNCount {
+	counts := make(map[uint]*ASNCount)
+	for _, r := range results {
+		if r.ASN == 0 || r.Error != "" {
+			continue
+		}
+		c, ok := counts[r.ASN]
+		if !ok {
+			c = &ASNCount{ASN: r.ASN, Org: r.ASOrg}
+			counts[r.ASN] = c
+		}
+		c.Count++
+	}
+	top := make([]ASNCount, 0, len(counts))
+	for _, c := range counts {
+		top = append(top, *c)
+	}
+	sort.Slice(top, func(i, j int) bool {
+		if top[i].Count != top[j].Count {
+			return top[i].Count > top[j].Count
+		}
+		return top[i].ASN < top[j].ASN
+	})
+	if n > 0 && len(top) > n {
+		top = top[:n]
+	}
+	return top
+}
@@ -0,0 +1,165 @@
+package dnsvalidator
+
+import (
+	"errors"
+	"sync"
+	"syscall"
+	"time"
+)
+
+// 自动调整并发数时的初始值、调整间隔和每个间隔至少需要的样本数
+const (
+	autoThreadsStart    = 10
+	autoThreadsInterval = time.Second
+	autoThreadsSamples  = 20
+)
+
+// 超时比例比基准高出该值时认为出现了丢包，需要降低并发
+const autoThreadsLossMargin = 0.15
+
+// 自动调整的并发上限：从较低的并发开始，本机错误率和超时比例稳定时逐步提高，
+// 超时比例明显高于基准或出现本机错误 (如文件描述符、缓冲区耗尽) 时降低。
+// Interactive 时也用于暂停检查和手动设置并发，手动设置后不再自动调整
+type autoThreads struct {
+	mu     sync.Mutex
+	cond   *sync.Cond
+	limit  int
+	active int
+	max    int
+	manual bool // 不自动调整上限
+	paused bool // 暂停开始新的检查
+
+	checks   int     // 本间隔内完成的检查数
+	timeouts int     // 本间隔内超时的检查数
+	local    int     // 本间隔内出现本机错误的检查数
+	baseline float64 // 平稳时的超时比例，小于 0 表示尚未测得
+	log      *logger
+}
+
+// 从 start 个并发开始，manual 为 true 时只由 set 调整
+func newAutoThreads(start, max int, manual bool, log *logger) *autoThreads {
+	a := &autoThreads{limit: min(start, max), max: max, manual: manual, baseline: -1, log: log}
+	a.cond = sync.NewCond(&a.mu)
+	go func() {
+		for range time.Tick(autoThreadsInterval) {
+			a.adjust()
+		}
+	}()
+	return a
+}
+
+// 等待空闲的并发名额，固定线程数时直接返回
+func (a *autoThreads) acquire() {
+	if a == nil {
+		return
+	}
+	a.mu.Lock()
+	for a.paused || a.active >= a.limit {
+		a.cond.Wait()
+	}
+	a.active++
+	a.mu.Unlock()
+}
+
+// 归还并发名额
+func (a *autoThreads) release() {
+	if a == nil {
+		return
+	}
+	a.mu.Lock()
+	a.active--
+	a.mu.Unlock()
+	a.cond.Signal()
+}
+
+// 记录一次检查的结果，err 为空表示通过
+func (a *autoThreads) observe(err error) {
+	if a == nil {
+		return
+	}
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.checks++
+	switch {
+	case err == nil:
+	case localError(err):
+		a.local++
+	case failureCategory(err) == failTimeout:
+		a.timeouts++
+	}
+}
+
+// 根据上一个间隔的结果调整并发上限
+func (a *autoThreads) adjust() {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if a.manual {
+		a.checks, a.timeouts, a.local = 0, 0, 0
+		return
+	}
+	old := a.limit
+	rate := 0.0
+	if a.checks > 0 {
+		rate = float64(a.timeouts) / float64(a.checks)
+	}
+	switch {
+	case a.local > 0:
+		a.limit = max(1, a.limit/2)
+	case a.checks < autoThreadsSamples:
+		// 样本太少，保持不变；并发名额全部被占用时说明检查较慢，仍可提高
+		if a.active >= a.limit {
+			a.limit = min(a.max, a.limit+a.limit/2+1)
+		}
+	case a.baseline < 0:
+		a.baseline = rate
+		a.limit = min(a.max, a.limit*2)
+	case rate > a.baseline+autoThreadsLossMargin:
+		a.limit = max(1, a.limit*3/4)
+	default:
+		a.baseline = 0.8*a.baseline + 0.2*rate
+		a.limit = min(a.max, a.limit+a.limit/2+1)
+	}
+	if a.limit < old {
+		a.log.printf("自动线程数从 %d 降低到 %d (超时比例 %.0f%%，本机错误 %d 次)\n", old, a.limit, rate*100, a.local)
+	}
+	a.checks, a.timeouts, a.local = 0, 0, 0
+	a.cond.Broadcast()
+}
+
+// 手动设置并发上限，限制在 1 到 max 之间，之后不再自动调整；返回设置后的上限
+func (a *autoThreads) set(n int) int {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.limit = min(max(n, 1), a.max)
+	a.manual = true
+	a.cond.Broadcast()
+	return a.limit
+}
+
+// 暂停或恢复开始新的检查，进行中的检查不受影响；a 为空时不做任何事
+func (a *autoThreads) pause(paused bool) {
+	if a == nil {
+		return
+	}
+	a.mu.Lock()
+	a.paused = paused
+	a.mu.Unlock()
+	a.cond.Broadcast()
+}
+
+// 返回当前的并发上限和是否暂停
+func (a *autoThreads) state() (limit int, paused bool) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	return a.limit, a.paused
+}
+
+// 判断错误是否来自本机资源耗尽，而不是服务器本身
+func localError(err error) bool {
+	for _, errno := range []syscall.Errno{syscall.EMFILE, syscall.ENFILE, syscall.ENOBUFS, syscall.ENOMEM, syscall.EADDRNOTAVAIL, syscall.EAGAIN} {
+		if errors.Is(err, errno) {
+			return true
+		}
+	}
+	return false
+}
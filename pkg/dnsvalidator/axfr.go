@@ -0,0 +1,59 @@
+package dnsvalidator
+
+import (
+	"net"
+	"net/netip"
+	"strings"
+)
+
+// 区域传送的查询类型
+const typeAXFR uint16 = 252
+
+// 服务器自身地址所在的反向区域：IPv4 为 /24，IPv6 为 /64
+func reverseZone(server string) string {
+	host, _, err := net.SplitHostPort(server)
+	if err != nil {
+		return ""
+	}
+	ip, err := netip.ParseAddr(host)
+	if err != nil {
+		return ""
+	}
+	ip = ip.Unmap()
+	if ip.Is4() {
+		labels := strings.SplitN(reverseLabels(ip), ".", 2)
+		return labels[1] + ".in-addr.arpa"
+	}
+	// 32 个半字节倒序排列，/64 为后 16 个
+	return reverseLabels(ip)[32:] + ".ip6.arpa"
+}
+
+// 通过 TCP 请求区域传送，第一条应答以 SOA 开头即视为允许传送
+func allowsAXFR(c *client, server, zone string) bool {
+	resp, _, err := exchangeTCP(c.ctx, c.net, server, newQuery(zone, typeAXFR), c.timeout)
+	if err != nil || resp.RCode != rcodeSuccess || len(resp.Answers) == 0 {
+		return false
+	}
+	return resp.Answers[0].Type == typeSOA
+}
+
+// 对服务器自身的反向区域和 zones 尝试区域传送，返回 axfr=open 及允许传送的区域，均被拒绝时为 axfr=refused。
+// 区域传送只能通过 TCP 进行，DoT 和 DoH 服务器不检查
+func checkAXFR(c *client, server, transport string, zones []string) []string {
+	if transport != "udp" && transport != "tcp" {
+		return nil
+	}
+	if zone := reverseZone(server); zone != "" {
+		zones = append([]string{zone}, zones...)
+	}
+	var open []string
+	for _, zone := range zones {
+		if allowsAXFR(c, server, zone) {
+			open = append(open, strings.TrimSuffix(zone, "."))
+		}
+	}
+	if len(open) == 0 {
+		return []string{"axfr=refused"}
+	}
+	return []string{"axfr=open", "axfr_zones=" + strings.Join(open, ",")}
+}
@@ -0,0 +1,103 @@
+package dnsvalidator
+
+import (
+	"fmt"
+	"net/netip"
+)
+
+// 视为同一位置的网段长度：CDN 在同一地区通常从同一个 /24 (IPv6 为 /48) 中分配地址
+const (
+	baselinePrefix4 = 24
+	baselinePrefix6 = 48
+)
+
+// 可信基准服务器对一个域名的答案
+type baselineAnswers struct {
+	ips      map[netip.Addr]bool
+	prefixes map[netip.Prefix]bool
+	asns     map[uint]bool // 设置了 ASN 查询时各答案所属的 ASN
+}
+
+// 返回地址所在的 /24 或 /48 网段
+func baselineNet(ip netip.Addr) netip.Prefix {
+	bits := baselinePrefix6
+	if ip.Is4() {
+		bits = baselinePrefix4
+	}
+	p, _ := ip.Prefix(bits)
+	return p
+}
+
+// 记录一个基准答案
+func (b *baselineAnswers) add(ip netip.Addr) {
+	b.ips[ip] = true
+	b.prefixes[baselineNet(ip)] = true
+}
+
+// 判断答案是否与基准一致：至少有一个地址与基准答案相同，或与基准答案同属一个 /24 (IPv6 为 /48) 网段，
+// 设置了 ASN 查询时也可以同属一个 ASN。CDN 和按地域调度的域名对不同的服务器返回不同的地址，
+// 因此不要求所有答案都出现在基准答案中
+func (b *baselineAnswers) match(c *client, asn *ASNLookup, answers []string) bool {
+	var addrs []netip.Addr
+	for _, answer := range answers {
+		if ip, err := netip.ParseAddr(answer); err == nil {
+			addrs = append(addrs, ip.Unmap())
+		}
+	}
+	for _, ip := range addrs {
+		if b.ips[ip] || b.prefixes[baselineNet(ip)] {
+			return true
+		}
+	}
+	if asn == nil || len(b.asns) == 0 {
+		return false
+	}
+	for _, ip := range addrs {
+		if n, _ := asn.lookup(c, ip); n != 0 && b.asns[n] {
+			return true
+		}
+	}
+	return false
+}
+
+// 返回查询基准服务器所用的客户端和地址：DoH 地址通过 HTTPS 查询，其他条目可以是 IP 或 IP:端口，未指定端口时使用 53
+func baselineServer(opts *options, server string) (*client, string) {
+	if isDoHURL(server) {
+		return &client{ctx: opts.run.ctx, network: "doh", timeout: opts.timeout, dohMethod: opts.dohMethod, net: opts.net}, server
+	}
+	return opts.udpClient(), bootstrapAddr(server)
+}
+
+// 查询所有可信基准服务器，返回每个域名的基准答案；设置了 ASN 查询时同时记录答案所属的 ASN
+func buildBaseline(opts *options, servers, domains []string) (map[string]*baselineAnswers, error) {
+	baseline := make(map[string]*baselineAnswers)
+	for _, domain := range domains {
+		answers := &baselineAnswers{ips: make(map[netip.Addr]bool), prefixes: make(map[netip.Prefix]bool)}
+		for _, server := range servers {
+			c, addr := baselineServer(opts, server)
+			ips, err := lookupIPs(c, addr, domain)
+			if err != nil {
+				opts.log.printf("基准服务器 %s 无法解析域名 %s: %v\n", server, domain, err)
+				continue
+			}
+			for _, answer := range ips {
+				if ip, err := netip.ParseAddr(answer); err == nil {
+					answers.add(ip.Unmap())
+				}
+			}
+		}
+		if len(answers.ips) == 0 {
+			return nil, fmt.Errorf("所有基准服务器均无法解析域名 %s", domain)
+		}
+		if opts.asn != nil {
+			answers.asns = make(map[uint]bool)
+			for ip := range answers.ips {
+				if n, _ := opts.asn.lookup(opts.udpClient(), ip); n != 0 {
+					answers.asns[n] = true
+				}
+			}
+		}
+		baseline[domain] = answers
+	}
+	return baseline, nil
+}
@@ -0,0 +1,46 @@
+package dnsvalidator
+
+import (
+	"net/netip"
+	"testing"
+)
+
+func TestBaselineMatch(t *testing.T) {
+	b := &baselineAnswers{ips: make(map[netip.Addr]bool), prefixes: make(map[netip.Prefix]bool)}
+	for _, ip := range []string{"142.250.80.46", "142.250.80.78", "2607:f8b0:4004:c1b::64"} {
+		b.add(netip.MustParseAddr(ip))
+	}
+	tests := []struct {
+		name    string
+		answers []string
+		want    bool
+	}{
+		{"相同地址", []string{"142.250.80.46"}, true},
+		{"部分相同", []string{"142.250.80.46", "172.217.0.1"}, true},
+		{"同一 /24", []string{"142.250.80.100"}, true},
+		{"同一 /48", []string{"2607:f8b0:4004:1::1"}, true},
+		{"映射的 IPv4 地址", []string{"::ffff:142.250.80.46"}, true},
+		{"不同网段", []string{"142.250.81.46"}, false},
+		{"完全不同", []string{"10.0.0.1", "192.0.2.1"}, false},
+		{"没有答案", nil, false},
+	}
+	for _, tt := range tests {
+		if got := b.match(nil, nil, tt.answers); got != tt.want {
+			t.Errorf("%s: match(%v) = %v，应为 %v", tt.name, tt.answers, got, tt.want)
+		}
+	}
+}
+
+// 基准服务器可以带端口，CDN 对不同服务器返回的不同地址只要与基准有交集即可通过
+func TestBaselineServers(t *testing.T) {
+	mock := NewMockServer()
+	mock.Handle("192.0.2.53:5353", &MockBehavior{Records: map[string][]string{"google.com A": {"142.250.80.46", "142.250.80.78"}}})
+	mock.Handle("8.8.8.8:53", &MockBehavior{Records: map[string][]string{"google.com A": {"142.250.80.78", "142.250.64.1"}}})
+	mock.Handle("1.1.1.1:53", &MockBehavior{Records: map[string][]string{"google.com A": {"142.250.80.110"}}})
+	mock.Handle("9.9.9.9:53", &MockBehavior{Records: map[string][]string{"google.com A": {"93.184.216.34"}}})
+
+	valid := runMock(t, mock, []string{"8.8.8.8", "1.1.1.1", "9.9.9.9"}, WithBaseline("192.0.2.53:5353"))
+	if len(valid) != 2 || valid[0] != "1.1.1.1" || valid[1] != "8.8.8.8" {
+		t.Errorf("通过检查的服务器为 %v，应为 [1.1.1.1 8.8.8.8]", valid)
+	}
+}
@@ -0,0 +1,147 @@
+package dnsvalidator
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+)
+
+// 压测的默认参数：从每秒 10 个查询开始逐级翻倍，每级持续 2 秒，最多每秒 500 个查询
+const (
+	defaultBenchStart   = 10
+	defaultBenchMax     = 500
+	defaultBenchStep    = 2 * time.Second
+	defaultBenchLoss    = 0.05
+	defaultBenchTimeout = 2 * time.Second
+)
+
+// 压测参数，零值字段使用括号中的默认值
+type BenchOptions struct {
+	Domain   string        // 压测查询的域名，应使用服务器已缓存的检查域名，避免压力传到权威服务器
+	StartQPS int           // 第一级负载每秒发出的查询数 (10)
+	MaxQPS   int           // 每秒最多发出的查询数，负载逐级翻倍到该值为止 (500)
+	Step     time.Duration // 每一级负载的持续时间 (2s)
+	MaxLoss  float64       // 丢失和错误应答的最大比例，超过后停止加压 (0.05)
+	Timeout  time.Duration // 单个查询的超时，超时的查询计为丢失 (2s)
+}
+
+// 一级负载的压测结果
+type BenchStep struct {
+	QPS    int `json:"qps"`
+	Sent   int `json:"sent"`
+	OK     int `json:"ok"`     // 返回 NOERROR 的查询数
+	Errors int `json:"errors"` // 返回 SERVFAIL、REFUSED 等错误码的查询数
+	Lost   int `json:"lost"`   // 超时或出错的查询数
+}
+
+// 丢失和错误应答占发出查询的比例
+func (s BenchStep) Loss() float64 {
+	if s.Sent == 0 {
+		return 0
+	}
+	return float64(s.Errors+s.Lost) / float64(s.Sent)
+}
+
+// 单台服务器的压测结果
+type BenchResult struct {
+	Server string      `json:"server"`
+	QPS    int         `json:"qps"` // 丢失和错误比例不超过 MaxLoss 的最高负载，第一级即超过时为 0
+	Loss   float64     `json:"loss"`
+	Steps  []BenchStep `json:"steps"`
+}
+
+// 通过 UDP 对 server 逐级加压，每级按固定间隔发出 QPS×Step 个查询，丢失和错误比例超过 MaxLoss
+// 或达到 MaxQPS 后停止，返回能够维持的最高负载。查询同样受 Options.RateLimit 限制；ctx 被取消时返回已完成的级别
+func Bench(ctx context.Context, server string, opts BenchOptions) (*BenchResult, error) {
+	if opts.Domain == "" {
+		return nil, fmt.Errorf("压测需要指定查询的域名")
+	}
+	if opts.StartQPS <= 0 {
+		opts.StartQPS = defaultBenchStart
+	}
+	if opts.MaxQPS <= 0 {
+		opts.MaxQPS = defaultBenchMax
+	}
+	if opts.Step <= 0 {
+		opts.Step = defaultBenchStep
+	}
+	if opts.MaxLoss <= 0 {
+		opts.MaxLoss = defaultBenchLoss
+	}
+	if opts.Timeout <= 0 {
+		opts.Timeout = defaultBenchTimeout
+	}
+
+	b := &BenchResult{Server: server}
+	for qps := opts.StartQPS; ; qps *= 2 {
+		if qps > opts.MaxQPS {
+			qps = opts.MaxQPS
+		}
+		step, err := benchStep(ctx, server, qps, opts)
+		if err != nil {
+			return b, err
+		}
+		b.Steps = append(b.Steps, step)
+		if step.Loss() > opts.MaxLoss {
+			break
+		}
+		b.QPS, b.Loss = qps, step.Loss()
+		if qps >= opts.MaxQPS {
+			break
+		}
+	}
+	return b, nil
+}
+
+// 以 qps 的速率发出一级负载的查询，等待全部应答或超时后统计结果
+func benchStep(ctx context.Context, server string, qps int, opts BenchOptions) (BenchStep, error) {
+	step := BenchStep{QPS: qps}
+	n := int(float64(qps) * opts.Step.Seconds())
+	if n < 1 {
+		n = 1
+	}
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	ticker := time.NewTicker(time.Second / time.Duration(qps))
+	defer ticker.Stop()
+	for i := 0; i < n; i++ {
+		if i > 0 {
+			select {
+			case <-ticker.C:
+			case <-ctx.Done():
+				wg.Wait()
+				return step, ctx.Err()
+			}
+		}
+		step.Sent++
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			resp, _, err := exchangeUDP(ctx, nil, server, newQuery(opts.Domain, typeA), opts.Timeout)
+			mu.Lock()
+			defer mu.Unlock()
+			switch {
+			case err != nil:
+				step.Lost++
+			case resp.RCode != rcodeSuccess:
+				step.Errors++
+			default:
+				step.OK++
+			}
+		}()
+	}
+	wg.Wait()
+	return step, ctx.Err()
+}
+
+// 按能够维持的负载从高到低排列压测结果，负载相同时丢失比例低的优先
+func RankBench(results []*BenchResult) {
+	sort.SliceStable(results, func(i, j int) bool {
+		if results[i].QPS != results[j].QPS {
+			return results[i].QPS > results[j].QPS
+		}
+		return results[i].Loss < results[j].Loss
+	})
+}
@@ -0,0 +1,18 @@
+package dnsvalidator
+
+// 分别测量命中缓存和需要递归的查询延迟：主检查域名连续查询两次，第二次一定来自缓存；
+// canary 下的随机子域名不可能被缓存，服务器必须向权威服务器查询。
+// 输出 cached_ms 和 uncached_ms 列，两者接近说明延迟主要来自网络，相差很大说明递归较慢、只是缓存较热。
+// 查询失败的一项不输出
+func checkCacheLatency(c *client, server, domain, canary string) []string {
+	var attrs []string
+	if _, _, err := c.exchange(server, newQuery(domain, typeA)); err == nil {
+		if _, rtt, err := c.exchange(server, newQuery(domain, typeA)); err == nil {
+			attrs = append(attrs, "cached_ms="+formatMs(rtt))
+		}
+	}
+	if _, rtt, err := c.exchange(server, newQuery(randomLabel(16)+"."+canary, typeA)); err == nil {
+		attrs = append(attrs, "uncached_ms="+formatMs(rtt))
+	}
+	return attrs
+}
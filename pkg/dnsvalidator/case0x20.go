@@ -0,0 +1,45 @@
+package dnsvalidator
+
+import (
+	"fmt"
+	"math/rand"
+	"strings"
+)
+
+// 随机改变域名中字母的大小写 (draft-vixie-dnsext-dns0x20)，保证至少各有一个大写和小写字母
+func mixCase(name string) string {
+	b := []byte(strings.ToLower(name))
+	var letters []int
+	for i, c := range b {
+		if c >= 'a' && c <= 'z' {
+			letters = append(letters, i)
+		}
+	}
+	if len(letters) < 2 {
+		return string(b)
+	}
+	for _, i := range letters {
+		if rand.Intn(2) == 0 {
+			b[i] -= 'a' - 'A'
+		}
+	}
+	// 全部为小写或全部为大写时改变第一个字母
+	switch string(b) {
+	case strings.ToLower(string(b)):
+		b[letters[0]] -= 'a' - 'A'
+	case strings.ToUpper(string(b)):
+		b[letters[0]] += 'a' - 'A'
+	}
+	return string(b)
+}
+
+// 用大小写混合的域名查询，判断响应的问题段是否原样保留大小写。
+// 保留大小写的服务器可以配合 0x20 编码增加伪造应答的难度
+func check0x20(c *client, server, domain string) (bool, error) {
+	name := mixCase(domain)
+	resp, _, err := c.exchange(server, newQuery(name, typeA))
+	if err != nil {
+		return false, fmt.Errorf("0x20 查询失败: %w", err)
+	}
+	return strings.TrimSuffix(resp.Questions[0].Name, ".") == strings.TrimSuffix(name, "."), nil
+}
@@ -0,0 +1,143 @@
+package dnsvalidator
+
+import (
+	"net"
+	"sort"
+	"strings"
+)
+
+// 审查探测默认查询的域名，均为常见的被封锁网站
+var defaultCensorDomains = []string{
+	"twitter.com", "facebook.com", "youtube.com", "wikipedia.org",
+	"telegram.org", "torproject.org", "signal.org", "bbc.com",
+}
+
+// 审查探测中单个域名的结果
+const (
+	censorBlocked    = "blocked"    // 拒绝、SERVFAIL 或没有答案
+	censorRedirected = "redirected" // 返回拦截地址、保留地址或与参考答案不同网段的地址
+	censorNXDomain   = "nxdomain"   // 参考服务器可以解析但返回 NXDOMAIN
+)
+
+// 通过参考服务器获取审查和过滤探测域名的答案，无法解析的域名没有参考答案，只能判断是否被拒绝
+func buildProbeRef(c *client, server string, domains []string, log *logger) map[string][]string {
+	ref := make(map[string][]string, len(domains))
+	for _, domain := range domains {
+		ips, err := lookupIPs(c, server, domain)
+		if err != nil {
+			log.printf("参考服务器 %s 无法解析探测域名 %s: %v\n", server, domain, err)
+			continue
+		}
+		ref[domain] = ips
+	}
+	return ref
+}
+
+// 判断答案与参考答案是否属于相同网段：IPv4 比较 /16，IPv6 比较 /32。
+// 被封锁的网站大多使用 CDN，不同地区的答案经常不同，只比较较大的网段以减少误报
+func sameNetwork(answers, ref []string) bool {
+	prefix := func(s string) string {
+		ip := net.ParseIP(s)
+		if ip == nil {
+			return s
+		}
+		if ip4 := ip.To4(); ip4 != nil {
+			return ip4.Mask(net.CIDRMask(16, 32)).String()
+		}
+		return ip.Mask(net.CIDRMask(32, 128)).String()
+	}
+	nets := make(map[string]bool, len(ref))
+	for _, ip := range ref {
+		nets[prefix(ip)] = true
+	}
+	for _, ip := range answers {
+		if nets[prefix(ip)] {
+			return true
+		}
+	}
+	return false
+}
+
+// 查询单个探测域名，返回 blocked、redirected、nxdomain，未被审查时返回空。
+// 超时或连接失败时没有收到应答，无法判断是否被审查，同样返回空
+func censorStatus(c *client, server, domain string, ref []string, sinkholes map[string]bool) string {
+	resp, _, err := c.exchange(server, newQuery(domain, typeA))
+	if err != nil {
+		return ""
+	}
+	if resp.RCode == rcodeNXDomain {
+		if len(ref) > 0 {
+			return censorNXDomain
+		}
+		return ""
+	}
+	answers := resp.answerValues(typeA)
+	if resp.RCode != rcodeSuccess || len(answers) == 0 {
+		return censorBlocked
+	}
+	if checkSinkhole(domain, answers, sinkholes) != nil || checkBogonAnswer(domain, answers) != nil {
+		return censorRedirected
+	}
+	if len(ref) > 0 && !sameNetwork(answers, ref) {
+		return censorRedirected
+	}
+	return ""
+}
+
+// 依次查询探测域名，填入 Censored 并返回 censors=true/false 及被审查的域名
+func checkCensorship(r *Resolver) []string {
+	censored := make(map[string]string)
+	var parts []string
+	for _, domain := range r.opts.censorDomains {
+		if status := censorStatus(r.c, r.Addr, domain, r.opts.probeRef[domain], r.opts.sinkholes); status != "" {
+			censored[domain] = status
+			parts = append(parts, domain+":"+status)
+		}
+	}
+	if len(censored) == 0 {
+		return []string{"censors=false"}
+	}
+	r.result.Censored = censored
+	return []string{"censors=true", "censored=" + strings.Join(parts, ",")}
+}
+
+// 一个国家的审查探测汇总
+type CensorshipCount struct {
+	Country   string         `json:"country"`   // ISO 3166 国家代码，未标注国家时为空
+	Tested    int            `json:"tested"`    // 做了审查探测的服务器数
+	Censoring int            `json:"censoring"` // 至少审查了一个域名的服务器数
+	Domains   map[string]int `json:"domains"`   // 每个域名被审查的服务器数
+}
+
+// 按国家汇总审查探测结果，按审查的服务器数从多到少排列；没有做审查探测的结果不计入
+func CensorshipByCountry(results []*Result) []CensorshipCount {
+	counts := make(map[string]*CensorshipCount)
+	for _, r := range results {
+		if _, ok := r.Attributes()["censors"]; !ok {
+			continue
+		}
+		c, ok := counts[r.Country]
+		if !ok {
+			c = &CensorshipCount{Country: r.Country, Domains: make(map[string]int)}
+			counts[r.Country] = c
+		}
+		c.Tested++
+		if len(r.Censored) > 0 {
+			c.Censoring++
+		}
+		for domain := range r.Censored {
+			c.Domains[domain]++
+		}
+	}
+	list := make([]CensorshipCount, 0, len(counts))
+	for _, c := range counts {
+		list = append(list, *c)
+	}
+	sort.Slice(list, func(i, j int) bool {
+		if list[i].Censoring != list[j].Censoring {
+			return list[i].Censoring > list[j].Censoring
+		}
+		return list[i].Country < list[j].Country
+	})
+	return list
+}
@@ -0,0 +1,39 @@
+package dnsvalidator
+
+import (
+	"testing"
+	"time"
+)
+
+func TestCensorStatus(t *testing.T) {
+	ref := []string{"104.244.42.1"}
+	tests := []struct {
+		name     string
+		behavior *MockBehavior
+		ref      []string
+		want     string
+	}{
+		{"与参考答案同一网段", &MockBehavior{Records: map[string][]string{"twitter.com A": {"104.244.43.1"}}}, ref, ""},
+		{"与参考答案不同网段", &MockBehavior{Records: map[string][]string{"twitter.com A": {"203.0.113.9"}}}, ref, censorRedirected},
+		{"返回保留地址", &MockBehavior{Records: map[string][]string{"twitter.com A": {"127.0.0.1"}}}, nil, censorRedirected},
+		{"REFUSED", &MockBehavior{RCode: "REFUSED"}, ref, censorBlocked},
+		{"SERVFAIL", &MockBehavior{RCode: "SERVFAIL"}, ref, censorBlocked},
+		{"没有答案", &MockBehavior{Records: map[string][]string{"twitter.com AAAA": {"2001:db8::1"}}}, ref, censorBlocked},
+		{"NXDOMAIN", &MockBehavior{}, ref, censorNXDomain},
+		{"没有参考答案时的 NXDOMAIN", &MockBehavior{}, nil, ""},
+		// 没有收到应答时无法判断
+		{"不应答", &MockBehavior{DropRate: 1}, ref, ""},
+		{"连接被拒绝", nil, ref, ""},
+	}
+	for _, tt := range tests {
+		mock := NewMockServer()
+		if tt.behavior != nil {
+			mock.Handle("198.51.100.1:53", tt.behavior)
+		}
+		c := mockClient(mock)
+		c.timeout = 50 * time.Millisecond
+		if got := censorStatus(c, "198.51.100.1:53", "twitter.com", tt.ref, nil); got != tt.want {
+			t.Errorf("%s: censorStatus = %q，应为 %q", tt.name, got, tt.want)
+		}
+	}
+}
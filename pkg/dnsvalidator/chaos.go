@@ -0,0 +1,177 @@
+package dnsvalidator
+
+import (
+	"encoding/hex"
+	"regexp"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+const (
+	classCHAOS uint16 = 3 // CHAOS 类，用于 hostname.bind 等服务器信息查询
+	ednsNSID   uint16 = 3 // 名称服务器标识选项编号 (RFC 5001)
+)
+
+// 查询 CHAOS 类的 TXT 记录，如 hostname.bind；服务器不支持或拒绝时返回空
+func chaosTXT(c *client, server, name string) string {
+	query := newQuery(name, typeTXT)
+	query.Questions[0].Class = classCHAOS
+	query.RecursionDesired = false
+	resp, _, err := c.exchange(server, query)
+	if err != nil || resp.RCode != rcodeSuccess {
+		return ""
+	}
+	values := resp.answerValues(typeTXT)
+	if len(values) == 0 {
+		return ""
+	}
+	return attrValue(values[0])
+}
+
+// 在普通查询中请求 NSID，返回服务器标识；可打印时原样返回，否则为十六进制
+func queryNSID(c *client, server, domain string) string {
+	query := newQuery(domain, typeA)
+	query.setEDNS(1232, false)
+	query.addEDNSOption(ednsNSID, nil)
+	resp, _, err := c.exchange(server, query)
+	if err != nil {
+		return ""
+	}
+	opt := resp.opt()
+	if opt == nil {
+		return ""
+	}
+	option := opt.ednsOption(ednsNSID)
+	if option == nil || len(option.Data) == 0 {
+		return ""
+	}
+	for _, b := range option.Data {
+		if b < 0x21 || b > 0x7e {
+			return hex.EncodeToString(option.Data)
+		}
+	}
+	return string(option.Data)
+}
+
+// 返回应答节点的标识：hostname.bind (或 id.server) 和 NSID 均为空表示服务器不提供
+func checkNodeID(c *client, server, domain string) []string {
+	var attrs []string
+	host := chaosTXT(c, server, "hostname.bind")
+	if host == "" {
+		host = chaosTXT(c, server, "id.server")
+	}
+	if host != "" {
+		attrs = append(attrs, "hostname_bind="+host)
+	}
+	if nsid := queryNSID(c, server, domain); nsid != "" {
+		attrs = append(attrs, "nsid="+nsid)
+	}
+	return attrs
+}
+
+// 将任意文本转换为可以放入 key=value 属性列的值：空白替换为 _，去掉不可打印字符
+func attrValue(s string) string {
+	return strings.Map(func(r rune) rune {
+		switch {
+		case unicode.IsSpace(r):
+			return '_'
+		case !unicode.IsPrint(r):
+			return -1
+		}
+		return r
+	}, strings.TrimSpace(s))
+}
+
+// version.bind 中可以识别的 DNS 软件，按顺序匹配小写的版本字符串
+var softwarePatterns = []struct {
+	name     string
+	keywords []string
+}{
+	{"dnsmasq", []string{"dnsmasq"}},
+	{"unbound", []string{"unbound"}},
+	{"powerdns", []string{"powerdns", "pdns"}},
+	{"mikrotik", []string{"mikrotik", "routeros"}},
+	{"knot", []string{"knot"}},
+	{"coredns", []string{"coredns"}},
+	{"nsd", []string{"nsd"}},
+	{"microsoft", []string{"microsoft", "windows"}},
+	{"bind", []string{"bind", "named"}},
+}
+
+var versionNumber = regexp.MustCompile(`\d+(\.\d+)+`)
+
+// 由 version.bind 的内容识别软件和版本号，BIND 通常只返回 9.16.1-Ubuntu 这样的版本号
+func identifySoftware(version string) (software, number string) {
+	lower := strings.ToLower(version)
+	number = versionNumber.FindString(lower)
+	for _, p := range softwarePatterns {
+		for _, keyword := range p.keywords {
+			if strings.Contains(lower, keyword) {
+				return p.name, number
+			}
+		}
+	}
+	if strings.HasPrefix(number, "9.") {
+		return "bind", number
+	}
+	return "unknown", number
+}
+
+// 明显过时或属于家用路由器的软件：router 表示家用或小型路由器，vulnerable 表示存在已公开的严重漏洞或已停止维护。
+// 规则只覆盖常见情况，版本号无法识别时不判断
+func softwareRisk(software, number string) string {
+	if software == "mikrotik" {
+		return "router"
+	}
+	major, minor := versionParts(number)
+	switch software {
+	case "dnsmasq":
+		// CVE-2017-14491 等远程代码执行漏洞在 2.78 修复
+		if major == 2 && minor < 78 || major > 0 && major < 2 {
+			return "vulnerable"
+		}
+	case "bind":
+		// 9.11 之前的版本和 BIND 8 均已停止维护
+		if major == 9 && minor < 11 || major > 0 && major < 9 {
+			return "vulnerable"
+		}
+	}
+	return ""
+}
+
+// 解析版本号的前两段，无法解析时返回 0, 0
+func versionParts(number string) (int, int) {
+	parts := strings.SplitN(number, ".", 3)
+	if len(parts) < 2 {
+		return 0, 0
+	}
+	major, err1 := strconv.Atoi(parts[0])
+	minor, err2 := strconv.Atoi(parts[1])
+	if err1 != nil || err2 != nil {
+		return 0, 0
+	}
+	return major, minor
+}
+
+// 查询 version.bind (或 version.server) 识别软件，excludeRisky 时拒绝 softwareRisk 判断为有风险的服务器。
+// 服务器隐藏版本时只输出 software=hidden
+func checkSoftware(c *client, server string, excludeRisky bool) CheckResult {
+	version := chaosTXT(c, server, "version.bind")
+	if version == "" {
+		version = chaosTXT(c, server, "version.server")
+	}
+	if version == "" {
+		return CheckResult{Attrs: []string{"software=hidden"}}
+	}
+	software, number := identifySoftware(version)
+	attrs := []string{"software=" + software, "version_bind=" + version}
+	risk := softwareRisk(software, number)
+	if risk == "" {
+		return CheckResult{Attrs: attrs}
+	}
+	if excludeRisky {
+		return CheckResult{Err: newFailure(failSoftware, "运行有风险的软件 %s (%s)", version, risk)}
+	}
+	return CheckResult{Attrs: append(attrs, "software_risk="+risk)}
+}
@@ -0,0 +1,462 @@
+package dnsvalidator
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// 对单台服务器执行的一项检查。内置检查和 Options.Checkers 中的自定义检查按顺序组成检查链，
+// 任一检查失败即停止并丢弃该服务器
+type Checker interface {
+	Name() string // 检查名称，记录在 Result.Checks 中，也用于 Options.DisableChecks
+	Check(ctx context.Context, r *Resolver) CheckResult
+}
+
+// 一项检查的结果
+type CheckResult struct {
+	Attrs    []string // 通过时写入文本输出属性列的 key=value
+	Err      error    // 非空表示服务器未通过检查
+	Category string   // Err 的失败类别，为空时按错误类型判断
+	Skip     bool     // 检查不适用于该服务器，不记录为通过
+}
+
+// 返回带失败类别的错误
+func (cr CheckResult) failure() error {
+	if cr.Category == "" {
+		return cr.Err
+	}
+	return &CheckError{Category: cr.Category, Err: cr.Err}
+}
+
+// 正在检查的服务器，供检查发送查询
+type Resolver struct {
+	Addr      string // host:port，DoH 服务器为 URL
+	Transport string // udp、tcp、dot 或 doh
+
+	c      *client
+	opts   *options
+	result *Result
+}
+
+// 一次查询的响应
+type Response struct {
+	RCode              string
+	Answers            []string // 应答段中与查询类型相同的记录值
+	RTT                time.Duration
+	Authoritative      bool
+	RecursionAvailable bool
+	Truncated          bool
+}
+
+// 使用检查所用的传输协议、超时和重试设置向服务器发送一次查询；
+// 只有网络错误和格式错误的响应返回错误，错误响应码记录在 Response.RCode 中
+func (r *Resolver) Query(name string, qtype uint16) (*Response, error) {
+	resp, rtt, err := r.c.exchange(r.Addr, newQuery(name, qtype))
+	if err != nil {
+		return nil, err
+	}
+	return &Response{
+		RCode:              rcodeString(resp.RCode),
+		Answers:            resp.answerValues(qtype),
+		RTT:                rtt,
+		Authoritative:      resp.Authoritative,
+		RecursionAvailable: resp.RecursionAvailable,
+		Truncated:          resp.Truncated,
+	}, nil
+}
+
+// 内置检查，直接使用内部客户端
+type builtinCheck struct {
+	name    string
+	enabled func(o *options) bool // 由对应的 Options 字段启用
+	enable  func(cfg *Options)    // Options.EnableChecks 按名称启用时设置的字段
+	check   func(r *Resolver) CheckResult
+}
+
+func (b *builtinCheck) Name() string { return b.name }
+
+func (b *builtinCheck) Check(ctx context.Context, r *Resolver) CheckResult { return b.check(r) }
+
+// 内置检查，按执行顺序排列
+var builtinChecks = []*builtinCheck{
+	{
+		// 根据 RA 标志区分开放递归解析器和只有权威的服务器
+		name:    "recursion",
+		enabled: func(o *options) bool { return o.recursion },
+		enable:  func(cfg *Options) { cfg.Recursion = true },
+		check: func(r *Resolver) CheckResult {
+			recursive, err := checkRecursion(r.c, r.Addr, r.opts.domain)
+			if err != nil {
+				return CheckResult{Err: err}
+			}
+			if r.opts.recursiveOnly && !recursive {
+				return CheckResult{Err: newFailure(failNoRecursion, "只是权威服务器，不提供递归查询")}
+			}
+			return CheckResult{Attrs: []string{"recursion=" + recursionString(recursive)}}
+		},
+	},
+	{
+		// 直接向该 DNS 服务器查询所有域名，要求足够多的域名解析正确
+		name:    "resolve",
+		enabled: func(o *options) bool { return true },
+		check: func(r *Resolver) CheckResult {
+			d := checkDomains(r.c, r.Addr, r.opts)
+			if d.passed < r.opts.quorum {
+				msgs := make([]string, len(d.failures))
+				for i, failure := range d.failures {
+					msgs[i] = failure.Error()
+				}
+				return CheckResult{
+					Category: failureCategory(d.failures[0]),
+					Err:      fmt.Errorf("仅正确解析了 %d/%d 个域名: %s", d.passed, len(r.opts.domains), strings.Join(msgs, "; ")),
+				}
+			}
+			r.result.setLookup(r.Addr, d.primary)
+			r.result.Reliability = d.reliability()
+			return CheckResult{}
+		},
+	},
+	{
+		// 多次查询主检查域名，以延迟分位数代替单次测量
+		name:    "latency",
+		enabled: func(o *options) bool { return o.samples > 1 },
+		enable: func(cfg *Options) {
+			if cfg.Samples < 2 {
+				cfg.Samples = defaultSamples
+			}
+		},
+		check: func(r *Resolver) CheckResult {
+			return CheckResult{Attrs: checkLatency(r.c, r.Addr, r.opts.domain, r.opts.samples, r.result)}
+		},
+	},
+	{
+		// 以固定间隔发送探测查询，估算丢包率和 RTT 抖动
+		name:    "jitter",
+		enabled: func(o *options) bool { return o.jitter },
+		enable:  func(cfg *Options) { cfg.Jitter = true },
+		check: func(r *Resolver) CheckResult {
+			return checkJitter(r.c, r.Addr, r.opts.domain, r.opts)
+		},
+	},
+	{
+		// 查询每种指定的记录类型，要求全部成功
+		name:    "types",
+		enabled: func(o *options) bool { return len(o.types) > 0 },
+		check: func(r *Resolver) CheckResult {
+			var failed error
+			var typeAttrs []string
+			for _, t := range checkTypes(r.c, r.Addr, r.opts.domain, r.opts.types) {
+				typeAttrs = append(typeAttrs, t.String())
+				if err := t.failure(); err != nil && failed == nil {
+					failed = err
+				}
+			}
+			if failed != nil {
+				return CheckResult{
+					Category: failureCategory(failed),
+					Err:      fmt.Errorf("未能成功查询所有记录类型: %s", strings.Join(typeAttrs, " ")),
+				}
+			}
+			return CheckResult{Attrs: typeAttrs}
+		},
+	},
+	{
+		// 检查是否返回了预期之外的答案
+		name:    "expect",
+		enabled: func(o *options) bool { return o.expected != nil },
+		check: func(r *Resolver) CheckResult {
+			return CheckResult{Err: checkExpected(r.c, r.Addr, r.opts.expected)}
+		},
+	},
+	{
+		// 检测泛解析和 NXDOMAIN 劫持
+		name:    "nxdomain",
+		enabled: func(o *options) bool { return o.nxcheck },
+		enable:  func(cfg *Options) { cfg.NXCheck = true },
+		check: func(r *Resolver) CheckResult {
+			return CheckResult{Err: checkNXDomain(r.c, r.Addr, r.opts.canary)}
+		},
+	},
+	{
+		// 检测 EDNS0 支持及 UDP 缓冲区大小
+		name:    "edns",
+		enabled: func(o *options) bool { return o.edns },
+		enable:  func(cfg *Options) { cfg.EDNS = true },
+		check: func(r *Resolver) CheckResult {
+			attrs, err := probeEDNS(r.c, r.Addr, r.opts.domain)
+			return CheckResult{Attrs: attrs, Err: err}
+		},
+	},
+	{
+		// 参照 ISC ednscomp 测试 EDNS 版本协商、未知选项和标志位的处理
+		name:    "ednscomp",
+		enabled: func(o *options) bool { return o.ednsComp },
+		enable:  func(cfg *Options) { cfg.EDNSCompliance = true },
+		check: func(r *Resolver) CheckResult {
+			return checkEDNSCompliance(r.c, r.Addr, r.opts.domain, r.opts.ednsMinGrade)
+		},
+	},
+	{
+		// 检测大应答的截断及 TCP 重试，只适用于 UDP
+		name:    "tc",
+		enabled: func(o *options) bool { return o.tcCheck },
+		enable:  func(cfg *Options) { cfg.TC = true },
+		check: func(r *Resolver) CheckResult {
+			if r.c.network != "udp" {
+				return CheckResult{Skip: true}
+			}
+			attrs, err := checkTruncation(r.c, r.Addr, r.opts.tcName, r.opts.tcType)
+			return CheckResult{Attrs: attrs, Err: err}
+		},
+	},
+	{
+		// 检测 EDNS Client Subnet 支持
+		name:    "ecs",
+		enabled: func(o *options) bool { return o.ecs },
+		enable:  func(cfg *Options) { cfg.ECS = true },
+		check: func(r *Resolver) CheckResult {
+			ecs, scope, err := checkECS(r.c, r.Addr, r.opts.domain)
+			if err != nil {
+				return CheckResult{Err: err}
+			}
+			if r.opts.ecsFilter == "exclude" && ecs {
+				return CheckResult{Err: newFailure(failECS, "支持 ECS，会转发客户端子网")}
+			}
+			if r.opts.ecsFilter == "only" && !ecs {
+				return CheckResult{Err: newFailure(failECS, "不支持 ECS")}
+			}
+			attrs := []string{fmt.Sprintf("ecs=%t", ecs)}
+			if ecs {
+				attrs = append(attrs, fmt.Sprintf("ecs_scope=%d", scope))
+			}
+			return CheckResult{Attrs: attrs}
+		},
+	},
+	{
+		// 估算 ANY、DNSKEY 和 TXT 查询的应答放大倍数，丢弃超过上限的服务器
+		name:    "amplification",
+		enabled: func(o *options) bool { return o.amplification },
+		enable:  func(cfg *Options) { cfg.Amplification = true },
+		check: func(r *Resolver) CheckResult {
+			// 只有 UDP 能被用于反射放大，其他传输协议不检查也不记入通过的检查
+			if r.Transport != "udp" {
+				return CheckResult{Skip: true}
+			}
+			return checkAmplification(r.c, r.Addr, r.opts.domain, r.opts.maxAmplification)
+		},
+	},
+	{
+		// 检查响应是否原样保留查询域名的大小写 (0x20 编码)
+		name:    "0x20",
+		enabled: func(o *options) bool { return o.case0x20 },
+		enable:  func(cfg *Options) { cfg.Case0x20 = true },
+		check: func(r *Resolver) CheckResult {
+			preserved, err := check0x20(r.c, r.Addr, r.opts.domain)
+			if err != nil {
+				return CheckResult{Err: err}
+			}
+			if r.opts.require0x20 && !preserved {
+				return CheckResult{Err: newFailure(failCase, "响应未保留查询域名的大小写")}
+			}
+			return CheckResult{Attrs: []string{fmt.Sprintf("case_preserved=%t", preserved)}}
+		},
+	},
+	{
+		// 检测 DNS 重绑定保护，可以按是否过滤私有地址应答筛选服务器
+		name:    "rebind",
+		enabled: func(o *options) bool { return o.rebind },
+		enable:  func(cfg *Options) { cfg.Rebind = true },
+		check: func(r *Resolver) CheckResult {
+			return checkRebindFilter(r.c, r.Addr, r.opts.rebindDomains, r.opts.rebindFilter)
+		},
+	},
+	{
+		// 检测 DNS Cookie 支持
+		name:    "cookie",
+		enabled: func(o *options) bool { return o.cookie },
+		enable:  func(cfg *Options) { cfg.Cookie = true },
+		check: func(r *Resolver) CheckResult {
+			cookie, err := checkCookie(r.c, r.Addr, r.opts.domain)
+			if err != nil {
+				return CheckResult{Err: err}
+			}
+			return CheckResult{Attrs: []string{fmt.Sprintf("cookie=%t", cookie)}}
+		},
+	},
+	{
+		// 通过 CHAOS hostname.bind 和 NSID 记录应答的节点，服务器不提供时不输出
+		name:    "nodeid",
+		enabled: func(o *options) bool { return o.nodeID },
+		enable:  func(cfg *Options) { cfg.NodeID = true },
+		check: func(r *Resolver) CheckResult {
+			return CheckResult{Attrs: checkNodeID(r.c, r.Addr, r.opts.domain)}
+		},
+	},
+	{
+		// 通过 whoami 类域名记录递归出口 IP，判断服务器是否只是转发查询
+		name:    "upstream",
+		enabled: func(o *options) bool { return o.upstream },
+		enable:  func(cfg *Options) { cfg.Upstream = true },
+		check: func(r *Resolver) CheckResult {
+			return CheckResult{Attrs: checkUpstream(r.c, r.Addr)}
+		},
+	},
+	{
+		// 通过 QNAME 最小化测试区域判断递归查询是否只向各级权威服务器发送必要的标签
+		name:    "qmin",
+		enabled: func(o *options) bool { return o.qmin },
+		enable:  func(cfg *Options) { cfg.QMin = true },
+		check: func(r *Resolver) CheckResult {
+			return CheckResult{Attrs: checkQMin(r.c, r.Addr, r.opts.qminZone)}
+		},
+	},
+	{
+		// 通过源端口测试区域评估递归查询的源端口随机化，丢弃容易被伪造应答投毒的服务器
+		name:    "porttest",
+		enabled: func(o *options) bool { return o.portRandom },
+		enable:  func(cfg *Options) { cfg.PortRandom = true },
+		check: func(r *Resolver) CheckResult {
+			return checkPortRandom(r.c, r.Addr, r.opts.portTestZone, r.opts.portMinGrade)
+		},
+	},
+	{
+		// 尝试对服务器自身的反向区域和指定区域做区域传送，标记允许开放 AXFR 的服务器
+		name:    "axfr",
+		enabled: func(o *options) bool { return o.axfr },
+		enable:  func(cfg *Options) { cfg.AXFR = true },
+		check: func(r *Resolver) CheckResult {
+			return CheckResult{Attrs: checkAXFR(r.c, r.Addr, r.Transport, r.opts.axfrZones)}
+		},
+	},
+	{
+		// 查询常见的被封锁域名，记录被拒绝、重定向或返回 NXDOMAIN 的域名
+		name:    "censorship",
+		enabled: func(o *options) bool { return o.censorship },
+		enable:  func(cfg *Options) { cfg.Censorship = true },
+		check: func(r *Resolver) CheckResult {
+			return CheckResult{Attrs: checkCensorship(r)}
+		},
+	},
+	{
+		// 查询广告、恶意软件和成人内容的探测域名，判断服务器的过滤类型
+		name:    "filtering",
+		enabled: func(o *options) bool { return o.filtering },
+		enable:  func(cfg *Options) { cfg.Filtering = true },
+		check:   checkFiltering,
+	},
+	{
+		// 以 RD=0 查询热门域名，标记会暴露缓存内容的服务器
+		name:    "snoop",
+		enabled: func(o *options) bool { return o.cacheSnoop },
+		enable:  func(cfg *Options) { cfg.CacheSnoop = true },
+		check: func(r *Resolver) CheckResult {
+			return CheckResult{Attrs: checkCacheSnoop(r.c, r.Addr, r.opts.snoopDomains)}
+		},
+	},
+	{
+		// 分别测量命中缓存和需要递归的查询延迟
+		name:    "cachelat",
+		enabled: func(o *options) bool { return o.cacheLatency },
+		enable:  func(cfg *Options) { cfg.CacheLatency = true },
+		check: func(r *Resolver) CheckResult {
+			return CheckResult{Attrs: checkCacheLatency(r.c, r.Addr, r.opts.domain, r.opts.canary)}
+		},
+	},
+	{
+		// 通过 CHAOS version.bind 识别服务器软件
+		name:    "software",
+		enabled: func(o *options) bool { return o.software },
+		enable:  func(cfg *Options) { cfg.Software = true },
+		check: func(r *Resolver) CheckResult {
+			return checkSoftware(r.c, r.Addr, r.opts.excludeRisky)
+		},
+	},
+	{
+		// 检测 DNSSEC 验证能力
+		name:    "dnssec",
+		enabled: func(o *options) bool { return o.dnssec },
+		enable:  func(cfg *Options) { cfg.DNSSEC = true },
+		check: func(r *Resolver) CheckResult {
+			validating, err := checkDNSSEC(r.c, r.Addr, r.opts.signedZone, r.opts.bogusZone)
+			if err != nil {
+				return CheckResult{Err: err}
+			}
+			if r.opts.dnssecOnly && !validating {
+				return CheckResult{Err: newFailure(failNoDNSSEC, "不验证 DNSSEC")}
+			}
+			return CheckResult{Attrs: []string{fmt.Sprintf("dnssec=%t", validating)}}
+		},
+	},
+}
+
+// 返回内置检查的名称，按执行顺序排列
+func CheckNames() []string {
+	names := make([]string, len(builtinChecks))
+	for i, b := range builtinChecks {
+		names[i] = b.name
+	}
+	return names
+}
+
+// 按名称查找内置检查
+func lookupBuiltinCheck(name string) *builtinCheck {
+	for _, b := range builtinChecks {
+		if b.name == name {
+			return b
+		}
+	}
+	return nil
+}
+
+// 按 Options.EnableChecks 设置启用对应检查的字段
+func enableChecks(cfg *Options) error {
+	for _, name := range cfg.EnableChecks {
+		b := lookupBuiltinCheck(name)
+		if b == nil {
+			return fmt.Errorf("未知的检查 %s，可选: %s", name, strings.Join(CheckNames(), "、"))
+		}
+		if b.enable == nil && b.name != "resolve" {
+			return fmt.Errorf("检查 %s 需要通过对应的选项配置，不能只按名称启用", name)
+		}
+		if b.enable != nil {
+			b.enable(cfg)
+		}
+	}
+	return nil
+}
+
+// 组成检查链：已启用的内置检查在前，自定义检查在后，跳过 disabled 中的检查
+func buildChecks(opts *options, custom []Checker, disabled []string) ([]Checker, error) {
+	skip := make(map[string]bool, len(disabled))
+	for _, name := range disabled {
+		if name == "resolve" {
+			return nil, fmt.Errorf("不能跳过 resolve 检查")
+		}
+		skip[name] = true
+	}
+	known := make(map[string]bool)
+	var checks []Checker
+	for _, b := range builtinChecks {
+		known[b.name] = true
+		if b.enabled(opts) && !skip[b.name] {
+			checks = append(checks, b)
+		}
+	}
+	for _, c := range custom {
+		if known[c.Name()] {
+			return nil, fmt.Errorf("检查名称 %s 重复", c.Name())
+		}
+		known[c.Name()] = true
+		if !skip[c.Name()] {
+			checks = append(checks, c)
+		}
+	}
+	for _, name := range disabled {
+		if !known[name] {
+			return nil, fmt.Errorf("未知的检查 %s，可选: %s", name, strings.Join(CheckNames(), "、"))
+		}
+	}
+	return checks, nil
+}
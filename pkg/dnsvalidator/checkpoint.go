@@ -0,0 +1,161 @@
+package dnsvalidator
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"sync"
+	"time"
+)
+
+// 断点文件写入磁盘的间隔，进程意外退出时最多丢失这段时间内完成的检查
+const checkpointInterval = time.Second
+
+// 断点文件中的一条记录：一台已完成检查的服务器，通过时保存结果，失败时保存类别和原因
+type checkpointEntry struct {
+	Key      string   `json:"key"` // 去重键，与 dedupKey 一致
+	Server   string   `json:"server"`
+	Category string   `json:"category,omitempty"`
+	Reason   string   `json:"reason,omitempty"`
+	Result   *Result  `json:"result,omitempty"`
+	Attrs    []string `json:"attrs,omitempty"` // 文本输出的属性列，保持检查顺序
+}
+
+// 以 JSON Lines 形式追加记录已完成的检查，定期写入磁盘
+type checkpoint struct {
+	mu    sync.Mutex
+	f     *os.File
+	w     *bufio.Writer
+	flush time.Time
+	err   error
+}
+
+// 上次运行保存的进度
+type checkpointState struct {
+	done    map[string]bool // 已完成检查的服务器去重键
+	results []*Result       // 通过检查的服务器
+	failed  []checkpointEntry
+}
+
+// 读取断点文件，文件不存在时返回空的进度；进程中途退出留下的不完整记录会被忽略
+func loadCheckpoint(path string) (*checkpointState, error) {
+	state := &checkpointState{done: make(map[string]bool)}
+	f, err := os.Open(path)
+	if os.IsNotExist(err) {
+		return state, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("无法读取断点文件 %s: %v", path, err)
+	}
+	defer f.Close()
+
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		var e checkpointEntry
+		if err := json.Unmarshal(scanner.Bytes(), &e); err != nil || e.Key == "" {
+			continue
+		}
+		if state.done[e.Key] {
+			continue
+		}
+		state.done[e.Key] = true
+		if e.Result != nil {
+			e.Result.attrs = e.Attrs
+			e.Result.Details = nil
+			e.Result.RTT = time.Duration(e.Result.LatencyMs * float64(time.Millisecond))
+			state.results = append(state.results, e.Result)
+		} else {
+			state.failed = append(state.failed, e)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("无法读取断点文件 %s: %v", path, err)
+	}
+	return state, nil
+}
+
+// 打开断点文件，resume 为 true 时在原有记录之后追加，否则清空重新记录
+func openCheckpoint(path string, resume bool) (*checkpoint, error) {
+	flags := os.O_CREATE | os.O_WRONLY | os.O_TRUNC
+	if resume {
+		flags = os.O_CREATE | os.O_RDWR | os.O_APPEND
+	}
+	f, err := os.OpenFile(path, flags, 0o644)
+	if err != nil {
+		return nil, fmt.Errorf("无法打开断点文件 %s: %v", path, err)
+	}
+	cp := &checkpoint{f: f, w: bufio.NewWriter(f), flush: time.Now()}
+
+	// 上次运行中途退出时最后一行可能不完整，先补上换行，避免与新记录连在一起
+	if resume {
+		if info, err := f.Stat(); err == nil && info.Size() > 0 {
+			last := make([]byte, 1)
+			if _, err := f.ReadAt(last, info.Size()-1); err == nil && last[0] != '\n' {
+				cp.w.WriteString("\n")
+			}
+		}
+	}
+	return cp, nil
+}
+
+// 记录一台已完成检查的服务器，距上次写入磁盘超过 checkpointInterval 时写入
+func (cp *checkpoint) record(e checkpointEntry) {
+	if cp == nil {
+		return
+	}
+	if e.Result != nil {
+		e.Attrs = e.Result.attrs
+	}
+	data, err := json.Marshal(e)
+	cp.mu.Lock()
+	defer cp.mu.Unlock()
+	if err == nil {
+		_, err = cp.w.Write(append(data, '\n'))
+	}
+	if err == nil && time.Since(cp.flush) >= checkpointInterval {
+		err = cp.w.Flush()
+		cp.flush = time.Now()
+	}
+	if err != nil && cp.err == nil {
+		cp.err = err
+	}
+}
+
+// 写入剩余的记录并关闭文件，返回期间遇到的第一个错误
+func (cp *checkpoint) close() error {
+	if cp == nil {
+		return nil
+	}
+	cp.mu.Lock()
+	defer cp.mu.Unlock()
+	err := cp.w.Flush()
+	if cerr := cp.f.Close(); err == nil {
+		err = cerr
+	}
+	if cp.err != nil {
+		return cp.err
+	}
+	return err
+}
+
+// 将上次运行中失败的服务器重新写入 -output-invalid 和 -tainted 文件，并计入统计
+func (state *checkpointState) replayFailures(opts *options) error {
+	for _, e := range state.failed {
+		opts.stats.fail(e.Category)
+		if e.Category == failHijack && opts.tainted != nil {
+			if err := opts.tainted.WriteLine(e.Server); err != nil {
+				return err
+			}
+		}
+		if opts.invalid != nil {
+			if err := opts.invalid.WriteLine(invalidLine(e.Server, e.Category, e.Reason)); err != nil {
+				return err
+			}
+		}
+		opts.failed(e.Server, &CheckError{Category: e.Category, Err: errors.New(e.Reason)})
+	}
+	return nil
+}
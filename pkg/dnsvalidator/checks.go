@@ -0,0 +1,374 @@
+package dnsvalidator
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"strings"
+	"sync"
+	"time"
+)
+
+// 校验参数
+type options struct {
+	domain        string   // 主检查域名，即 domains 中的第一个
+	domains       []string // 需要解析的全部域名
+	quorum        int      // 至少需要正确解析的域名个数
+	repeat        int      // 每个域名的查询次数
+	repeatPass    int      // 每个域名至少需要成功的查询次数
+	samples       int      // 测量延迟的查询次数，大于 1 时输出延迟分位数
+	timeout       time.Duration
+	net           *netState                   // 本次运行共享的网络设置
+	retries       int                         // 查询超时后的最多重试次数
+	backoff       time.Duration               // 第一次重试前的等待时间
+	transport     string                      // 查询所用的传输协议: udp、tcp、both 或 dot
+	dohMethod     string                      // DoH 请求方法: GET 或 POST
+	port          string                      // 条目未指定端口时使用的端口，为空则按传输协议取默认值
+	baseline      map[string]*baselineAnswers // 每个域名的可信基准答案，为空表示不做比对
+	expected      map[string][]*net.IPNet     // 域名到预期答案网段的映射，为空表示不做检查
+	sinkholes     map[string]bool             // 视为拦截的应答地址
+	nxcheck       bool                        // 是否检测 NXDOMAIN 劫持
+	canary        string                      // 用于生成随机不存在子域名的域名
+	tainted       *lockedWriter               // 劫持 NXDOMAIN 的服务器写入此处，为空则直接丢弃
+	invalid       *lockedWriter               // 未通过检查的服务器及原因写入此处，为空则不记录
+	onFailure     func(server string, err error)
+	includeFailed bool       // 未通过检查的服务器也送出结果
+	failureMu     sync.Mutex // 保证 onFailure 不会并发调用
+	events        *eventBus  // 向 Subscribe 的订阅者广播事件
+
+	dnssec     bool   // 是否检测 DNSSEC 验证能力
+	dnssecOnly bool   // 只保留 DNSSEC 验证型服务器
+	signedZone string // 已正确签名的域名
+	bogusZone  string // 签名故意损坏的域名
+
+	recursion     bool // 是否检测递归能力
+	recursiveOnly bool // 只保留开放递归解析器
+
+	types []uint16 // 需要全部查询成功的记录类型
+
+	edns bool // 是否检测 EDNS0 支持及 UDP 缓冲区大小
+
+	ednsComp     bool   // 是否测试 EDNS 合规性
+	ednsMinGrade string // 要求的最低合规性等级: ok 或 partial，为空不要求
+
+	tcCheck bool   // 是否检测截断及 TCP 重试
+	tcName  string // 应答超过 512 字节的域名
+	tcType  uint16 // 截断测试查询的记录类型
+
+	ecs       bool   // 是否检测 ECS 支持
+	ecsFilter string // 按 ECS 支持情况过滤: exclude 排除支持的服务器，only 只保留支持的服务器
+
+	amplification    bool    // 是否估算应答放大倍数
+	maxAmplification float64 // 允许的最大放大倍数，为 0 不限制
+
+	case0x20    bool // 是否检查大小写保留
+	require0x20 bool // 只保留原样保留大小写的服务器
+
+	rebind        bool     // 是否检测重绑定保护
+	rebindFilter  string   // 按重绑定保护过滤: exclude 排除有保护的服务器，only 只保留有保护的服务器
+	rebindDomains []string // 解析到私有地址的探测域名
+
+	cookie bool // 是否检测 DNS Cookie 支持
+
+	nodeID bool // 是否记录 hostname.bind 和 NSID
+
+	upstream bool // 是否记录递归出口 IP
+
+	qmin     bool   // 是否检测 QNAME 最小化
+	qminZone string // QNAME 最小化测试区域
+
+	portRandom   bool   // 是否评估源端口随机化
+	portTestZone string // 源端口测试区域
+	portMinGrade string // 要求的最低评级，为空不要求
+
+	axfr      bool     // 是否检查开放的区域传送
+	axfrZones []string // 除反向区域外尝试传送的区域
+
+	allowPrivateAnswers bool // 允许检查域名解析到保留或私有地址
+
+	censorship    bool     // 是否做审查探测
+	censorDomains []string // 审查探测的域名
+
+	filtering        bool                // 是否判断过滤类型
+	filteringDomains map[string][]string // 各类别的过滤探测域名
+	filteringClasses map[string]bool     // 只保留这些过滤类型，为空不限制
+
+	probeRef map[string][]string // 参考服务器对审查和过滤探测域名的答案
+
+	jitter         bool          // 是否估算丢包率和 RTT 抖动
+	jitterProbes   int           // 探测查询的个数
+	jitterInterval time.Duration // 探测查询的间隔
+	maxLoss        float64       // 允许的最大丢包率 (百分比)，为 0 不限制
+	maxJitter      time.Duration // 允许的最大 RTT 抖动，为 0 不限制
+
+	cacheSnoop   bool     // 是否检测缓存探测
+	snoopDomains []string // 以 RD=0 查询的热门域名
+
+	cacheLatency bool // 是否分别测量命中缓存和需要递归的查询延迟
+
+	software     bool // 是否通过 version.bind 识别软件
+	excludeRisky bool // 丢弃运行过时或家用路由器软件的服务器
+
+	checks []Checker // 依次执行的检查链
+
+	showSource  bool         // 是否在输出中记录条目来源
+	showLatency bool         // 是否在文本输出中加入延迟列
+	log         *logger      // 逐台服务器的检查信息
+	stats       *Stats       // 运行统计
+	err         *runError    // 无法继续运行的错误，如写入文件失败
+	run         *runState    // 本次运行的取消状态
+	checkpoint  *checkpoint  // 记录已完成检查的断点文件，为空则不记录
+	stopAfter   *stopAfter   // 找到足够的可用服务器后停止运行
+	threads     *autoThreads // 自动调整的并发，为空表示固定线程数
+	geoip       *GeoIP       // 标注国家和城市的数据库，为空则不标注
+	asn         *ASNLookup   // 标注 ASN 的数据来源，为空则不标注
+	ptr         bool         // 是否反向解析通过检查的服务器
+	ptrServer   string       // 反向解析所用的服务器
+
+	reputation *ReputationStore // 跨运行的历史记录，为空则不记录
+}
+
+// 检查DNS是否能解析给定域名
+func checkDNS(cand candidate, opts *options, results chan<- *Result) {
+	dnsServer := cand.server
+
+	r, err := validate(opts.run.ctx, cand, opts)
+	opts.threads.observe(err)
+	// 因取消或超过截止时间而未完成的检查不计入结果
+	if err != nil && opts.run.ctx.Err() != nil {
+		return
+	}
+	if err != nil {
+		err = classify(err)
+		category := failureCategory(err)
+		reason := strings.ReplaceAll(err.Error(), "\n", " ")
+		opts.stats.fail(category)
+		opts.checkpoint.record(checkpointEntry{Key: dedupKey(dnsServer), Server: dnsServer, Category: category, Reason: reason})
+		if err := opts.reputation.record(dnsServer, nil, category); err != nil {
+			opts.abort(fmt.Errorf("写入历史记录时出错：%v", err))
+		}
+		opts.log.printf("DNS 服务器 %s [%s] %v\n", dnsServer, category, err)
+		if category == failHijack && opts.tainted != nil {
+			if err := opts.tainted.WriteLine(dnsServer); err != nil {
+				opts.abort(fmt.Errorf("写入劫持服务器文件时出错：%v", err))
+			}
+		}
+		if opts.invalid != nil {
+			if err := opts.invalid.WriteLine(invalidLine(dnsServer, category, reason)); err != nil {
+				opts.abort(fmt.Errorf("写入不可用服务器文件时出错：%v", err))
+			}
+		}
+		opts.failed(dnsServer, err)
+		opts.events.publish(Event{Type: EventFailed, Server: dnsServer, Err: err})
+		if opts.includeFailed {
+			if r == nil {
+				r = &Result{}
+			}
+			r.Server = serverName(dnsServer, opts.port)
+			if r.IP == "" && !isDoHURL(dnsServer) {
+				r.IP, r.Port = splitServer(r.Server)
+			}
+			r.Protocol, r.Provider, r.Hostname = cand.protocol, cand.provider, cand.hostname
+			opts.geoip.annotate(r)
+			opts.asn.annotate(opts.udpClient(), r)
+			cand.meta.fill(r)
+			r.Error, r.Category, r.Timestamp = reason, category, time.Now()
+			results <- r
+		}
+		return
+	}
+
+	if err := opts.reputation.record(dnsServer, r, ""); err != nil {
+		opts.abort(fmt.Errorf("写入历史记录时出错：%v", err))
+	}
+
+	// 已找到足够的可用服务器时，之后完成的检查不再保留
+	if !opts.stopAfter.collect() {
+		return
+	}
+
+	// 如果 DNS 服务器能解析域名，输出并保存到结果通道
+	opts.stats.pass()
+	opts.log.printf("DNS 服务器 %s 可以解析域名 %s\n", dnsServer, strings.Join(opts.domains, ","))
+	r.Server = serverName(dnsServer, opts.port)
+	r.Protocol, r.Provider, r.Hostname = cand.protocol, cand.provider, cand.hostname
+	if cand.protocol != "" {
+		r.attrs = append(r.attrs, "protocol="+cand.protocol)
+	}
+	if cand.provider != "" {
+		r.attrs = append(r.attrs, "provider="+cand.provider)
+	}
+	if cand.hostname != "" {
+		r.attrs = append(r.attrs, "hostname="+cand.hostname)
+	}
+	opts.geoip.annotate(r)
+	opts.asn.annotate(opts.udpClient(), r)
+	cand.meta.fill(r)
+	annotatePTR(opts, r)
+	r.Timestamp = time.Now()
+	if opts.showSource {
+		r.Source = cand.source
+		r.attrs = append(r.attrs, "source="+cand.source)
+	}
+	if opts.showLatency {
+		r.attrs = append(r.attrs, "latency_ms="+r.field("latency_ms"))
+	}
+	opts.checkpoint.record(checkpointEntry{Key: dedupKey(dnsServer), Server: dnsServer, Result: r})
+	opts.events.publish(Event{Type: EventResolved, Server: dnsServer, Result: r})
+	results <- r
+}
+
+// 将未通过检查的服务器交给 OnFailure
+func (o *options) failed(server string, err error) {
+	if o.onFailure == nil {
+		return
+	}
+	o.failureMu.Lock()
+	defer o.failureMu.Unlock()
+	o.onFailure(server, err)
+}
+
+// 返回通过 UDP 做辅助查询 (基准答案、引导解析、ASN 和 PTR) 的客户端，使用本次运行的网络设置
+func (o *options) udpClient() *client {
+	return &client{ctx: o.run.ctx, network: "udp", timeout: o.timeout, net: o.net}
+}
+
+// 返回 -output-invalid 文件中的一行，原因写在 # 之后，文件可以直接通过 -f 重新检查
+func invalidLine(server, category, reason string) string {
+	return fmt.Sprintf("%s # [%s] %s", server, category, reason)
+}
+
+// 依次执行各项检查，返回检查结果，失败时返回带类别的错误
+func validate(ctx context.Context, cand candidate, opts *options) (*Result, error) {
+	dnsServer := cand.server
+	onRetry := func(attempt int, err error) {
+		opts.events.publish(Event{Type: EventRetried, Server: dnsServer, Attempt: attempt, Err: err})
+	}
+
+	// DoH 地址直接以 URL 作为服务器，通过 HTTPS 完成所有检查
+	if isDoHURL(dnsServer) {
+		c := &client{ctx: ctx, network: "doh", timeout: opts.timeout, dohMethod: opts.dohMethod, net: opts.net, pin: cand.pin, retries: opts.retries, backoff: opts.backoff, onRetry: onRetry}
+		defer cand.pin.close()
+		dohAttrs, err := probeDoH(c, dnsServer, opts.domain)
+		if err != nil {
+			return nil, err
+		}
+		r := &Result{}
+		r.pass("doh", dohAttrs...)
+		return r, runChecks(c, dnsServer, opts, r)
+	}
+
+	// 选择查询所用的传输协议和端口，条目自带的协议和端口优先于 -transport 和 -port
+	transport := opts.transport
+	if cand.transport != "" {
+		transport = cand.transport
+	}
+	c := &client{ctx: ctx, network: transport, timeout: opts.timeout, tlsName: cand.tlsName, net: opts.net, pin: cand.pin, crypt: cand.crypt, retries: opts.retries, backoff: opts.backoff, onRetry: onRetry}
+	host, port := splitServer(dnsServer)
+	if port == "" {
+		port = opts.port
+	}
+	if port == "" {
+		port = "53"
+		switch transport {
+		case "dot":
+			port = "853"
+		case "dnscrypt":
+			port = "443"
+		}
+	}
+	addr := net.JoinHostPort(host, port)
+	r := &Result{}
+
+	// DoT 模式下先记录证书信息和延迟
+	if transport == "dot" {
+		certAttrs, err := probeDoT(c, addr, cand.tlsName, opts.domain)
+		if err != nil {
+			return nil, err
+		}
+		r.pass("dot", certAttrs...)
+	}
+
+	// DNSCrypt 模式下先取得并验证解析器证书，之后的检查都经加密通道查询
+	if transport == "dnscrypt" {
+		cryptAttrs, err := probeDNSCrypt(c, addr, opts.domain)
+		if err != nil {
+			return nil, err
+		}
+		r.pass("dnscrypt", cryptAttrs...)
+	}
+
+	// 分别检查 UDP 和 TCP，使用其中可用的协议继续检查
+	if transport == "both" {
+		udpErr, tcpErr := checkTransports(c, addr, opts.domain)
+		switch {
+		case udpErr == nil:
+			c.network = "udp"
+		case tcpErr == nil:
+			c.network = "tcp"
+		default:
+			return nil, fmt.Errorf("UDP 和 TCP 均无法查询: %w", udpErr)
+		}
+		r.pass("transport", "udp="+transportStatus(udpErr), "tcp="+transportStatus(tcpErr))
+	}
+
+	return r, runChecks(c, addr, opts, r)
+}
+
+// 使用指定客户端对服务器依次执行检查链，通过的检查及属性列记录在 r 中
+func runChecks(c *client, addr string, opts *options, r *Result) error {
+	r.Transport = c.network
+	defer func() { r.Attempts, r.Retries = c.attempts, c.retried }()
+
+	res := &Resolver{Addr: addr, Transport: c.network, c: c, opts: opts, result: r}
+	for _, check := range opts.checks {
+		cr := check.Check(c.ctx, res)
+		if cr.Err != nil {
+			return cr.failure()
+		}
+		if !cr.Skip {
+			r.pass(check.Name(), cr.Attrs...)
+		}
+	}
+	return nil
+}
+
+// 一次 A/AAAA 查询的结果
+type lookup struct {
+	answers []string
+	rcode   int
+	flags   []string      // A 记录响应中设置的标志
+	rtt     time.Duration // A 记录查询的往返时间
+}
+
+// 向指定服务器查询域名的 A 和 AAAA 记录
+func lookupIPs(c *client, server, domain string) ([]string, error) {
+	l, err := lookupDomain(c, server, domain)
+	if err != nil {
+		return nil, err
+	}
+	return l.answers, nil
+}
+
+// 向指定服务器查询域名的 A 和 AAAA 记录，并记录响应码和延迟
+func lookupDomain(c *client, server, domain string) (*lookup, error) {
+	l := &lookup{}
+	for _, qtype := range []uint16{typeA, typeAAAA} {
+		query := newQuery(domain, qtype)
+		resp, rtt, err := c.exchange(server, query)
+		if err != nil {
+			return nil, err
+		}
+		if qtype == typeA {
+			l.rtt, l.rcode, l.flags = rtt, resp.RCode, resp.flagNames()
+		}
+		if resp.RCode != rcodeSuccess {
+			return nil, rcodeFailureEDE(resp.RCode, fetchExtendedError(c, server, query, resp))
+		}
+		l.answers = append(l.answers, resp.answerValues(qtype)...)
+	}
+	if len(l.answers) == 0 {
+		return nil, newFailure(failNoAnswer, "没有返回任何地址")
+	}
+	return l, nil
+}
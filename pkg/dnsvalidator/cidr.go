@@ -0,0 +1,46 @@
+package dnsvalidator
+
+import (
+	"fmt"
+	"net"
+	"strings"
+)
+
+// 将 CIDR 网段展开为单个 IP 并逐个交给 emit，其余条目原样交给 emit，展开的 IP 沿用网段的来源
+// 无效的网段会被拒绝；单个网段超过 maxHosts 个地址时报错，除非 allowLarge 为 true
+func expandCIDR(cand candidate, maxHosts int, allowLarge bool, rejects *rejectLog, emit func(candidate)) error {
+	entry := cand.server
+	if !strings.Contains(entry, "/") || isDoHURL(entry) {
+		emit(cand)
+		return nil
+	}
+	_, network, err := net.ParseCIDR(entry)
+	if err != nil {
+		rejects.reject(cand, "无效的 CIDR")
+		return nil
+	}
+	ones, bits := network.Mask.Size()
+	if hostBits := bits - ones; !allowLarge && (hostBits >= 31 || 1<<hostBits > maxHosts) {
+		return fmt.Errorf("网段 %s 包含的地址超过 %d 个，请使用 -allow-large 确认展开", entry, maxHosts)
+	}
+	if bits-ones > 32 {
+		return fmt.Errorf("网段 %s 过大，无法展开", entry)
+	}
+	for ip := network.IP.Mask(network.Mask); ip != nil && network.Contains(ip); ip = nextIP(ip) {
+		emit(candidate{server: ip.String(), source: cand.source})
+	}
+	return nil
+}
+
+// 返回下一个 IP 地址，溢出时返回 nil
+func nextIP(ip net.IP) net.IP {
+	next := make(net.IP, len(ip))
+	copy(next, ip)
+	for i := len(next) - 1; i >= 0; i-- {
+		next[i]++
+		if next[i] != 0 {
+			return next
+		}
+	}
+	return nil
+}
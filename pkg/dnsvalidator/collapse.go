@@ -0,0 +1,68 @@
+package dnsvalidator
+
+import (
+	"net"
+	"strings"
+)
+
+// 限制每个 /24 (IPv6 为 /48) 网段最多输出的服务器数量
+type PrefixLimiter struct {
+	max     int
+	counts  map[string]int
+	skipped int
+}
+
+func NewPrefixLimiter(max int) *PrefixLimiter {
+	return &PrefixLimiter{max: max, counts: make(map[string]int)}
+}
+
+// 返回服务器所在的 /24 或 /48 网段，无法解析为 IP 时返回空字符串
+func prefixKey(server string) string {
+	host, _ := splitServer(server)
+	ip := net.ParseIP(host)
+	if ip == nil {
+		return ""
+	}
+	if ip4 := ip.To4(); ip4 != nil {
+		return ip4.Mask(net.CIDRMask(24, 32)).String() + "/24"
+	}
+	return ip.Mask(net.CIDRMask(48, 128)).String() + "/48"
+}
+
+// 返回因超过限制而省略的服务器数量
+func (l *PrefixLimiter) Skipped() int {
+	return l.skipped
+}
+
+// 判断服务器是否还能输出，max 为 0 时不限制
+func (l *PrefixLimiter) Allow(server string) bool {
+	if l.max <= 0 {
+		return true
+	}
+	key := prefixKey(server)
+	if key == "" {
+		return true
+	}
+	if l.counts[key] >= l.max {
+		l.skipped++
+		return false
+	}
+	l.counts[key]++
+	return true
+}
+
+// 返回用于去重的条目键：IP 统一为规范写法，其余条目原样使用
+func dedupKey(entry string) string {
+	if isDoHURL(entry) {
+		return entry
+	}
+	host, port := splitServer(entry)
+	ip := net.ParseIP(host)
+	if ip == nil {
+		return strings.ToLower(entry)
+	}
+	if port == "" || port == "53" {
+		return ip.String()
+	}
+	return net.JoinHostPort(ip.String(), port)
+}
@@ -0,0 +1,35 @@
+package dnsvalidator
+
+import (
+	"bytes"
+	"crypto/rand"
+	"fmt"
+)
+
+// DNS Cookie 选项编号 (RFC 7873)
+const ednsCookie uint16 = 10
+
+// 发送客户端 Cookie，检查服务器是否返回有效的服务器 Cookie
+// 有效的应答须原样带回 8 字节客户端 Cookie，并附带 8 到 32 字节的服务器 Cookie
+func checkCookie(c *client, server, domain string) (bool, error) {
+	clientCookie := make([]byte, 8)
+	if _, err := rand.Read(clientCookie); err != nil {
+		return false, err
+	}
+	query := newQuery(domain, typeA)
+	query.setEDNS(1232, false)
+	query.addEDNSOption(ednsCookie, clientCookie)
+	resp, _, err := c.exchange(server, query)
+	if err != nil {
+		return false, fmt.Errorf("Cookie 查询失败: %w", err)
+	}
+	opt := resp.opt()
+	if opt == nil {
+		return false, nil
+	}
+	option := opt.ednsOption(ednsCookie)
+	if option == nil || len(option.Data) < 16 || len(option.Data) > 40 {
+		return false, nil
+	}
+	return bytes.Equal(option.Data[:8], clientCookie), nil
+}
@@ -0,0 +1,42 @@
+package dnsvalidator
+
+import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/klauspost/compress/zstd"
+)
+
+// 压缩格式的魔数
+var (
+	gzipMagic = []byte{0x1f, 0x8b}
+	zstdMagic = []byte{0x28, 0xb5, 0x2f, 0xfd}
+)
+
+// 根据文件名、Content-Encoding 或数据开头的魔数透明解压 gzip 和 zstd 数据，
+// 未压缩的数据原样返回
+func decompressReader(r io.Reader, name, encoding string) (io.ReadCloser, error) {
+	br := bufio.NewReader(r)
+	magic, _ := br.Peek(4)
+	encoding = strings.ToLower(strings.TrimSpace(encoding))
+
+	switch {
+	case encoding == "gzip" || strings.HasSuffix(name, ".gz") || bytes.HasPrefix(magic, gzipMagic):
+		zr, err := gzip.NewReader(br)
+		if err != nil {
+			return nil, fmt.Errorf("无法解压 gzip 数据 %s: %v", name, err)
+		}
+		return zr, nil
+	case encoding == "zstd" || strings.HasSuffix(name, ".zst") || bytes.HasPrefix(magic, zstdMagic):
+		zr, err := zstd.NewReader(br)
+		if err != nil {
+			return nil, fmt.Errorf("无法解压 zstd 数据 %s: %v", name, err)
+		}
+		return zr.IOReadCloser(), nil
+	}
+	return io.NopCloser(br), nil
+}
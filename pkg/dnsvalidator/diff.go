@@ -0,0 +1,141 @@
+package dnsvalidator
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+)
+
+// 两次运行之间单台服务器的变化类型
+const (
+	ChangeLatency   = "latency"   // 延迟明显变慢
+	ChangeFiltering = "filtering" // 开始过滤、审查或拦截域名
+	ChangePoisoned  = "poisoned"  // 开始返回被篡改的答案或劫持 NXDOMAIN
+	ChangeAnswers   = "answers"   // 主检查域名的答案换到了不同的网段
+)
+
+// 因以下类别失败的服务器视为开始篡改答案，而不是简单地变得不可用
+var poisonedCategories = map[string]bool{failWrongAnswer: true, failHijack: true, failBogon: true}
+
+// 因以下类别失败的服务器视为开始过滤
+var filteringCategories = map[string]bool{failSinkhole: true, failFilterClass: true}
+
+// 单台服务器的变化
+type ResultChange struct {
+	Server string  `json:"server"`
+	Kind   string  `json:"kind"`   // latency、filtering、poisoned 或 answers
+	Detail string  `json:"detail"` // 变化的说明，如 "12.3ms -> 80.1ms"
+	Old    *Result `json:"old"`
+	New    *Result `json:"new"`
+}
+
+// 两次运行结果的差异，各列表按服务器名称排列
+type ResultDiff struct {
+	Added   []*Result      `json:"added"`   // 新增的可用服务器
+	Removed []*Result      `json:"removed"` // 不再可用的服务器，新结果中有失败记录时为失败记录，否则为旧结果
+	Changed []ResultChange `json:"changed"`
+}
+
+// 比较的阈值，零值字段使用括号中的默认值
+type DiffOptions struct {
+	LatencyRatio float64 // 新延迟至少是旧延迟的该倍数才算变慢 (2)
+	LatencyMin   float64 // 且至少增加该毫秒数，避免低延迟服务器的正常波动 (20)
+}
+
+// 读取 JSON 数组或 JSONL 格式的结果，即 -format json 或 jsonl 的输出
+func ReadResults(r io.Reader) ([]*Result, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+	data = bytes.TrimSpace(data)
+	var results []*Result
+	if len(data) > 0 && data[0] == '[' {
+		if err := json.Unmarshal(data, &results); err != nil {
+			return nil, fmt.Errorf("无法解析 JSON 结果: %v", err)
+		}
+		return results, nil
+	}
+	dec := json.NewDecoder(bytes.NewReader(data))
+	for dec.More() {
+		r := &Result{}
+		if err := dec.Decode(r); err != nil {
+			return nil, fmt.Errorf("无法解析第 %d 条 JSONL 结果: %v", len(results)+1, err)
+		}
+		results = append(results, r)
+	}
+	return results, nil
+}
+
+// 比较两次运行的结果，找出新增、移除和有变化的服务器。-include-failed 输出的失败记录不算可用，
+// 但可以说明服务器不再可用的原因：因答案不一致、劫持或保留地址失败的记为 poisoned，因拦截或过滤类型失败的记为 filtering
+func DiffResults(old, cur []*Result, opts DiffOptions) *ResultDiff {
+	if opts.LatencyRatio <= 0 {
+		opts.LatencyRatio = 2
+	}
+	if opts.LatencyMin <= 0 {
+		opts.LatencyMin = 20
+	}
+	index := func(results []*Result) map[string]*Result {
+		m := make(map[string]*Result, len(results))
+		for _, r := range results {
+			m[r.Server] = r
+		}
+		return m
+	}
+	oldByServer, newByServer := index(old), index(cur)
+
+	d := &ResultDiff{}
+	for _, n := range cur {
+		if o := oldByServer[n.Server]; n.Error == "" && (o == nil || o.Error != "") {
+			d.Added = append(d.Added, n)
+		}
+	}
+	for _, o := range old {
+		if o.Error != "" {
+			continue
+		}
+		n := newByServer[o.Server]
+		switch {
+		case n == nil:
+			d.Removed = append(d.Removed, o)
+		case n.Error != "" && poisonedCategories[n.Category]:
+			d.Changed = append(d.Changed, ResultChange{Server: o.Server, Kind: ChangePoisoned, Detail: n.Error, Old: o, New: n})
+		case n.Error != "" && filteringCategories[n.Category]:
+			d.Changed = append(d.Changed, ResultChange{Server: o.Server, Kind: ChangeFiltering, Detail: n.Error, Old: o, New: n})
+		case n.Error != "":
+			d.Removed = append(d.Removed, n)
+		default:
+			d.Changed = append(d.Changed, compareResults(o, n, opts)...)
+		}
+	}
+
+	sort.Slice(d.Added, func(i, j int) bool { return d.Added[i].Server < d.Added[j].Server })
+	sort.Slice(d.Removed, func(i, j int) bool { return d.Removed[i].Server < d.Removed[j].Server })
+	sort.SliceStable(d.Changed, func(i, j int) bool { return d.Changed[i].Server < d.Changed[j].Server })
+	return d
+}
+
+// 比较同一台服务器两次都可用时的结果
+func compareResults(o, n *Result, opts DiffOptions) []ResultChange {
+	var changes []ResultChange
+	add := func(kind, format string, args ...interface{}) {
+		changes = append(changes, ResultChange{Server: o.Server, Kind: kind, Detail: fmt.Sprintf(format, args...), Old: o, New: n})
+	}
+	if o.LatencyMs > 0 && n.LatencyMs >= o.LatencyMs*opts.LatencyRatio && n.LatencyMs-o.LatencyMs >= opts.LatencyMin {
+		add(ChangeLatency, "%gms -> %gms", o.LatencyMs, n.LatencyMs)
+	}
+	oldAttrs, newAttrs := o.Attributes(), n.Attributes()
+	if before, after := oldAttrs["filter_class"], newAttrs["filter_class"]; before != "" && after != before && after != classUnfiltered {
+		add(ChangeFiltering, "filter_class %s -> %s", before, after)
+	}
+	if oldAttrs["censors"] == "false" && newAttrs["censors"] == "true" {
+		add(ChangeFiltering, "开始审查 %s", newAttrs["censored"])
+	}
+	if len(o.Answers) > 0 && len(n.Answers) > 0 && !sameNetwork(n.Answers, o.Answers) {
+		add(ChangeAnswers, "%v -> %v", o.Answers, n.Answers)
+	}
+	return changes
+}
@@ -0,0 +1,662 @@
+package dnsvalidator
+
+import (
+	"context"
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"math/rand"
+	"net"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// DNS 记录类型
+const (
+	typeA      uint16 = 1
+	typeNS     uint16 = 2
+	typeCNAME  uint16 = 5
+	typeSOA    uint16 = 6
+	typePTR    uint16 = 12
+	typeMX     uint16 = 15
+	typeTXT    uint16 = 16
+	typeAAAA   uint16 = 28
+	typeOPT    uint16 = 41
+	typeDNSKEY uint16 = 48
+)
+
+// DNS 记录类
+const (
+	classINET uint16 = 1
+)
+
+// DNS 响应码
+const (
+	rcodeSuccess  = 0
+	rcodeFormErr  = 1
+	rcodeServFail = 2
+	rcodeNXDomain = 3
+	rcodeNotImp   = 4
+	rcodeRefused  = 5
+)
+
+// 记录类型名称与编号的对应关系
+var typeNames = map[uint16]string{
+	typeA:      "A",
+	typeNS:     "NS",
+	typeCNAME:  "CNAME",
+	typeSOA:    "SOA",
+	typePTR:    "PTR",
+	typeMX:     "MX",
+	typeTXT:    "TXT",
+	typeAAAA:   "AAAA",
+	typeOPT:    "OPT",
+	typeDNSKEY: "DNSKEY",
+}
+
+// 响应码名称
+var rcodeNames = map[int]string{
+	rcodeSuccess:  "NOERROR",
+	rcodeFormErr:  "FORMERR",
+	rcodeServFail: "SERVFAIL",
+	rcodeNXDomain: "NXDOMAIN",
+	rcodeNotImp:   "NOTIMP",
+	rcodeRefused:  "REFUSED",
+}
+
+var errMalformed = errors.New("DNS 响应格式错误")
+
+// 返回记录类型的名称
+func typeString(t uint16) string {
+	if name, ok := typeNames[t]; ok {
+		return name
+	}
+	return "TYPE" + strconv.Itoa(int(t))
+}
+
+// 返回响应码的名称
+func rcodeString(rcode int) string {
+	if name, ok := rcodeNames[rcode]; ok {
+		return name
+	}
+	return "RCODE" + strconv.Itoa(rcode)
+}
+
+// DNS 问题段
+type dnsQuestion struct {
+	Name  string
+	Type  uint16
+	Class uint16
+}
+
+// DNS 资源记录，Value 为解析后的可读值
+type dnsRR struct {
+	Name  string
+	Type  uint16
+	Class uint16
+	TTL   uint32
+	Data  []byte
+	Value string
+}
+
+// DNS 报文
+type dnsMessage struct {
+	ID                 uint16
+	Response           bool
+	Opcode             int
+	Authoritative      bool
+	Truncated          bool
+	RecursionDesired   bool
+	RecursionAvailable bool
+	AuthenticData      bool
+	CheckingDisabled   bool
+	RCode              int
+	Questions          []dnsQuestion
+	Answers            []dnsRR
+	Authority          []dnsRR
+	Additional         []dnsRR
+	Size               int // 解析时的报文字节数
+}
+
+// 构造一个查询报文
+func newQuery(name string, qtype uint16) *dnsMessage {
+	return &dnsMessage{
+		ID:               uint16(rand.Intn(1 << 16)),
+		RecursionDesired: true,
+		Questions:        []dnsQuestion{{Name: name, Type: qtype, Class: classINET}},
+	}
+}
+
+// 在附加段中加入 EDNS0 OPT 记录，udpSize 为通告的 UDP 缓冲区大小，do 表示请求 DNSSEC 记录
+func (m *dnsMessage) setEDNS(udpSize uint16, do bool) {
+	var ttl uint32
+	if do {
+		ttl |= 1 << 15
+	}
+	m.Additional = append(m.Additional, dnsRR{Name: ".", Type: typeOPT, Class: udpSize, TTL: ttl})
+}
+
+// EDNS0 选项
+type ednsOption struct {
+	Code uint16
+	Data []byte
+}
+
+// 向 OPT 记录追加一个 EDNS0 选项，需先调用 setEDNS
+func (m *dnsMessage) addEDNSOption(code uint16, data []byte) {
+	opt := m.opt()
+	if opt == nil {
+		return
+	}
+	buf := append([]byte(nil), opt.Data...)
+	buf = binary.BigEndian.AppendUint16(buf, code)
+	buf = binary.BigEndian.AppendUint16(buf, uint16(len(data)))
+	opt.Data = append(buf, data...)
+}
+
+// 解析 OPT 记录中的 EDNS0 选项
+func (rr *dnsRR) ednsOptions() []ednsOption {
+	var options []ednsOption
+	for data := rr.Data; len(data) >= 4; {
+		code := binary.BigEndian.Uint16(data)
+		l := int(binary.BigEndian.Uint16(data[2:]))
+		if 4+l > len(data) {
+			break
+		}
+		options = append(options, ednsOption{Code: code, Data: data[4 : 4+l]})
+		data = data[4+l:]
+	}
+	return options
+}
+
+// 返回 OPT 记录中指定编号的 EDNS0 选项，没有则返回 nil
+func (rr *dnsRR) ednsOption(code uint16) *ednsOption {
+	for _, option := range rr.ednsOptions() {
+		if option.Code == code {
+			return &option
+		}
+	}
+	return nil
+}
+
+// 返回附加段中的 OPT 记录，没有则返回 nil
+func (m *dnsMessage) opt() *dnsRR {
+	for i := range m.Additional {
+		if m.Additional[i].Type == typeOPT {
+			return &m.Additional[i]
+		}
+	}
+	return nil
+}
+
+// 将报文编码为线上格式（不做名称压缩）
+func (m *dnsMessage) pack() ([]byte, error) {
+	buf := make([]byte, 12, 512)
+	binary.BigEndian.PutUint16(buf[0:], m.ID)
+
+	var flags uint16
+	if m.Response {
+		flags |= 1 << 15
+	}
+	flags |= uint16(m.Opcode&0xf) << 11
+	if m.Authoritative {
+		flags |= 1 << 10
+	}
+	if m.Truncated {
+		flags |= 1 << 9
+	}
+	if m.RecursionDesired {
+		flags |= 1 << 8
+	}
+	if m.RecursionAvailable {
+		flags |= 1 << 7
+	}
+	if m.AuthenticData {
+		flags |= 1 << 5
+	}
+	if m.CheckingDisabled {
+		flags |= 1 << 4
+	}
+	flags |= uint16(m.RCode & 0xf)
+	binary.BigEndian.PutUint16(buf[2:], flags)
+	binary.BigEndian.PutUint16(buf[4:], uint16(len(m.Questions)))
+	binary.BigEndian.PutUint16(buf[6:], uint16(len(m.Answers)))
+	binary.BigEndian.PutUint16(buf[8:], uint16(len(m.Authority)))
+	binary.BigEndian.PutUint16(buf[10:], uint16(len(m.Additional)))
+
+	var err error
+	for _, q := range m.Questions {
+		if buf, err = appendName(buf, q.Name); err != nil {
+			return nil, err
+		}
+		buf = binary.BigEndian.AppendUint16(buf, q.Type)
+		buf = binary.BigEndian.AppendUint16(buf, q.Class)
+	}
+	for _, section := range [][]dnsRR{m.Answers, m.Authority, m.Additional} {
+		for _, rr := range section {
+			if buf, err = appendName(buf, rr.Name); err != nil {
+				return nil, err
+			}
+			buf = binary.BigEndian.AppendUint16(buf, rr.Type)
+			buf = binary.BigEndian.AppendUint16(buf, rr.Class)
+			buf = binary.BigEndian.AppendUint32(buf, rr.TTL)
+			buf = binary.BigEndian.AppendUint16(buf, uint16(len(rr.Data)))
+			buf = append(buf, rr.Data...)
+		}
+	}
+	return buf, nil
+}
+
+// 以长度前缀标签的形式追加域名
+func appendName(buf []byte, name string) ([]byte, error) {
+	name = strings.TrimSuffix(name, ".")
+	if name == "" {
+		return append(buf, 0), nil
+	}
+	for _, label := range strings.Split(name, ".") {
+		if len(label) == 0 || len(label) > 63 {
+			return nil, fmt.Errorf("无效的域名: %s", name)
+		}
+		buf = append(buf, byte(len(label)))
+		buf = append(buf, label...)
+	}
+	return append(buf, 0), nil
+}
+
+// 解析线上格式的报文
+func parseMessage(msg []byte) (*dnsMessage, error) {
+	if len(msg) < 12 {
+		return nil, errMalformed
+	}
+	flags := binary.BigEndian.Uint16(msg[2:])
+	m := &dnsMessage{
+		ID:                 binary.BigEndian.Uint16(msg[0:]),
+		Response:           flags&(1<<15) != 0,
+		Opcode:             int(flags>>11) & 0xf,
+		Authoritative:      flags&(1<<10) != 0,
+		Truncated:          flags&(1<<9) != 0,
+		RecursionDesired:   flags&(1<<8) != 0,
+		RecursionAvailable: flags&(1<<7) != 0,
+		AuthenticData:      flags&(1<<5) != 0,
+		CheckingDisabled:   flags&(1<<4) != 0,
+		RCode:              int(flags & 0xf),
+		Size:               len(msg),
+	}
+	qdcount := int(binary.BigEndian.Uint16(msg[4:]))
+	counts := []int{
+		int(binary.BigEndian.Uint16(msg[6:])),
+		int(binary.BigEndian.Uint16(msg[8:])),
+		int(binary.BigEndian.Uint16(msg[10:])),
+	}
+
+	off := 12
+	for i := 0; i < qdcount; i++ {
+		name, n, err := readName(msg, off)
+		if err != nil {
+			return nil, err
+		}
+		off = n
+		if off+4 > len(msg) {
+			return nil, errMalformed
+		}
+		m.Questions = append(m.Questions, dnsQuestion{
+			Name:  name,
+			Type:  binary.BigEndian.Uint16(msg[off:]),
+			Class: binary.BigEndian.Uint16(msg[off+2:]),
+		})
+		off += 4
+	}
+
+	sections := []*[]dnsRR{&m.Answers, &m.Authority, &m.Additional}
+	for i, section := range sections {
+		for j := 0; j < counts[i]; j++ {
+			rr, n, err := readRR(msg, off)
+			if err != nil {
+				return nil, err
+			}
+			off = n
+			*section = append(*section, rr)
+		}
+	}
+	return m, nil
+}
+
+// 读取一条资源记录
+func readRR(msg []byte, off int) (dnsRR, int, error) {
+	name, off, err := readName(msg, off)
+	if err != nil {
+		return dnsRR{}, 0, err
+	}
+	if off+10 > len(msg) {
+		return dnsRR{}, 0, errMalformed
+	}
+	rr := dnsRR{
+		Name:  name,
+		Type:  binary.BigEndian.Uint16(msg[off:]),
+		Class: binary.BigEndian.Uint16(msg[off+2:]),
+		TTL:   binary.BigEndian.Uint32(msg[off+4:]),
+	}
+	rdlen := int(binary.BigEndian.Uint16(msg[off+8:]))
+	off += 10
+	if off+rdlen > len(msg) {
+		return dnsRR{}, 0, errMalformed
+	}
+	rr.Data = msg[off : off+rdlen]
+	rr.Value, err = rdataString(msg, off, rr.Type, rr.Data)
+	if err != nil {
+		return dnsRR{}, 0, err
+	}
+	return rr, off + rdlen, nil
+}
+
+// 将记录数据转换为可读字符串
+func rdataString(msg []byte, off int, rrtype uint16, data []byte) (string, error) {
+	switch rrtype {
+	case typeA:
+		if len(data) != net.IPv4len {
+			return "", errMalformed
+		}
+		return net.IP(data).String(), nil
+	case typeAAAA:
+		if len(data) != net.IPv6len {
+			return "", errMalformed
+		}
+		return net.IP(data).String(), nil
+	case typeCNAME, typeNS, typePTR:
+		name, _, err := readName(msg, off)
+		return name, err
+	case typeMX:
+		if len(data) < 3 {
+			return "", errMalformed
+		}
+		name, _, err := readName(msg, off+2)
+		return strconv.Itoa(int(binary.BigEndian.Uint16(data))) + " " + name, err
+	case typeTXT:
+		var parts []string
+		for i := 0; i < len(data); {
+			l := int(data[i])
+			if i+1+l > len(data) {
+				return "", errMalformed
+			}
+			parts = append(parts, string(data[i+1:i+1+l]))
+			i += 1 + l
+		}
+		return strings.Join(parts, ""), nil
+	}
+	return hex.EncodeToString(data), nil
+}
+
+// 读取域名，支持名称压缩指针，返回域名及其后的偏移
+func readName(msg []byte, off int) (string, int, error) {
+	var labels []string
+	end := -1
+	for jumps := 0; ; {
+		if off >= len(msg) {
+			return "", 0, errMalformed
+		}
+		l := int(msg[off])
+		switch {
+		case l == 0:
+			off++
+			if end < 0 {
+				end = off
+			}
+			return strings.Join(labels, ".") + ".", end, nil
+		case l&0xc0 == 0xc0:
+			if off+1 >= len(msg) {
+				return "", 0, errMalformed
+			}
+			if jumps++; jumps > 32 {
+				return "", 0, errMalformed
+			}
+			if end < 0 {
+				end = off + 2
+			}
+			off = int(binary.BigEndian.Uint16(msg[off:]) & 0x3fff)
+		case l&0xc0 != 0:
+			return "", 0, errMalformed
+		default:
+			if off+1+l > len(msg) {
+				return "", 0, errMalformed
+			}
+			labels = append(labels, string(msg[off+1:off+1+l]))
+			off += 1 + l
+		}
+	}
+}
+
+// 返回报文中设置的标志名称，按报文头中的顺序排列
+func (m *dnsMessage) flagNames() []string {
+	var flags []string
+	for _, f := range []struct {
+		set  bool
+		name string
+	}{
+		{m.Response, "qr"}, {m.Authoritative, "aa"}, {m.Truncated, "tc"}, {m.RecursionDesired, "rd"},
+		{m.RecursionAvailable, "ra"}, {m.AuthenticData, "ad"}, {m.CheckingDisabled, "cd"},
+	} {
+		if f.set {
+			flags = append(flags, f.name)
+		}
+	}
+	return flags
+}
+
+// 返回应答段中指定类型记录的值
+func (m *dnsMessage) answerValues(rrtype uint16) []string {
+	var values []string
+	for _, rr := range m.Answers {
+		if rr.Type == rrtype {
+			values = append(values, rr.Value)
+		}
+	}
+	return values
+}
+
+// DNS 查询客户端
+type client struct {
+	ctx       context.Context // 取消后进行中的查询立即结束
+	network   string          // udp、tcp、dot、doh 或 dnscrypt
+	timeout   time.Duration
+	dohMethod string    // DoH 请求方法: GET 或 POST
+	tlsName   string    // DoT 握手时使用的服务器名称，为空则不发送 SNI 也不校验主机名
+	net       *netState // 本次运行共享的网络设置，为空时使用默认设置
+	pin       *stampPin // 来自 DNS Stamp 的 DoH/DoT 连接地址和证书哈希
+
+	crypt        *dnscryptProvider // DNSCrypt 提供者，network 为 dnscrypt 时使用
+	cryptSession *dnscryptSession  // 第一次 DNSCrypt 查询时取得的证书和密钥
+
+	retries  int             // 超时后的最多重试次数
+	backoff  time.Duration   // 第一次重试前的等待时间，之后每次翻倍 (不超过 maxBackoff) 并加入随机抖动
+	attempts int             // 已发送的查询次数，包括重试
+	retried  int             // 超时后重试的次数
+	rtts     []time.Duration // 该服务器成功查询的 RTT，用于自适应超时
+	onRetry  func(attempt int, err error)
+}
+
+// 向指定服务器发送查询，返回响应及往返耗时；超时时按指数退避重试
+func (c *client) exchange(server string, query *dnsMessage) (*dnsMessage, time.Duration, error) {
+	for i := 0; ; i++ {
+		if err := c.ctx.Err(); err != nil {
+			return nil, 0, err
+		}
+		c.attempts++
+		resp, rtt, err := c.exchangeOnce(server, query, c.net.timeout(c.rtts, c.timeout))
+		if err == nil {
+			c.rtts = append(c.rtts, rtt)
+			c.net.observe(rtt)
+		}
+		if err == nil || i >= c.retries || failureCategory(err) != failTimeout {
+			return resp, rtt, err
+		}
+		c.retried++
+		if c.onRetry != nil {
+			c.onRetry(c.retried, err)
+		}
+		if delay := retryDelay(c.backoff, i); delay > 0 {
+			select {
+			case <-time.After(delay):
+			case <-c.ctx.Done():
+				return nil, 0, c.ctx.Err()
+			}
+		}
+	}
+}
+
+// 重试等待时间的上限，避免 -retries 较大时等待时间溢出或长得没有意义
+const maxBackoff = 10 * time.Second
+
+// 返回第 i 次重试 (从 0 开始) 前的等待时间：backoff 每次翻倍，不超过 maxBackoff，再加上至多同样长的随机抖动
+func retryDelay(backoff time.Duration, i int) time.Duration {
+	if backoff <= 0 {
+		return 0
+	}
+	delay := maxBackoff
+	if i < 32 && backoff <= maxBackoff>>i {
+		delay = backoff << i
+	}
+	return delay + time.Duration(rand.Int63n(int64(delay)))
+}
+
+// 发送一次查询，不做重试
+func (c *client) exchangeOnce(server string, query *dnsMessage, timeout time.Duration) (*dnsMessage, time.Duration, error) {
+	switch c.network {
+	case "tcp":
+		return exchangeTCP(c.ctx, c.net, server, query, timeout)
+	case "dot":
+		resp, rtt, _, err := exchangeTLS(c.ctx, c.net, server, c.tlsName, c.pin, query, timeout)
+		return resp, rtt, err
+	case "doh":
+		resp, rtt, _, err := exchangeDoH(c.ctx, c.net, server, c.pin, query, c.dohMethod, timeout)
+		return resp, rtt, err
+	case "dnscrypt":
+		if c.cryptSession == nil {
+			s, err := newDNSCryptSession(c, server, c.crypt)
+			if err != nil {
+				return nil, 0, err
+			}
+			c.cryptSession = s
+		}
+		return exchangeDNSCrypt(c.ctx, c.net, server, c.cryptSession, query, timeout)
+	}
+	resp, rtt, err := exchangeUDP(c.ctx, c.net, server, query, timeout)
+	// 应答被截断时自动改用 TCP 重试
+	if err == nil && resp.Truncated {
+		return exchangeTCP(c.ctx, c.net, server, query, timeout)
+	}
+	return resp, rtt, err
+}
+
+// 通过 UDP 发送查询
+func exchangeUDP(ctx context.Context, n *netState, server string, query *dnsMessage, timeout time.Duration) (*dnsMessage, time.Duration, error) {
+	if err := n.wait(ctx); err != nil {
+		return nil, 0, err
+	}
+	if ex := exchangerFrom(ctx); ex != nil {
+		return exchangeVia(ctx, ex, "udp", server, query, timeout)
+	}
+	req, err := packQuery(ctx, "udp", server, query)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	conn, err := n.dialer(timeout).DialContext(ctx, "udp", server)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer conn.Close()
+	defer watchConn(ctx, conn)()
+
+	start := time.Now()
+	conn.SetDeadline(queryDeadline(ctx, start, timeout))
+	if _, err := conn.Write(req); err != nil {
+		return nil, 0, err
+	}
+
+	buf := make([]byte, 65535)
+	for {
+		n, err := conn.Read(buf)
+		if err != nil {
+			return nil, 0, err
+		}
+		resp, err := parseResponse(ctx, "udp", server, buf[:n])
+		if err != nil {
+			return nil, 0, err
+		}
+		// 忽略 ID 或问题段不匹配的报文，防止被伪造的响应干扰
+		if resp.ID != query.ID || !resp.Response || !sameQuestion(query, resp) {
+			continue
+		}
+		return resp, time.Since(start), nil
+	}
+}
+
+// 通过 TCP 发送查询
+func exchangeTCP(ctx context.Context, n *netState, server string, query *dnsMessage, timeout time.Duration) (*dnsMessage, time.Duration, error) {
+	if err := n.wait(ctx); err != nil {
+		return nil, 0, err
+	}
+	if ex := exchangerFrom(ctx); ex != nil {
+		return exchangeVia(ctx, ex, "tcp", server, query, timeout)
+	}
+	start := time.Now()
+	conn, err := n.dialer(timeout).DialContext(ctx, "tcp", server)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer conn.Close()
+	defer watchConn(ctx, conn)()
+
+	conn.SetDeadline(queryDeadline(ctx, start, timeout))
+	resp, err := exchangeStream(ctx, "tcp", server, conn, query)
+	if err != nil {
+		return nil, 0, err
+	}
+	return resp, time.Since(start), nil
+}
+
+// 在面向流的连接上发送查询并读取响应，报文前带两字节长度前缀
+func exchangeStream(ctx context.Context, network, server string, conn net.Conn, query *dnsMessage) (*dnsMessage, error) {
+	req, err := packQuery(ctx, network, server, query)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := conn.Write(append(binary.BigEndian.AppendUint16(nil, uint16(len(req))), req...)); err != nil {
+		return nil, err
+	}
+
+	var length [2]byte
+	if _, err := io.ReadFull(conn, length[:]); err != nil {
+		return nil, err
+	}
+	buf := make([]byte, binary.BigEndian.Uint16(length[:]))
+	if _, err := io.ReadFull(conn, buf); err != nil {
+		return nil, err
+	}
+	resp, err := parseResponse(ctx, network, server, buf)
+	if err != nil {
+		return nil, err
+	}
+	if resp.ID != query.ID || !resp.Response || !sameQuestion(query, resp) {
+		return nil, errMalformed
+	}
+	return resp, nil
+}
+
+// 判断响应的问题段是否与查询一致
+func sameQuestion(query, resp *dnsMessage) bool {
+	if len(resp.Questions) != len(query.Questions) {
+		return false
+	}
+	for i, q := range query.Questions {
+		r := resp.Questions[i]
+		if r.Type != q.Type || r.Class != q.Class ||
+			!strings.EqualFold(strings.TrimSuffix(r.Name, "."), strings.TrimSuffix(q.Name, ".")) {
+			return false
+		}
+	}
+	return true
+}
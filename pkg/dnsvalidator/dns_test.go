@@ -0,0 +1,154 @@
+package dnsvalidator
+
+import (
+	"errors"
+	"slices"
+	"testing"
+	"time"
+)
+
+// 带名称压缩的应答：example.com 的 CNAME 指向 www.example.com，后者有 A 记录，另有 MX 和分段的 TXT 记录
+var compressedResponse = []byte{
+	0x12, 0x34, 0x81, 0x80, 0, 1, 0, 4, 0, 0, 0, 0,
+	// 12: example.com A IN
+	7, 'e', 'x', 'a', 'm', 'p', 'l', 'e', 3, 'c', 'o', 'm', 0, 0, 1, 0, 1,
+	// 29: example.com CNAME www.example.com，数据中的 example.com 为指向 12 的指针
+	0xc0, 12, 0, 5, 0, 1, 0, 0, 0x0e, 0x10, 0, 6, 3, 'w', 'w', 'w', 0xc0, 12,
+	// 47: www.example.com A 93.184.216.34，名称为指向 41 (CNAME 数据) 的指针
+	0xc0, 41, 0, 1, 0, 1, 0, 0, 0, 60, 0, 4, 93, 184, 216, 34,
+	// 63: example.com MX 10 mail.example.com
+	0xc0, 12, 0, 15, 0, 1, 0, 0, 0, 60, 0, 9, 0, 10, 4, 'm', 'a', 'i', 'l', 0xc0, 12,
+	// 84: example.com TXT "v=spf1" " -all"
+	0xc0, 12, 0, 16, 0, 1, 0, 0, 0, 60, 0, 13, 6, 'v', '=', 's', 'p', 'f', '1', 5, ' ', '-', 'a', 'l', 'l',
+}
+
+func TestParseCompressed(t *testing.T) {
+	m, err := parseMessage(compressedResponse)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if m.ID != 0x1234 || !m.Response || !m.RecursionDesired || !m.RecursionAvailable || m.RCode != rcodeSuccess {
+		t.Errorf("报文头解析错误: %+v", m)
+	}
+	if len(m.Questions) != 1 || m.Questions[0].Name != "example.com." || m.Questions[0].Type != typeA {
+		t.Errorf("问题段为 %+v", m.Questions)
+	}
+	want := []struct {
+		name  string
+		rtype uint16
+		value string
+	}{
+		{"example.com.", typeCNAME, "www.example.com."},
+		{"www.example.com.", typeA, "93.184.216.34"},
+		{"example.com.", typeMX, "10 mail.example.com."},
+		{"example.com.", typeTXT, "v=spf1 -all"},
+	}
+	if len(m.Answers) != len(want) {
+		t.Fatalf("答案段有 %d 条记录，应为 %d 条", len(m.Answers), len(want))
+	}
+	for i, w := range want {
+		rr := m.Answers[i]
+		if rr.Name != w.name || rr.Type != w.rtype || rr.Value != w.value {
+			t.Errorf("第 %d 条记录为 %s %s %q，应为 %s %s %q", i+1, rr.Name, typeString(rr.Type), rr.Value, w.name, typeString(w.rtype), w.value)
+		}
+	}
+	if got := m.answerValues(typeA); !slices.Equal(got, []string{"93.184.216.34"}) {
+		t.Errorf("A 记录为 %v", got)
+	}
+}
+
+// 截断、越界和循环的名称压缩指针都返回 errMalformed，不会死循环或越界
+func TestParseMalformed(t *testing.T) {
+	header := func(an int) []byte { return []byte{0, 1, 0x81, 0x80, 0, 0, 0, byte(an), 0, 0, 0, 0} }
+	rr := func(name []byte, rtype uint16, data ...byte) []byte {
+		b := append(header(1), name...)
+		return append(append(b, byte(rtype>>8), byte(rtype), 0, 1, 0, 0, 0, 60, 0, byte(len(data))), data...)
+	}
+	tests := []struct {
+		name string
+		msg  []byte
+	}{
+		{"报文头不完整", []byte{0, 1, 0x81}},
+		{"问题段缺少类型", append([]byte{0, 1, 0x81, 0x80, 0, 1, 0, 0, 0, 0, 0, 0}, 1, 'a', 0, 0)},
+		{"标签越界", rr([]byte{9, 'a', 0}, typeA, 1, 2, 3, 4)},
+		{"指针指向自身", rr([]byte{0xc0, 12}, typeA, 1, 2, 3, 4)},
+		{"指针越界", rr([]byte{0xc0, 0xff}, typeA, 1, 2, 3, 4)},
+		{"指针不完整", append(header(1), 0xc0)},
+		{"保留的标签类型", rr([]byte{0x40, 'a', 0}, typeA, 1, 2, 3, 4)},
+		{"A 记录长度错误", rr([]byte{0}, typeA, 1, 2, 3)},
+		{"AAAA 记录长度错误", rr([]byte{0}, typeAAAA, 1, 2, 3, 4)},
+		{"TXT 字符串越界", rr([]byte{0}, typeTXT, 5, 'a')},
+		{"记录数据越界", append(rr([]byte{0}, typeA, 1, 2, 3, 4)[:20], 0, 10)},
+		{"记录数多于实际", append(header(2), rr([]byte{0}, typeA, 1, 2, 3, 4)[12:]...)},
+	}
+	for _, tt := range tests {
+		if _, err := parseMessage(tt.msg); !errors.Is(err, errMalformed) {
+			t.Errorf("%s: parseMessage 返回 %v，应为 errMalformed", tt.name, err)
+		}
+	}
+}
+
+// 打包后再解析得到相同的报文头、问题段和 EDNS 设置
+func TestPackRoundTrip(t *testing.T) {
+	tests := []struct {
+		name  string
+		qtype uint16
+		edns  bool
+	}{
+		{"google.com", typeA, false},
+		{"Example.COM.", typeAAAA, true},
+		{".", typeDNSKEY, true},
+	}
+	for _, tt := range tests {
+		q := newQuery(tt.name, tt.qtype)
+		q.CheckingDisabled = true
+		if tt.edns {
+			q.setEDNS(4096, true)
+		}
+		data, err := q.pack()
+		if err != nil {
+			t.Fatal(err)
+		}
+		m, err := parseMessage(data)
+		if err != nil {
+			t.Fatalf("%s: %v", tt.name, err)
+		}
+		if m.ID != q.ID || m.Response || !m.RecursionDesired || !m.CheckingDisabled || !sameQuestion(q, m) {
+			t.Errorf("%s: 解析结果为 %+v", tt.name, m)
+		}
+		if (m.opt() != nil) != tt.edns {
+			t.Errorf("%s: OPT 记录为 %v", tt.name, m.opt())
+		}
+	}
+	if _, err := newQuery("a..b", typeA).pack(); err == nil {
+		t.Error("空标签的域名应无法打包")
+	}
+}
+
+// 重试等待时间每次翻倍，不超过 maxBackoff，抖动不超过等待时间本身，重试次数很大时也不会溢出
+func TestRetryDelay(t *testing.T) {
+	tests := []struct {
+		backoff time.Duration
+		attempt int
+		base    time.Duration
+	}{
+		{0, 0, 0},
+		{-time.Second, 3, 0},
+		{200 * time.Millisecond, 0, 200 * time.Millisecond},
+		{200 * time.Millisecond, 3, 1600 * time.Millisecond},
+		{200 * time.Millisecond, 6, maxBackoff},
+		{200 * time.Millisecond, 40, maxBackoff},
+		{200 * time.Millisecond, 100, maxBackoff},
+		{time.Minute, 0, maxBackoff},
+		{time.Nanosecond, 62, maxBackoff},
+	}
+	for _, tt := range tests {
+		for n := 0; n < 10; n++ {
+			got := retryDelay(tt.backoff, tt.attempt)
+			if got < tt.base || got > 2*tt.base || tt.base == 0 && got != 0 {
+				t.Errorf("retryDelay(%v, %d) = %v，应在 [%v, %v] 之间", tt.backoff, tt.attempt, got, tt.base, 2*tt.base)
+				break
+			}
+		}
+	}
+}
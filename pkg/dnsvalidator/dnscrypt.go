@@ -0,0 +1,348 @@
+package dnsvalidator
+
+import (
+	"bytes"
+	"context"
+	"crypto/ed25519"
+	"crypto/rand"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"net"
+	"time"
+
+	"golang.org/x/crypto/chacha20"
+	"golang.org/x/crypto/curve25519"
+	"golang.org/x/crypto/nacl/box"
+	"golang.org/x/crypto/poly1305"
+)
+
+// DNSCrypt 证书和应答的魔数
+var (
+	dnscryptCertMagic     = []byte("DNSC")
+	dnscryptResolverMagic = []byte("r6fnvWj8")
+)
+
+// DNSCrypt 证书的加密方式 (es-version)
+const (
+	dnscryptXSalsa20  uint16 = 1 // X25519-XSalsa20Poly1305
+	dnscryptXChaCha20 uint16 = 2 // X25519-XChacha20Poly1305
+)
+
+// 证书的最小长度：魔数、加密方式、次版本号、签名、解析器公钥、客户端魔数、序列号和有效期
+const dnscryptCertSize = 4 + 2 + 2 + 64 + 32 + 8 + 4 + 4 + 4
+
+// 经 UDP 发送的查询填充后的最小长度，用于防止被用作放大攻击
+const dnscryptMinQuery = 256
+
+// DNS Stamp 中的 DNSCrypt 提供者
+type dnscryptProvider struct {
+	name string            // 提供者名称，如 2.dnscrypt-cert.example.com，解析器证书以该名称的 TXT 记录提供
+	key  ed25519.PublicKey // 签署解析器证书的提供者公钥
+}
+
+// 经提供者公钥验证的解析器证书
+type dnscryptCert struct {
+	version     uint16
+	resolverKey [32]byte
+	clientMagic [8]byte
+	serial      uint32
+	notBefore   time.Time
+	notAfter    time.Time
+}
+
+// 与一台 DNSCrypt 服务器的会话：选用的解析器证书、本次检查的临时公钥和共享密钥
+type dnscryptSession struct {
+	cert      *dnscryptCert
+	publicKey [32]byte
+	sharedKey [32]byte
+}
+
+// 返回加密方式的名称
+func dnscryptCipherName(version uint16) string {
+	if version == dnscryptXChaCha20 {
+		return "xchacha20poly1305"
+	}
+	return "xsalsa20poly1305"
+}
+
+// 以明文 DNS 查询提供者名称的 TXT 记录取得解析器证书，用提供者公钥验证签名后
+// 选用有效期内序列号最大的证书，并生成本次检查使用的临时密钥
+func newDNSCryptSession(c *client, server string, provider *dnscryptProvider) (*dnscryptSession, error) {
+	plain := &client{ctx: c.ctx, network: "udp", timeout: c.timeout, net: c.net}
+	resp, _, err := plain.exchange(server, newQuery(provider.name, typeTXT))
+	if err != nil {
+		return nil, fmt.Errorf("无法获取 DNSCrypt 证书: %w", err)
+	}
+	if resp.RCode != rcodeSuccess {
+		return nil, rcodeFailure(resp.RCode)
+	}
+	var best *dnscryptCert
+	now := time.Now()
+	for _, rr := range resp.Answers {
+		if rr.Type != typeTXT {
+			continue
+		}
+		cert, err := parseDNSCryptCert(txtData(rr.Data), provider.key)
+		if err != nil || now.Before(cert.notBefore) || now.After(cert.notAfter) {
+			continue
+		}
+		if best == nil || cert.serial > best.serial || cert.serial == best.serial && cert.version > best.version {
+			best = cert
+		}
+	}
+	if best == nil {
+		return nil, newFailure(failTLS, "没有经提供者公钥验证且在有效期内的 DNSCrypt 证书")
+	}
+
+	s := &dnscryptSession{cert: best}
+	var secret [32]byte
+	if _, err := rand.Read(secret[:]); err != nil {
+		return nil, err
+	}
+	curve25519.ScalarBaseMult(&s.publicKey, &secret)
+	if best.version == dnscryptXSalsa20 {
+		box.Precompute(&s.sharedKey, &best.resolverKey, &secret)
+		return s, nil
+	}
+	shared, err := curve25519.X25519(secret[:], best.resolverKey[:])
+	if err != nil {
+		return nil, newFailure(failTLS, "DNSCrypt 证书中的解析器公钥无效")
+	}
+	key, err := chacha20.HChaCha20(shared, make([]byte, 16))
+	if err != nil {
+		return nil, err
+	}
+	copy(s.sharedKey[:], key)
+	return s, nil
+}
+
+// 拼接 TXT 记录数据中的各个字符串，DNSCrypt 证书以二进制存放在 TXT 记录中
+func txtData(data []byte) []byte {
+	var out []byte
+	for len(data) > 0 {
+		l := int(data[0])
+		if 1+l > len(data) {
+			break
+		}
+		out = append(out, data[1:1+l]...)
+		data = data[1+l:]
+	}
+	return out
+}
+
+// 解析并验证一个解析器证书
+func parseDNSCryptCert(data []byte, key ed25519.PublicKey) (*dnscryptCert, error) {
+	if len(data) < dnscryptCertSize || !bytes.Equal(data[:4], dnscryptCertMagic) {
+		return nil, errMalformed
+	}
+	cert := &dnscryptCert{version: binary.BigEndian.Uint16(data[4:6])}
+	if cert.version != dnscryptXSalsa20 && cert.version != dnscryptXChaCha20 {
+		return nil, fmt.Errorf("不支持的 DNSCrypt 加密方式 %d", cert.version)
+	}
+	signature, signed := data[8:72], data[72:]
+	if len(key) != ed25519.PublicKeySize || !ed25519.Verify(key, signed, signature) {
+		return nil, fmt.Errorf("DNSCrypt 证书签名无效")
+	}
+	copy(cert.resolverKey[:], signed[0:32])
+	copy(cert.clientMagic[:], signed[32:40])
+	cert.serial = binary.BigEndian.Uint32(signed[40:44])
+	cert.notBefore = time.Unix(int64(binary.BigEndian.Uint32(signed[44:48])), 0)
+	cert.notAfter = time.Unix(int64(binary.BigEndian.Uint32(signed[48:52])), 0)
+	return cert, nil
+}
+
+// 加密查询：客户端魔数、临时公钥、12 字节客户端随机数，之后是按 64 字节填充并加密的查询报文
+func (s *dnscryptSession) seal(req []byte, network string) ([]byte, [12]byte, error) {
+	var half [12]byte
+	if _, err := rand.Read(half[:]); err != nil {
+		return nil, half, err
+	}
+	min := len(req) + 1
+	if network == "udp" {
+		min = max(min, dnscryptMinQuery)
+	}
+	padded := append(append([]byte(nil), req...), 0x80)
+	padded = append(padded, make([]byte, (min+63)/64*64-len(padded))...)
+
+	var nonce [24]byte
+	copy(nonce[:], half[:])
+	out := append(append(append([]byte(nil), s.cert.clientMagic[:]...), s.publicKey[:]...), half[:]...)
+	if s.cert.version == dnscryptXSalsa20 {
+		return box.SealAfterPrecomputation(out, padded, &nonce, &s.sharedKey), half, nil
+	}
+	return append(out, xsecretboxSeal(padded, &nonce, &s.sharedKey)...), half, nil
+}
+
+// 解密应答：解析器魔数、24 字节随机数 (前 12 字节为查询的客户端随机数)，之后是加密的应答报文
+func (s *dnscryptSession) open(data []byte, half [12]byte) ([]byte, error) {
+	if len(data) < 8+24+poly1305.TagSize || !bytes.Equal(data[:8], dnscryptResolverMagic) || !bytes.Equal(data[8:20], half[:]) {
+		return nil, errMalformed
+	}
+	var nonce [24]byte
+	copy(nonce[:], data[8:32])
+	var plain []byte
+	var ok bool
+	if s.cert.version == dnscryptXSalsa20 {
+		plain, ok = box.OpenAfterPrecomputation(nil, data[32:], &nonce, &s.sharedKey)
+	} else {
+		plain, ok = xsecretboxOpen(data[32:], &nonce, &s.sharedKey)
+	}
+	if !ok {
+		return nil, fmt.Errorf("%w: 无法解密 DNSCrypt 应答", errMalformed)
+	}
+	// 去掉 0x80 和之后的填充
+	end := bytes.LastIndexByte(plain, 0x80)
+	if end < 0 || len(bytes.Trim(plain[end+1:], "\x00")) > 0 {
+		return nil, errMalformed
+	}
+	return plain[:end], nil
+}
+
+// 以 XChaCha20 代替 XSalsa20 的 NaCl secretbox：Poly1305 标签在前，密钥流第一个块的前 32 字节作为 Poly1305 密钥
+func xsecretboxSeal(message []byte, nonce *[24]byte, key *[32]byte) []byte {
+	cipher, _ := chacha20.NewUnauthenticatedCipher(key[:], nonce[:])
+	var block [64]byte
+	cipher.XORKeyStream(block[:], block[:])
+	out := make([]byte, poly1305.TagSize+len(message))
+	ciphertext := out[poly1305.TagSize:]
+	first := min(len(message), 32)
+	for i := 0; i < first; i++ {
+		ciphertext[i] = message[i] ^ block[32+i]
+	}
+	cipher.SetCounter(1)
+	cipher.XORKeyStream(ciphertext[first:], message[first:])
+	var polyKey [32]byte
+	copy(polyKey[:], block[:32])
+	var tag [poly1305.TagSize]byte
+	poly1305.Sum(&tag, ciphertext, &polyKey)
+	copy(out, tag[:])
+	return out
+}
+
+// 验证并解密 xsecretboxSeal 的输出
+func xsecretboxOpen(sealed []byte, nonce *[24]byte, key *[32]byte) ([]byte, bool) {
+	if len(sealed) < poly1305.TagSize {
+		return nil, false
+	}
+	cipher, _ := chacha20.NewUnauthenticatedCipher(key[:], nonce[:])
+	var block [64]byte
+	cipher.XORKeyStream(block[:], block[:])
+	var polyKey [32]byte
+	copy(polyKey[:], block[:32])
+	var tag [poly1305.TagSize]byte
+	copy(tag[:], sealed)
+	ciphertext := sealed[poly1305.TagSize:]
+	if !poly1305.Verify(&tag, ciphertext, &polyKey) {
+		return nil, false
+	}
+	message := make([]byte, len(ciphertext))
+	first := min(len(ciphertext), 32)
+	for i := 0; i < first; i++ {
+		message[i] = ciphertext[i] ^ block[32+i]
+	}
+	cipher.SetCounter(1)
+	cipher.XORKeyStream(message[first:], ciphertext[first:])
+	return message, true
+}
+
+// 通过 DNSCrypt 发送查询，先经 UDP 发送，应答被截断时改用 TCP
+func exchangeDNSCrypt(ctx context.Context, n *netState, server string, s *dnscryptSession, query *dnsMessage, timeout time.Duration) (*dnsMessage, time.Duration, error) {
+	resp, rtt, err := exchangeDNSCryptOnce(ctx, n, "udp", server, s, query, timeout)
+	if err == nil && resp.Truncated {
+		return exchangeDNSCryptOnce(ctx, n, "tcp", server, s, query, timeout)
+	}
+	return resp, rtt, err
+}
+
+// 经 UDP 或 TCP 发送一次 DNSCrypt 查询，TCP 上的报文带两字节长度前缀
+func exchangeDNSCryptOnce(ctx context.Context, n *netState, network, server string, s *dnscryptSession, query *dnsMessage, timeout time.Duration) (*dnsMessage, time.Duration, error) {
+	if err := n.wait(ctx); err != nil {
+		return nil, 0, err
+	}
+	req, err := packQuery(ctx, "dnscrypt", server, query)
+	if err != nil {
+		return nil, 0, err
+	}
+	sealed, half, err := s.seal(req, network)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	start := time.Now()
+	conn, err := n.dialer(timeout).DialContext(ctx, network, server)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer conn.Close()
+	defer watchConn(ctx, conn)()
+	conn.SetDeadline(queryDeadline(ctx, start, timeout))
+
+	var data []byte
+	if network == "tcp" {
+		if _, err := conn.Write(append(binary.BigEndian.AppendUint16(nil, uint16(len(sealed))), sealed...)); err != nil {
+			return nil, 0, err
+		}
+		if data, err = readStreamFrame(conn); err != nil {
+			return nil, 0, err
+		}
+	} else {
+		if _, err := conn.Write(sealed); err != nil {
+			return nil, 0, err
+		}
+		buf := make([]byte, 65535)
+		size, err := conn.Read(buf)
+		if err != nil {
+			return nil, 0, err
+		}
+		data = buf[:size]
+	}
+
+	plain, err := s.open(data, half)
+	if err != nil {
+		return nil, 0, err
+	}
+	resp, err := parseResponse(ctx, "dnscrypt", server, plain)
+	if err != nil {
+		return nil, 0, err
+	}
+	if resp.ID != query.ID || !resp.Response || !sameQuestion(query, resp) {
+		return nil, 0, errMalformed
+	}
+	return resp, time.Since(start), nil
+}
+
+// 读取一个带两字节长度前缀的报文
+func readStreamFrame(conn net.Conn) ([]byte, error) {
+	var size [2]byte
+	if _, err := io.ReadFull(conn, size[:]); err != nil {
+		return nil, err
+	}
+	data := make([]byte, binary.BigEndian.Uint16(size[:]))
+	if _, err := io.ReadFull(conn, data); err != nil {
+		return nil, err
+	}
+	return data, nil
+}
+
+// 通过 DNSCrypt 查询域名，返回证书和延迟输出列。取得的会话保存在 c 中，之后的检查沿用同一证书和密钥
+func probeDNSCrypt(c *client, server, domain string) ([]string, error) {
+	s, err := newDNSCryptSession(c, server, c.crypt)
+	if err != nil {
+		return nil, fmt.Errorf("DNSCrypt 握手失败: %w", err)
+	}
+	c.cryptSession = s
+	resp, rtt, err := exchangeDNSCrypt(c.ctx, c.net, server, s, newQuery(domain, typeA), c.timeout)
+	if err != nil {
+		return nil, fmt.Errorf("DNSCrypt 查询失败: %w", err)
+	}
+	if resp.RCode != rcodeSuccess {
+		return nil, rcodeFailure(resp.RCode)
+	}
+	return []string{
+		"dnscrypt_cipher=" + dnscryptCipherName(s.cert.version),
+		fmt.Sprintf("dnscrypt_serial=%d", s.cert.serial),
+		"cert_expiry=" + s.cert.notAfter.Format("2006-01-02"),
+		fmt.Sprintf("dnscrypt_latency=%dms", rtt.Milliseconds()),
+	}, nil
+}
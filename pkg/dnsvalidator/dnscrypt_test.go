@@ -0,0 +1,171 @@
+package dnsvalidator
+
+import (
+	"bytes"
+	"context"
+	"crypto/ed25519"
+	"crypto/rand"
+	"encoding/binary"
+	"net"
+	"testing"
+	"time"
+
+	"golang.org/x/crypto/chacha20"
+	"golang.org/x/crypto/curve25519"
+	"golang.org/x/crypto/nacl/box"
+)
+
+// 测试用的 DNSCrypt 服务器：对提供者名称的明文 TXT 查询返回证书，对加密查询应答 google.com 的 A 记录
+type testDNSCrypt struct {
+	conn     net.PacketConn
+	provider *dnscryptProvider
+	cert     []byte
+	version  uint16
+	magic    [8]byte
+	secret   [32]byte
+	public   [32]byte
+}
+
+// 启动一个使用指定加密方式的 DNSCrypt 服务器，证书有效期为 [notBefore, notAfter]
+func startDNSCrypt(t *testing.T, version uint16, notBefore, notAfter time.Time) *testDNSCrypt {
+	t.Helper()
+	providerPub, providerKey, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	s := &testDNSCrypt{provider: &dnscryptProvider{name: "2.dnscrypt-cert.example.com", key: providerPub}, version: version}
+	rand.Read(s.secret[:])
+	curve25519.ScalarBaseMult(&s.public, &s.secret)
+	copy(s.magic[:], "testmagc")
+
+	signed := append(append([]byte(nil), s.public[:]...), s.magic[:]...)
+	signed = binary.BigEndian.AppendUint32(signed, 1)
+	signed = binary.BigEndian.AppendUint32(signed, uint32(notBefore.Unix()))
+	signed = binary.BigEndian.AppendUint32(signed, uint32(notAfter.Unix()))
+	s.cert = append(append(append([]byte("DNSC"), byte(version>>8), byte(version), 0, 0), ed25519.Sign(providerKey, signed)...), signed...)
+
+	if s.conn, err = net.ListenPacket("udp", "127.0.0.1:0"); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { s.conn.Close() })
+	go s.serve()
+	return s
+}
+
+func (s *testDNSCrypt) serve() {
+	buf := make([]byte, 65535)
+	for {
+		n, addr, err := s.conn.ReadFrom(buf)
+		if err != nil {
+			return
+		}
+		var resp []byte
+		if n > 8 && bytes.Equal(buf[:8], s.magic[:]) {
+			resp = s.answerEncrypted(append([]byte(nil), buf[:n]...))
+		} else {
+			resp = s.answerCert(buf[:n])
+		}
+		if resp != nil {
+			s.conn.WriteTo(resp, addr)
+		}
+	}
+}
+
+// 以 TXT 记录返回证书，证书长于 255 字节，分成多个字符串
+func (s *testDNSCrypt) answerCert(data []byte) []byte {
+	query, err := parseMessage(data)
+	if err != nil {
+		return nil
+	}
+	var txt []byte
+	for cert := s.cert; len(cert) > 0; {
+		l := min(len(cert), 255)
+		txt = append(append(txt, byte(l)), cert[:l]...)
+		cert = cert[l:]
+	}
+	resp := &dnsMessage{ID: query.ID, Response: true, Questions: query.Questions,
+		Answers: []dnsRR{{Name: query.Questions[0].Name, Type: typeTXT, Class: classINET, TTL: 60, Data: txt}}}
+	out, _ := resp.pack()
+	return out
+}
+
+// 解密查询并加密返回应答，XSalsa20 使用不经预计算的 box.Open 与客户端的实现相互印证
+func (s *testDNSCrypt) answerEncrypted(data []byte) []byte {
+	var clientPK [32]byte
+	copy(clientPK[:], data[8:40])
+	var nonce [24]byte
+	copy(nonce[:12], data[40:52])
+
+	var shared [32]byte
+	var plain []byte
+	var ok bool
+	if s.version == dnscryptXSalsa20 {
+		box.Precompute(&shared, &clientPK, &s.secret)
+		plain, ok = box.Open(nil, data[52:], &nonce, &clientPK, &s.secret)
+	} else {
+		x, _ := curve25519.X25519(s.secret[:], clientPK[:])
+		key, _ := chacha20.HChaCha20(x, make([]byte, 16))
+		copy(shared[:], key)
+		plain, ok = xsecretboxOpen(data[52:], &nonce, &shared)
+	}
+	if !ok || len(plain) < dnscryptMinQuery || len(plain)%64 != 0 {
+		return nil
+	}
+	query, err := parseMessage(plain[:bytes.LastIndexByte(plain, 0x80)])
+	if err != nil {
+		return nil
+	}
+	resp := &dnsMessage{ID: query.ID, Response: true, RecursionAvailable: true, Questions: query.Questions,
+		Answers: []dnsRR{{Name: query.Questions[0].Name, Type: typeA, Class: classINET, TTL: 60, Data: []byte{142, 250, 80, 46}}}}
+	msg, _ := resp.pack()
+	msg = append(append(msg, 0x80), make([]byte, 63-len(msg)%64)...)
+
+	rand.Read(nonce[12:])
+	out := append([]byte("r6fnvWj8"), nonce[:]...)
+	if s.version == dnscryptXSalsa20 {
+		return box.SealAfterPrecomputation(out, msg, &nonce, &shared)
+	}
+	return append(out, xsecretboxSeal(msg, &nonce, &shared)...)
+}
+
+func TestDNSCrypt(t *testing.T) {
+	now := time.Now()
+	tests := []struct {
+		name      string
+		version   uint16
+		notBefore time.Time
+		notAfter  time.Time
+		wrongKey  bool
+		ok        bool
+	}{
+		{"XSalsa20Poly1305", dnscryptXSalsa20, now.Add(-time.Hour), now.Add(time.Hour), false, true},
+		{"XChaCha20Poly1305", dnscryptXChaCha20, now.Add(-time.Hour), now.Add(time.Hour), false, true},
+		{"证书已过期", dnscryptXChaCha20, now.Add(-2 * time.Hour), now.Add(-time.Hour), false, false},
+		{"提供者公钥不符", dnscryptXSalsa20, now.Add(-time.Hour), now.Add(time.Hour), true, false},
+	}
+	for _, tt := range tests {
+		s := startDNSCrypt(t, tt.version, tt.notBefore, tt.notAfter)
+		provider := s.provider
+		if tt.wrongKey {
+			pub, _, _ := ed25519.GenerateKey(rand.Reader)
+			provider = &dnscryptProvider{name: provider.name, key: pub}
+		}
+		c := &client{ctx: context.Background(), network: "dnscrypt", timeout: time.Second, crypt: provider}
+		attrs, err := probeDNSCrypt(c, s.conn.LocalAddr().String(), "google.com")
+		if (err == nil) != tt.ok {
+			t.Errorf("%s: probeDNSCrypt 返回 %v，应%s", tt.name, err, map[bool]string{true: "成功", false: "失败"}[tt.ok])
+			continue
+		}
+		if !tt.ok {
+			continue
+		}
+		if attrs[0] != "dnscrypt_cipher="+dnscryptCipherName(tt.version) {
+			t.Errorf("%s: 输出列为 %v", tt.name, attrs)
+		}
+		// 之后的检查沿用同一会话
+		ips, err := lookupIPs(c, s.conn.LocalAddr().String(), "google.com")
+		if err != nil || len(ips) == 0 || ips[0] != "142.250.80.46" {
+			t.Errorf("%s: 经 DNSCrypt 查询得到 %v, %v", tt.name, ips, err)
+		}
+	}
+}
@@ -0,0 +1,28 @@
+package dnsvalidator
+
+import (
+	"fmt"
+)
+
+// 检测服务器是否为 DNSSEC 验证型解析器
+// 对已签名的域名应返回 AD 标志，对签名损坏的域名应返回 SERVFAIL
+func checkDNSSEC(c *client, server, signedZone, bogusZone string) (bool, error) {
+	query := newQuery(signedZone, typeA)
+	query.setEDNS(1232, true)
+	resp, _, err := c.exchange(server, query)
+	if err != nil {
+		return false, fmt.Errorf("查询已签名域名 %s 失败: %w", signedZone, err)
+	}
+	if resp.RCode != rcodeSuccess || !resp.AuthenticData {
+		return false, nil
+	}
+
+	query = newQuery(bogusZone, typeA)
+	query.setEDNS(1232, true)
+	resp, _, err = c.exchange(server, query)
+	if err != nil {
+		return false, fmt.Errorf("查询签名损坏的域名 %s 失败: %w", bogusZone, err)
+	}
+	// 真正做验证的解析器必须拒绝伪造的签名
+	return resp.RCode == rcodeServFail, nil
+}
@@ -0,0 +1,97 @@
+package dnsvalidator
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// 判断输入条目是否为 DoH 地址
+func isDoHURL(s string) bool {
+	return strings.HasPrefix(s, "https://")
+}
+
+// 按 RFC 8484 通过 HTTPS 发送查询 (DNS-over-HTTPS)，返回响应、往返耗时和 HTTP 状态码；
+// pin 不为空时连接 Stamp 给出的地址并校验固定的证书哈希
+func exchangeDoH(ctx context.Context, n *netState, endpoint string, pin *stampPin, query *dnsMessage, method string, timeout time.Duration) (*dnsMessage, time.Duration, int, error) {
+	if err := n.wait(ctx); err != nil {
+		return nil, 0, 0, err
+	}
+	// RFC 8484 建议将 ID 置为 0 以便缓存
+	query.ID = 0
+	if ex := exchangerFrom(ctx); ex != nil {
+		resp, rtt, err := exchangeVia(ctx, ex, "doh", endpoint, query, timeout)
+		if err != nil {
+			return nil, 0, 0, err
+		}
+		return resp, rtt, http.StatusOK, nil
+	}
+	req, err := packQuery(ctx, "doh", endpoint, query)
+	if err != nil {
+		return nil, 0, 0, err
+	}
+
+	var httpReq *http.Request
+	if method == http.MethodGet {
+		sep := "?"
+		if strings.Contains(endpoint, "?") {
+			sep = "&"
+		}
+		httpReq, err = http.NewRequest(http.MethodGet, endpoint+sep+"dns="+base64.RawURLEncoding.EncodeToString(req), nil)
+	} else {
+		httpReq, err = http.NewRequest(http.MethodPost, endpoint, bytes.NewReader(req))
+		if err == nil {
+			httpReq.Header.Set("Content-Type", "application/dns-message")
+		}
+	}
+	if err != nil {
+		return nil, 0, 0, err
+	}
+	httpReq.Header.Set("Accept", "application/dns-message")
+
+	start := time.Now()
+	ctx, cancel := context.WithDeadline(ctx, queryDeadline(ctx, start, timeout))
+	defer cancel()
+	httpClient := &http.Client{Transport: pin.dohTransport(n, timeout)}
+	httpResp, err := httpClient.Do(httpReq.WithContext(ctx))
+	if err != nil {
+		return nil, 0, 0, err
+	}
+	defer httpResp.Body.Close()
+
+	if httpResp.StatusCode != http.StatusOK {
+		return nil, 0, httpResp.StatusCode, newFailure(failHTTP, "HTTP 状态码 %d", httpResp.StatusCode)
+	}
+	body, err := io.ReadAll(io.LimitReader(httpResp.Body, 65535))
+	if err != nil {
+		return nil, 0, httpResp.StatusCode, err
+	}
+	resp, err := parseResponse(ctx, "doh", endpoint, body)
+	if err != nil {
+		return nil, 0, httpResp.StatusCode, err
+	}
+	if !resp.Response || !sameQuestion(query, resp) {
+		return nil, 0, httpResp.StatusCode, errMalformed
+	}
+	return resp, time.Since(start), httpResp.StatusCode, nil
+}
+
+// 通过 DoH 查询域名，返回 HTTP 状态码和延迟输出列
+func probeDoH(c *client, endpoint, domain string) ([]string, error) {
+	resp, rtt, status, err := exchangeDoH(c.ctx, c.net, endpoint, c.pin, newQuery(domain, typeA), c.dohMethod, c.timeout)
+	if err != nil {
+		return nil, fmt.Errorf("DoH 查询失败: %w", err)
+	}
+	if resp.RCode != rcodeSuccess {
+		return nil, rcodeFailure(resp.RCode)
+	}
+	return []string{
+		fmt.Sprintf("http_status=%d", status),
+		fmt.Sprintf("doh_latency=%dms", rtt.Milliseconds()),
+	}, nil
+}
@@ -0,0 +1,115 @@
+package dnsvalidator
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"strings"
+)
+
+// 从文件读取检查域名列表，忽略空行和 # 开头的注释
+func LoadDomains(path string) ([]string, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("无法打开域名列表文件: %v", err)
+	}
+	defer file.Close()
+
+	var domains []string
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		domains = append(domains, line)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("读取域名列表文件时出错: %v", err)
+	}
+	return domains, nil
+}
+
+// 所有检查域名的查询汇总
+type domainCheck struct {
+	passed    int     // 正确解析的域名个数
+	failures  []error // 未正确解析的域名的失败原因
+	primary   *lookup // 第一个解析成功的域名的查询结果
+	queries   int     // 总查询次数
+	succeeded int     // 成功的查询次数
+}
+
+// 成功查询所占的比例，作为可靠性评分
+func (d *domainCheck) reliability() float64 {
+	if d.queries == 0 {
+		return 0
+	}
+	return float64(d.succeeded) / float64(d.queries)
+}
+
+// 向服务器查询每个检查域名，返回正确解析的个数、失败原因及查询统计
+func checkDomains(c *client, server string, opts *options) *domainCheck {
+	d := &domainCheck{}
+	for _, domain := range opts.domains {
+		l, err := checkDomain(c, server, domain, opts, d)
+		if err != nil {
+			d.failures = append(d.failures, err)
+			continue
+		}
+		if d.primary == nil {
+			d.primary = l
+		}
+		d.passed++
+	}
+	return d
+}
+
+// 重复查询单个域名，要求成功次数达到 repeatPass 次，返回首次成功的查询结果
+func checkDomain(c *client, server, domain string, opts *options, d *domainCheck) (*lookup, error) {
+	succeeded := 0
+	var first *lookup
+	var lastErr error
+	for i := 0; i < opts.repeat; i++ {
+		d.queries++
+		l, err := queryDomain(c, server, domain, opts)
+		if err != nil {
+			lastErr = err
+			continue
+		}
+		if first == nil {
+			first = l
+		}
+		succeeded++
+		d.succeeded++
+	}
+	if succeeded >= opts.repeatPass {
+		return first, nil
+	}
+	if opts.repeat == 1 {
+		return nil, lastErr
+	}
+	return nil, &CheckError{
+		Category: failureCategory(lastErr),
+		Err:      fmt.Errorf("域名 %s 仅 %d/%d 次查询成功: %v", domain, succeeded, opts.repeat, lastErr),
+	}
+}
+
+// 查询单个域名，丢弃被解析到拦截地址或保留地址的应答，并与可信基准答案比对
+func queryDomain(c *client, server, domain string, opts *options) (*lookup, error) {
+	l, err := lookupDomain(c, server, domain)
+	if err != nil {
+		return nil, fmt.Errorf("无法解析域名 %s: %w", domain, err)
+	}
+	if err := checkSinkhole(domain, l.answers, opts.sinkholes); err != nil {
+		return nil, err
+	}
+	if !opts.allowPrivateAnswers {
+		if err := checkBogonAnswer(domain, l.answers); err != nil {
+			return nil, err
+		}
+	}
+	if b := opts.baseline[domain]; b != nil && !b.match(opts.udpClient(), opts.asn, l.answers) {
+		return nil, newFailure(failWrongAnswer, "域名 %s 的答案 %s 与基准答案没有相同的地址、网段或 ASN", domain, strings.Join(l.answers, ","))
+	}
+	return l, nil
+}
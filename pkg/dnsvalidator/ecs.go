@@ -0,0 +1,46 @@
+package dnsvalidator
+
+import (
+	"encoding/binary"
+	"fmt"
+	"net"
+)
+
+// EDNS Client Subnet 选项编号 (RFC 7871)
+const ednsClientSubnet uint16 = 8
+
+// 探测时携带的客户端子网
+var ecsProbeSubnet = &net.IPNet{IP: net.IPv4(192, 0, 2, 0).To4(), Mask: net.CIDRMask(24, 32)}
+
+// 构造 ECS 选项数据
+func ecsOptionData(subnet *net.IPNet) []byte {
+	family, ip := uint16(1), subnet.IP.To4()
+	if ip == nil {
+		family, ip = 2, subnet.IP.To16()
+	}
+	prefix, _ := subnet.Mask.Size()
+	data := binary.BigEndian.AppendUint16(nil, family)
+	data = append(data, byte(prefix), 0)
+	return append(data, ip[:(prefix+7)/8]...)
+}
+
+// 携带 ECS 选项查询域名，判断服务器是否回显或转发客户端子网
+// 返回是否支持 ECS 以及应答中的作用域前缀长度
+func checkECS(c *client, server, domain string) (bool, int, error) {
+	query := newQuery(domain, typeA)
+	query.setEDNS(1232, false)
+	query.addEDNSOption(ednsClientSubnet, ecsOptionData(ecsProbeSubnet))
+	resp, _, err := c.exchange(server, query)
+	if err != nil {
+		return false, 0, fmt.Errorf("ECS 查询失败: %w", err)
+	}
+	opt := resp.opt()
+	if opt == nil {
+		return false, 0, nil
+	}
+	option := opt.ednsOption(ednsClientSubnet)
+	if option == nil || len(option.Data) < 4 {
+		return false, 0, nil
+	}
+	return true, int(option.Data[3]), nil
+}
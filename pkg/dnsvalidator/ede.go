@@ -0,0 +1,80 @@
+package dnsvalidator
+
+import (
+	"encoding/binary"
+	"fmt"
+	"strings"
+)
+
+// Extended DNS Error 选项编号 (RFC 8914)
+const ednsExtendedError uint16 = 15
+
+// 扩展错误码名称
+var edeNames = map[uint16]string{
+	0:  "Other",
+	1:  "Unsupported DNSKEY Algorithm",
+	2:  "Unsupported DS Digest Type",
+	3:  "Stale Answer",
+	4:  "Forged Answer",
+	5:  "DNSSEC Indeterminate",
+	6:  "DNSSEC Bogus",
+	7:  "Signature Expired",
+	8:  "Signature Not Yet Valid",
+	9:  "DNSKEY Missing",
+	10: "RRSIGs Missing",
+	11: "No Zone Key Bit Set",
+	12: "NSEC Missing",
+	13: "Cached Error",
+	14: "Not Ready",
+	15: "Blocked",
+	16: "Censored",
+	17: "Filtered",
+	18: "Prohibited",
+	19: "Stale NXDOMAIN Answer",
+	20: "Not Authoritative",
+	21: "Not Supported",
+	22: "No Reachable Authority",
+	23: "Network Error",
+	24: "Invalid Data",
+}
+
+// 返回响应中的扩展错误描述，没有则返回空字符串
+func extendedError(resp *dnsMessage) string {
+	opt := resp.opt()
+	if opt == nil {
+		return ""
+	}
+	option := opt.ednsOption(ednsExtendedError)
+	if option == nil || len(option.Data) < 2 {
+		return ""
+	}
+	code := binary.BigEndian.Uint16(option.Data)
+	name, ok := edeNames[code]
+	if !ok {
+		name = "Unknown"
+	}
+	desc := fmt.Sprintf("EDE %d %s", code, name)
+	if text := strings.TrimRight(string(option.Data[2:]), "\x00"); text != "" {
+		desc += ": " + text
+	}
+	return desc
+}
+
+// 对 SERVFAIL 或 REFUSED 响应获取扩展错误
+// 原响应中没有扩展错误时，带 OPT 记录重新查询一次
+func fetchExtendedError(c *client, server string, query, resp *dnsMessage) string {
+	if resp.RCode != rcodeServFail && resp.RCode != rcodeRefused {
+		return ""
+	}
+	if ede := extendedError(resp); ede != "" || query.opt() != nil {
+		return ede
+	}
+	q := query.Questions[0]
+	retry := newQuery(q.Name, q.Type)
+	retry.setEDNS(1232, false)
+	retryResp, _, err := c.exchange(server, retry)
+	if err != nil {
+		return ""
+	}
+	return extendedError(retryResp)
+}
@@ -0,0 +1,57 @@
+package dnsvalidator
+
+import (
+	"fmt"
+)
+
+// 探测时依次通告的 UDP 缓冲区大小
+var ednsProbeSizes = []uint16{512, 1232, 4096}
+
+// 检测服务器是否支持 EDNS0，并探测其实际能承载的 UDP 载荷大小
+// 返回输出列，服务器不支持 EDNS0 时只返回 edns=false
+func probeEDNS(c *client, server, domain string) ([]string, error) {
+	// 不带 OPT 记录的普通查询必须成功
+	resp, _, err := c.exchange(server, newQuery(domain, typeA))
+	if err != nil {
+		return nil, fmt.Errorf("普通查询失败: %w", err)
+	}
+	if resp.RCode != rcodeSuccess {
+		return nil, rcodeFailure(resp.RCode)
+	}
+
+	query := newQuery(domain, typeA)
+	query.setEDNS(1232, false)
+	resp, _, err = c.exchange(server, query)
+	if err != nil {
+		return []string{"edns=false"}, nil
+	}
+	opt := resp.opt()
+	if opt == nil || resp.RCode == rcodeFormErr || resp.RCode == rcodeNotImp {
+		return []string{"edns=false"}, nil
+	}
+	attrs := []string{"edns=true", fmt.Sprintf("edns_size=%d", opt.Class)}
+
+	// 只有 UDP 才受缓冲区大小限制
+	if c.network != "udp" {
+		return attrs, nil
+	}
+	return append(attrs, fmt.Sprintf("edns_honored=%d", probeUDPSize(c, server))), nil
+}
+
+// 用应答较大的根区 DNSKEY 查询探测服务器实际承载的 UDP 载荷大小
+// 返回未被截断且响应超过 512 字节的最大通告大小，全部失败时返回 512
+func probeUDPSize(c *client, server string) int {
+	honored := 512
+	for _, size := range ednsProbeSizes {
+		query := newQuery(".", typeDNSKEY)
+		query.setEDNS(size, true)
+		resp, _, err := exchangeUDP(c.ctx, c.net, server, query, c.timeout)
+		if err != nil || resp.Truncated {
+			continue
+		}
+		if resp.Size > 512 && int(size) > honored {
+			honored = int(size)
+		}
+	}
+	return honored
+}
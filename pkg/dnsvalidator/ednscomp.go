@@ -0,0 +1,137 @@
+package dnsvalidator
+
+import (
+	"fmt"
+	"strings"
+)
+
+// EDNS 合规性等级
+const (
+	ednsGradeOK      = "ok"      // 通过全部测试
+	ednsGradePartial = "partial" // 支持 EDNS0 但部分测试未通过
+	ednsGradeBroken  = "broken"  // 带 EDNS0 的查询失败或被丢弃，多见于中间设备
+)
+
+const (
+	rcodeBadVers   = 16     // 扩展响应码 BADVERS (RFC 6891)
+	ednsUnknownOpt = 100    // 测试用的未分配选项编号
+	ednsUnknownFlg = 0x0080 // 测试用的未定义 EDNS 标志位
+	ednsDOFlag     = 0x8000 // DO 标志位
+)
+
+// 参照 ISC EDNS 合规性测试 (ednscomp) 的一项测试
+type ednsTest struct {
+	name    string
+	version uint8  // EDNS 版本
+	flags   uint16 // EDNS 标志位
+	option  bool   // 是否带未知选项
+	check   func(resp *dnsMessage, opt *dnsRR) bool
+}
+
+// OPT 记录中的扩展响应码、版本和标志位
+func ednsRCode(resp *dnsMessage, opt *dnsRR) int {
+	return int(opt.TTL>>24)<<4 | resp.RCode
+}
+
+func ednsVersion(opt *dnsRR) uint8 { return uint8(opt.TTL >> 16) }
+
+func ednsFlags(opt *dnsRR) uint16 { return uint16(opt.TTL) }
+
+var ednsTests = []ednsTest{
+	{
+		// EDNS0 查询应返回 NOERROR 并带有版本 0 的 OPT 记录
+		name: "edns",
+		check: func(resp *dnsMessage, opt *dnsRR) bool {
+			return opt != nil && ednsRCode(resp, opt) == rcodeSuccess && ednsVersion(opt) == 0
+		},
+	},
+	{
+		// EDNS1 查询应返回 BADVERS，OPT 记录中为服务器支持的版本 0，且不带答案
+		name:    "edns1",
+		version: 1,
+		check: func(resp *dnsMessage, opt *dnsRR) bool {
+			return opt != nil && ednsRCode(resp, opt) == rcodeBadVers && ednsVersion(opt) == 0 && len(resp.Answers) == 0
+		},
+	},
+	{
+		// 未知选项应被忽略，不能原样带回
+		name:   "ednsopt",
+		option: true,
+		check: func(resp *dnsMessage, opt *dnsRR) bool {
+			return opt != nil && ednsRCode(resp, opt) == rcodeSuccess && opt.ednsOption(ednsUnknownOpt) == nil
+		},
+	},
+	{
+		// 未定义的标志位应被忽略，不能原样带回
+		name:  "ednsflags",
+		flags: ednsUnknownFlg,
+		check: func(resp *dnsMessage, opt *dnsRR) bool {
+			return opt != nil && ednsRCode(resp, opt) == rcodeSuccess && ednsFlags(opt)&ednsUnknownFlg == 0
+		},
+	},
+	{
+		// DO 标志位应在应答中带回 (RFC 3225)
+		name:  "do",
+		flags: ednsDOFlag,
+		check: func(resp *dnsMessage, opt *dnsRR) bool {
+			return opt != nil && ednsRCode(resp, opt) == rcodeSuccess && ednsFlags(opt)&ednsDOFlag != 0
+		},
+	},
+	{
+		// 同时使用 EDNS1 和未知选项时版本协商优先，应返回 BADVERS
+		name:    "edns1opt",
+		version: 1,
+		option:  true,
+		check: func(resp *dnsMessage, opt *dnsRR) bool {
+			return opt != nil && ednsRCode(resp, opt) == rcodeBadVers && ednsVersion(opt) == 0
+		},
+	},
+}
+
+// 依次执行 EDNS 合规性测试，返回等级和未通过的测试名称
+func ednsCompliance(c *client, server, domain string) (string, []string) {
+	var failed []string
+	for _, t := range ednsTests {
+		query := newQuery(domain, typeA)
+		query.setEDNS(1232, false)
+		opt := query.opt()
+		opt.TTL = uint32(t.version)<<16 | uint32(t.flags)
+		if t.option {
+			query.addEDNSOption(ednsUnknownOpt, nil)
+		}
+		resp, _, err := c.exchange(server, query)
+		if err != nil || !t.check(resp, resp.opt()) {
+			// 基本的 EDNS0 查询都失败时不再继续
+			if t.name == "edns" {
+				return ednsGradeBroken, []string{t.name}
+			}
+			failed = append(failed, t.name)
+		}
+	}
+	if len(failed) > 0 {
+		return ednsGradePartial, failed
+	}
+	return ednsGradeOK, nil
+}
+
+// 检查 EDNS 合规性，等级低于 minGrade 时返回失败；minGrade 为空时只记录等级
+func checkEDNSCompliance(c *client, server, domain, minGrade string) CheckResult {
+	grade, failed := ednsCompliance(c, server, domain)
+	attrs := []string{"edns_compliance=" + grade}
+	if len(failed) > 0 {
+		attrs = append(attrs, "edns_failed="+strings.Join(failed, ","))
+	}
+	if minGrade == ednsGradeOK && grade != ednsGradeOK || minGrade == ednsGradePartial && grade == ednsGradeBroken {
+		return CheckResult{Err: newFailure(failEDNS, "EDNS 合规性为 %s，未通过: %s", grade, strings.Join(failed, ","))}
+	}
+	return CheckResult{Attrs: attrs}
+}
+
+// 检查 EDNS 合规性最低等级的取值
+func validEDNSGrade(grade string) error {
+	switch grade {
+	case "", ednsGradeOK, ednsGradePartial:
+		return nil
+	}
+	return fmt.Errorf("EDNS 合规性最低等级只能是 ok 或 partial")
+}
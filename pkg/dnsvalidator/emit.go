@@ -0,0 +1,140 @@
+package dnsvalidator
+
+import (
+	"fmt"
+	"net"
+	"net/url"
+	"strings"
+)
+
+// resolv.conf 最多生效的 nameserver 数量 (glibc MAXNS)
+const ResolvConfMaxNS = 3
+
+// 判断是否为配置片段输出格式
+func IsConfigFormat(format string) bool {
+	switch format {
+	case "resolvconf", "dnsmasq", "unbound", "dnscrypt":
+		return true
+	}
+	return false
+}
+
+// 将一台服务器写成配置片段中的一行，不适用于该配置的服务器会被跳过
+func (rw *Writer) writeConfig(r *Result) error {
+	var line string
+	switch rw.format {
+	case "resolvconf":
+		// resolv.conf 只支持 53 端口上的明文 DNS
+		if r.IP == "" || r.Port != "53" || r.Transport == "dot" || r.Transport == "doh" || r.Transport == "dnscrypt" {
+			return nil
+		}
+		line = "nameserver " + r.IP
+	case "dnsmasq":
+		if r.IP == "" || r.Transport == "dot" || r.Transport == "doh" || r.Transport == "dnscrypt" {
+			return nil
+		}
+		line = "server=" + r.IP
+		if r.Port != "53" {
+			line += "#" + r.Port
+		}
+	case "unbound":
+		if r.IP == "" || r.Transport == "doh" || r.Transport == "dnscrypt" {
+			return nil
+		}
+		// 一个 forward-zone 只能统一使用 TLS 或明文，以第一台服务器为准
+		tls := r.Transport == "dot"
+		if rw.count == 0 {
+			header := "forward-zone:\n    name: \".\""
+			if tls {
+				header += "\n    forward-tls-upstream: yes"
+			}
+			if _, err := fmt.Fprintln(rw.w, header); err != nil {
+				return err
+			}
+			rw.tls = tls
+		}
+		if tls != rw.tls {
+			return nil
+		}
+		line = "    forward-addr: " + unboundAddr(r)
+	case "dnscrypt":
+		st := resultStamp(r)
+		if st == nil {
+			return nil
+		}
+		if rw.count == 0 {
+			if _, err := fmt.Fprint(rw.w, "# dnsvalidator\n\n由 dnsvalidator_go 验证可用的 DoH/DoT 解析器\n"); err != nil {
+				return err
+			}
+			rw.names = make(map[string]bool)
+		}
+		name := st.Hostname
+		if name == "" {
+			name = r.IP
+		}
+		for i := 2; rw.names[name]; i++ {
+			name = fmt.Sprintf("%s-%d", strings.TrimSuffix(name, fmt.Sprintf("-%d", i-1)), i)
+		}
+		rw.names[name] = true
+		line = fmt.Sprintf("\n## %s\n%s，延迟 %sms，通过检查: %s\n\n%s", name, r.Server, r.field("latency_ms"), strings.Join(r.Checks, ","), st)
+	}
+	rw.count++
+	_, err := fmt.Fprintln(rw.w, line)
+	return err
+}
+
+// 为 DoH 或 DoT 结果生成 DNS Stamp，其他协议返回空
+func resultStamp(r *Result) *dnsStamp {
+	switch r.Transport {
+	case "doh":
+		u, err := url.Parse(r.Server)
+		if err != nil {
+			return nil
+		}
+		st := &dnsStamp{Protocol: stampDoH, Hostname: u.Host, Path: u.EscapedPath()}
+		if st.Path == "" {
+			st.Path = "/dns-query"
+		}
+		st.Props = stampProps(r)
+		return st
+	case "dot":
+		st := &dnsStamp{Protocol: stampDoT, Address: r.IP, Hostname: r.Provider}
+		if r.Port != "853" {
+			st.Address = net.JoinHostPort(r.IP, r.Port)
+		}
+		if st.Hostname == "" {
+			st.Hostname = r.Hostname
+		}
+		st.Hostname = hostOnly(st.Hostname)
+		if st.Hostname == "" {
+			st.Hostname = r.IP
+		}
+		st.Props = stampProps(r)
+		return st
+	}
+	return nil
+}
+
+// 根据检查结果设置 Stamp 属性，只声明实际验证过的 DNSSEC
+func stampProps(r *Result) uint64 {
+	if r.field("dnssec") == "true" {
+		return stampPropDNSSEC
+	}
+	return 0
+}
+
+// 返回 unbound forward-addr 的地址写法: ip[@port][#tls名称]
+func unboundAddr(r *Result) string {
+	addr := r.IP
+	if (r.Transport == "dot" && r.Port != "853") || (r.Transport != "dot" && r.Port != "53") {
+		addr += "@" + r.Port
+	}
+	name := r.Hostname
+	if name == "" {
+		name = r.Provider
+	}
+	if r.Transport == "dot" && name != "" && net.ParseIP(name) == nil {
+		addr += "#" + name
+	}
+	return addr
+}
@@ -0,0 +1,106 @@
+package dnsvalidator
+
+import (
+	"sync"
+	"time"
+)
+
+// 事件类型
+type EventType string
+
+const (
+	EventStarted  EventType = "started"  // 开始检查一台服务器
+	EventResolved EventType = "resolved" // 服务器通过检查，Result 为检查结果
+	EventFailed   EventType = "failed"   // 服务器未通过检查，Err 为 *CheckError
+	EventRetried  EventType = "retried"  // 查询超时后重试，Err 为超时错误，Attempt 为第几次重试
+	EventFinished EventType = "finished" // 所有检查结束，Stats 为运行统计
+)
+
+// 运行中的一个事件
+type Event struct {
+	Type    EventType
+	Time    time.Time
+	Server  string
+	Result  *Result
+	Err     error
+	Attempt int
+	Stats   *Stats
+}
+
+// 事件总线，向所有订阅者广播事件
+type eventBus struct {
+	mu     sync.Mutex
+	subs   map[chan Event]bool
+	closed bool
+}
+
+func newEventBus() *eventBus {
+	return &eventBus{subs: make(map[chan Event]bool)}
+}
+
+// 订阅事件，返回的通道在运行结束或取消订阅后关闭
+func (b *eventBus) subscribe(buffer int) (<-chan Event, func()) {
+	ch := make(chan Event, buffer)
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.closed {
+		close(ch)
+		return ch, func() {}
+	}
+	b.subs[ch] = true
+	return ch, func() {
+		b.mu.Lock()
+		defer b.mu.Unlock()
+		if b.subs[ch] {
+			delete(b.subs, ch)
+			close(ch)
+		}
+	}
+}
+
+// 广播事件；订阅者的缓冲区已满时丢弃该订阅者的这条事件，不阻塞检查
+func (b *eventBus) publish(e Event) {
+	if b == nil {
+		return
+	}
+	e.Time = time.Now()
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	for ch := range b.subs {
+		select {
+		case ch <- e:
+		default:
+		}
+	}
+}
+
+// 发送 finished 事件并关闭所有订阅者的通道；缓冲区已满时丢弃最早的一条事件，保证 finished 送达
+func (b *eventBus) finish(stats *Stats) {
+	e := Event{Type: EventFinished, Time: time.Now(), Stats: stats}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.closed = true
+	for ch := range b.subs {
+		select {
+		case ch <- e:
+		default:
+			select {
+			case <-ch:
+			default:
+			}
+			ch <- e
+		}
+		delete(b.subs, ch)
+		close(ch)
+	}
+}
+
+// 订阅本次运行的事件，buffer 为通道的缓冲区大小 (小于 1 时为 256)；返回的函数用于取消订阅。
+// 应在 Run 之前订阅以免错过事件；读取过慢导致缓冲区已满时新的事件会被丢弃，
+// finished 事件之后通道关闭，运行结束后订阅得到的是已关闭的通道
+func (v *Validator) Subscribe(buffer int) (<-chan Event, func()) {
+	if buffer < 1 {
+		buffer = 256
+	}
+	return v.opts.events.subscribe(buffer)
+}
@@ -0,0 +1,49 @@
+package dnsvalidator
+
+import (
+	"context"
+	"time"
+)
+
+// 发送线上格式的 DNS 查询报文并返回响应报文。network 为 udp、tcp、dot 或 doh，
+// server 为 host:port 或 DoH URL；ctx 到期时应返回超时错误。
+// 设置 Options.Exchanger 后所有查询都经由它发送而不访问网络，用于测试或模拟故障，见 MockServer
+type Exchanger interface {
+	Exchange(ctx context.Context, network, server string, req []byte) ([]byte, error)
+}
+
+type exchangerKey struct{}
+
+// 返回经由 ex 发送查询的 ctx
+func withExchanger(ctx context.Context, ex Exchanger) context.Context {
+	return context.WithValue(ctx, exchangerKey{}, ex)
+}
+
+// 返回 ctx 中设置的 Exchanger，未设置时为空
+func exchangerFrom(ctx context.Context) Exchanger {
+	ex, _ := ctx.Value(exchangerKey{}).(Exchanger)
+	return ex
+}
+
+// 经由 Exchanger 发送一次查询，与真实网络查询一样调用中间件并校验响应的 ID 和问题段
+func exchangeVia(ctx context.Context, ex Exchanger, network, server string, query *dnsMessage, timeout time.Duration) (*dnsMessage, time.Duration, error) {
+	req, err := packQuery(ctx, network, server, query)
+	if err != nil {
+		return nil, 0, err
+	}
+	start := time.Now()
+	ctx, cancel := context.WithDeadline(ctx, queryDeadline(ctx, start, timeout))
+	defer cancel()
+	buf, err := ex.Exchange(ctx, network, server, req)
+	if err != nil {
+		return nil, 0, err
+	}
+	resp, err := parseResponse(ctx, network, server, buf)
+	if err != nil {
+		return nil, 0, err
+	}
+	if resp.ID != query.ID || !resp.Response || !sameQuestion(query, resp) {
+		return nil, 0, errMalformed
+	}
+	return resp, time.Since(start), nil
+}
@@ -0,0 +1,55 @@
+package dnsvalidator
+
+import (
+	"bufio"
+	"fmt"
+	"net"
+	"os"
+)
+
+// 从排除文件和 -exclude-cidr 参数加载需要跳过的网段
+// 排除文件每行一个 IP 或 CIDR，# 之后的内容为注释
+func LoadExcludes(files []string, cidrs []string) ([]*net.IPNet, error) {
+	var networks []*net.IPNet
+	for _, item := range cidrs {
+		network, err := parseIPOrCIDR(item)
+		if err != nil {
+			return nil, fmt.Errorf("-exclude-cidr: %v", err)
+		}
+		networks = append(networks, network)
+	}
+	for _, path := range files {
+		file, err := os.Open(path)
+		if err != nil {
+			return nil, fmt.Errorf("无法打开排除文件: %v", err)
+		}
+		scanner := bufio.NewScanner(file)
+		for lineNo := 1; scanner.Scan(); lineNo++ {
+			line := normalizeLine(scanner.Text())
+			if line == "" {
+				continue
+			}
+			network, err := parseIPOrCIDR(line)
+			if err != nil {
+				file.Close()
+				return nil, fmt.Errorf("排除文件 %s 第 %d 行: %v", path, lineNo, err)
+			}
+			networks = append(networks, network)
+		}
+		err = scanner.Err()
+		file.Close()
+		if err != nil {
+			return nil, fmt.Errorf("读取排除文件 %s 时出错: %v", path, err)
+		}
+	}
+	return networks, nil
+}
+
+// 判断条目是否落在排除网段内
+func excluded(cand candidate, networks []*net.IPNet) bool {
+	if len(networks) == 0 || isDoHURL(cand.server) {
+		return false
+	}
+	host, _ := splitServer(cand.server)
+	return containsIP(networks, host)
+}
@@ -0,0 +1,96 @@
+package dnsvalidator
+
+import (
+	"bufio"
+	"fmt"
+	"net"
+	"os"
+	"strings"
+)
+
+// 从文件加载域名与预期答案的对应关系
+// 每行格式为: 域名 IP或CIDR[,IP或CIDR...]，以 # 开头的行为注释
+func LoadExpected(path string) (map[string][]*net.IPNet, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("无法打开预期答案文件: %v", err)
+	}
+	defer file.Close()
+
+	expected := make(map[string][]*net.IPNet)
+	scanner := bufio.NewScanner(file)
+	for lineNo := 1; scanner.Scan(); lineNo++ {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		fields := strings.Fields(line)
+		if len(fields) < 2 {
+			return nil, fmt.Errorf("预期答案文件第 %d 行格式错误: %s", lineNo, line)
+		}
+		domain := strings.ToLower(strings.TrimSuffix(fields[0], "."))
+		for _, item := range SplitList(strings.Join(fields[1:], ",")) {
+			network, err := parseIPOrCIDR(item)
+			if err != nil {
+				return nil, fmt.Errorf("预期答案文件第 %d 行: %v", lineNo, err)
+			}
+			expected[domain] = append(expected[domain], network)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("读取预期答案文件时出错: %v", err)
+	}
+	if len(expected) == 0 {
+		return nil, fmt.Errorf("预期答案文件 %s 中没有任何条目", path)
+	}
+	return expected, nil
+}
+
+// 将单个 IP 或 CIDR 解析为网段
+func parseIPOrCIDR(s string) (*net.IPNet, error) {
+	if strings.Contains(s, "/") {
+		_, network, err := net.ParseCIDR(s)
+		if err != nil {
+			return nil, fmt.Errorf("无效的 CIDR: %s", s)
+		}
+		return network, nil
+	}
+	ip := net.ParseIP(s)
+	if ip == nil {
+		return nil, fmt.Errorf("无效的 IP: %s", s)
+	}
+	if ip4 := ip.To4(); ip4 != nil {
+		return &net.IPNet{IP: ip4, Mask: net.CIDRMask(32, 32)}, nil
+	}
+	return &net.IPNet{IP: ip, Mask: net.CIDRMask(128, 128)}, nil
+}
+
+// 判断 IP 是否落在任一网段内
+func containsIP(networks []*net.IPNet, s string) bool {
+	ip := net.ParseIP(s)
+	if ip == nil {
+		return false
+	}
+	for _, network := range networks {
+		if network.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
+
+// 检查服务器对每个域名的答案是否都在预期集合内
+func checkExpected(c *client, server string, expected map[string][]*net.IPNet) error {
+	for domain, networks := range expected {
+		answers, err := lookupIPs(c, server, domain)
+		if err != nil {
+			return fmt.Errorf("无法解析域名 %s: %w", domain, err)
+		}
+		for _, ip := range answers {
+			if !containsIP(networks, ip) {
+				return newFailure(failWrongAnswer, "域名 %s 的答案 %s 不在预期范围内，疑似被污染", domain, ip)
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,269 @@
+package dnsvalidator
+
+import (
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"sort"
+	"sync"
+)
+
+// 失败类别
+const (
+	failConnect       = "connect_error"      // 无法连接或目标不可达
+	failTimeout       = "query_timeout"      // 查询超时
+	failServFail      = "servfail"           // 返回 SERVFAIL
+	failRefused       = "refused"            // 返回 REFUSED
+	failNXDomain      = "nxdomain"           // 返回 NXDOMAIN
+	failRCode         = "bad_rcode"          // 返回其他错误响应码
+	failNoAnswer      = "no_answer"          // 响应中没有所需记录
+	failWrongAnswer   = "wrong_answer"       // 答案与基准或预期不一致
+	failMalformed     = "malformed"          // 响应格式错误
+	failHTTP          = "http_error"         // DoH 返回非 200 状态码
+	failTLS           = "tls_error"          // 证书校验失败
+	failTruncated     = "tc_no_tcp"          // UDP 应答被截断但无法通过 TCP 取回
+	failHijack        = "nxdomain_hijack"    // 对不存在的域名返回了地址
+	failNoRecursion   = "no_recursion"       // 不提供递归查询
+	failNoDNSSEC      = "no_dnssec"          // 不验证 DNSSEC
+	failECS           = "ecs_filtered"       // 因 ECS 支持情况被过滤
+	failSoftware      = "risky_software"     // 运行过时或家用路由器的 DNS 软件
+	failEDNS          = "edns_noncompliant"  // EDNS 合规性低于要求
+	failAmplification = "high_amplification" // 应答放大倍数过高
+	failPortRandom    = "weak_port_random"   // 递归查询的源端口随机化不足
+	failCase          = "case_not_preserved" // 响应未保留查询域名的大小写
+	failSinkhole      = "sinkhole"           // 将常见域名解析到 0.0.0.0 等拦截地址
+	failBogon         = "bogon_answer"       // 检查域名被解析到保留或私有地址
+	failFilterClass   = "filter_class"       // 过滤类型不在要求的范围内
+	failRebind        = "rebind_filtered"    // 因重绑定保护情况被过滤
+	failLoss          = "packet_loss"        // 探测查询的丢包率过高
+	failJitter        = "high_jitter"        // 探测查询的 RTT 抖动过大
+	failOther         = "other"              // 其他错误
+)
+
+// 各失败类别对应的错误，可以用 errors.Is 判断 OnFailure 收到的错误属于哪一类
+var (
+	ErrConnect       = errors.New("无法连接")
+	ErrTimeout       = errors.New("查询超时")
+	ErrServFail      = errors.New("返回 SERVFAIL")
+	ErrRefused       = errors.New("返回 REFUSED")
+	ErrNXDomain      = errors.New("返回 NXDOMAIN")
+	ErrRCode         = errors.New("返回错误响应码")
+	ErrNoAnswer      = errors.New("响应中没有所需记录")
+	ErrPoisoned      = errors.New("答案与基准或预期不一致")
+	ErrMalformed     = errors.New("响应格式错误")
+	ErrHTTP          = errors.New("DoH 返回非 200 状态码")
+	ErrTLS           = errors.New("证书校验失败")
+	ErrTruncated     = errors.New("应答被截断且无法通过 TCP 取回")
+	ErrHijacked      = errors.New("劫持 NXDOMAIN")
+	ErrNoRecursion   = errors.New("不提供递归查询")
+	ErrNoDNSSEC      = errors.New("不验证 DNSSEC")
+	ErrECS           = errors.New("因 ECS 支持情况被过滤")
+	ErrSoftware      = errors.New("运行有风险的 DNS 软件")
+	ErrEDNS          = errors.New("EDNS 合规性低于要求")
+	ErrAmplification = errors.New("应答放大倍数过高")
+	ErrPortRandom    = errors.New("源端口随机化不足")
+	ErrCase          = errors.New("未保留查询域名的大小写")
+	ErrSinkhole      = errors.New("将域名解析到拦截地址")
+	ErrBogon         = errors.New("将域名解析到保留或私有地址")
+	ErrFilterClass   = errors.New("过滤类型不符合要求")
+	ErrRebind        = errors.New("因重绑定保护情况被过滤")
+	ErrLoss          = errors.New("丢包率过高")
+	ErrJitter        = errors.New("RTT 抖动过大")
+)
+
+// 失败类别到对应错误的映射
+var categoryErrors = map[string]error{
+	failConnect:       ErrConnect,
+	failTimeout:       ErrTimeout,
+	failServFail:      ErrServFail,
+	failRefused:       ErrRefused,
+	failNXDomain:      ErrNXDomain,
+	failRCode:         ErrRCode,
+	failNoAnswer:      ErrNoAnswer,
+	failWrongAnswer:   ErrPoisoned,
+	failMalformed:     ErrMalformed,
+	failHTTP:          ErrHTTP,
+	failTLS:           ErrTLS,
+	failTruncated:     ErrTruncated,
+	failHijack:        ErrHijacked,
+	failNoRecursion:   ErrNoRecursion,
+	failNoDNSSEC:      ErrNoDNSSEC,
+	failECS:           ErrECS,
+	failSoftware:      ErrSoftware,
+	failEDNS:          ErrEDNS,
+	failAmplification: ErrAmplification,
+	failPortRandom:    ErrPortRandom,
+	failCase:          ErrCase,
+	failSinkhole:      ErrSinkhole,
+	failBogon:         ErrBogon,
+	failFilterClass:   ErrFilterClass,
+	failRebind:        ErrRebind,
+	failLoss:          ErrLoss,
+	failJitter:        ErrJitter,
+}
+
+// 带失败类别的错误，Category 为 connect_error、query_timeout 等类别名称
+type CheckError struct {
+	Category string
+	Err      error
+}
+
+func (e *CheckError) Error() string { return e.Err.Error() }
+
+func (e *CheckError) Unwrap() error { return e.Err }
+
+// 与该类别对应的 Err* 错误匹配
+func (e *CheckError) Is(target error) bool {
+	return target != nil && categoryErrors[e.Category] == target
+}
+
+// 为错误附加失败类别，已带类别的错误原样返回
+func classify(err error) error {
+	var ce *CheckError
+	if errors.As(err, &ce) {
+		return err
+	}
+	return &CheckError{Category: failureCategory(err), Err: err}
+}
+
+// 构造指定类别的错误
+func newFailure(category, format string, args ...interface{}) error {
+	return &CheckError{Category: category, Err: fmt.Errorf(format, args...)}
+}
+
+// 根据响应码构造对应类别的错误
+func rcodeFailure(rcode int) error {
+	category := failRCode
+	switch rcode {
+	case rcodeServFail:
+		category = failServFail
+	case rcodeRefused:
+		category = failRefused
+	case rcodeNXDomain:
+		category = failNXDomain
+	}
+	return newFailure(category, "响应码 %s", rcodeString(rcode))
+}
+
+// 根据响应码构造错误，附带扩展错误描述
+func rcodeFailureEDE(rcode int, ede string) error {
+	if ede == "" {
+		return rcodeFailure(rcode)
+	}
+	return &CheckError{
+		Category: failureCategory(rcodeFailure(rcode)),
+		Err:      fmt.Errorf("响应码 %s (%s)", rcodeString(rcode), ede),
+	}
+}
+
+// 返回错误所属的失败类别
+func failureCategory(err error) string {
+	var ce *CheckError
+	if errors.As(err, &ce) {
+		return ce.Category
+	}
+	if errors.Is(err, errMalformed) {
+		return failMalformed
+	}
+	var ve *tls.CertificateVerificationError
+	if errors.As(err, &ve) {
+		return failTLS
+	}
+	var ne net.Error
+	if errors.As(err, &ne) && ne.Timeout() {
+		return failTimeout
+	}
+	var oe *net.OpError
+	if errors.As(err, &oe) {
+		return failConnect
+	}
+	return failOther
+}
+
+// 运行统计
+type Stats struct {
+	mu       sync.Mutex
+	tested   int
+	valid    int
+	failures map[string]int
+}
+
+func newStats() *Stats {
+	return &Stats{failures: make(map[string]int)}
+}
+
+// 记录一台通过校验的服务器
+func (s *Stats) pass() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.tested++
+	s.valid++
+}
+
+// 记录一台未通过校验的服务器及其失败类别
+func (s *Stats) fail(category string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.tested++
+	s.failures[category]++
+}
+
+// 返回已检查的服务器数
+func (s *Stats) Tested() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.tested
+}
+
+// 返回通过检查的服务器数
+func (s *Stats) Valid() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.valid
+}
+
+// 返回各失败类别的服务器数
+func (s *Stats) Failures() map[string]int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	failures := make(map[string]int, len(s.failures))
+	for category, count := range s.failures {
+		failures[category] = count
+	}
+	return failures
+}
+
+// 失败类别及其数量
+type categoryCount struct {
+	Category string
+	Count    int
+}
+
+// 返回失败类别统计，按数量从多到少排列
+func (s *Stats) breakdown() []categoryCount {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	counts := make([]categoryCount, 0, len(s.failures))
+	for category, count := range s.failures {
+		counts = append(counts, categoryCount{category, count})
+	}
+	sort.Slice(counts, func(i, j int) bool {
+		if counts[i].Count != counts[j].Count {
+			return counts[i].Count > counts[j].Count
+		}
+		return counts[i].Category < counts[j].Category
+	})
+	return counts
+}
+
+// 打印本次运行的汇总信息，失败类别按数量从多到少排列
+func (s *Stats) Print(w io.Writer) {
+	s.mu.Lock()
+	fmt.Fprintf(w, "共检查 %d 台服务器，可用 %d 台，失败 %d 台\n", s.tested, s.valid, s.tested-s.valid)
+	s.mu.Unlock()
+	for _, c := range s.breakdown() {
+		fmt.Fprintf(w, "  %-16s %d\n", c.Category, c.Count)
+	}
+}
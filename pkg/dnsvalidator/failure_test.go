@@ -0,0 +1,70 @@
+package dnsvalidator
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+)
+
+// 以 mock 作为 Exchanger 检查 servers，返回每台服务器的失败类别，通过检查的服务器为空字符串
+func mockCategories(t *testing.T, mock *MockServer, servers []string, opts ...Option) map[string]string {
+	t.Helper()
+	opts = append([]Option{WithDomains("google.com"), WithTimeout(200 * time.Millisecond), WithExchanger(mock), WithIncludeFailed()}, opts...)
+	v, err := NewValidator(opts...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	results, err := v.Run(context.Background(), Sources{Servers: servers})
+	if err != nil {
+		t.Fatal(err)
+	}
+	categories := make(map[string]string)
+	for r := range results {
+		categories[r.Server] = r.Category
+	}
+	if err := v.Err(); err != nil {
+		t.Fatal(err)
+	}
+	return categories
+}
+
+func TestFailureCategories(t *testing.T) {
+	tests := []struct {
+		name     string
+		behavior *MockBehavior
+		want     string
+	}{
+		{"正常应答", mockResolver(), ""},
+		{"REFUSED", &MockBehavior{RCode: "REFUSED"}, failRefused},
+		{"SERVFAIL", &MockBehavior{RCode: "SERVFAIL"}, failServFail},
+		{"NXDOMAIN", &MockBehavior{}, failNXDomain},
+		{"不应答", &MockBehavior{DropRate: 1}, failTimeout},
+		{"连接被拒绝", nil, failConnect},
+		{"NXDOMAIN 劫持", &MockBehavior{Records: mockResolver().Records, Wildcard: []string{"198.51.100.7"}}, failHijack},
+	}
+	mock := NewMockServer()
+	var servers []string
+	want := make(map[string]string)
+	for i, tt := range tests {
+		server := fmt.Sprintf("8.8.4.%d", i+1)
+		if tt.behavior != nil {
+			mock.Handle(server+":53", tt.behavior)
+		}
+		servers = append(servers, server)
+		want[server] = tt.want
+	}
+
+	got := mockCategories(t, mock, servers, WithChecks("nxdomain"))
+	for i, tt := range tests {
+		server := servers[i]
+		category, ok := got[server]
+		if !ok {
+			t.Errorf("%s: 没有 %s 的结果", tt.name, server)
+			continue
+		}
+		if category != want[server] {
+			t.Errorf("%s: 失败类别为 %q，应为 %q", tt.name, category, want[server])
+		}
+	}
+}
@@ -0,0 +1,101 @@
+package dnsvalidator
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// 过滤探测的类别
+const (
+	filterAds     = "ads"     // 广告和跟踪
+	filterMalware = "malware" // 恶意软件和钓鱼
+	filterAdult   = "adult"   // 成人内容
+)
+
+// 服务器的过滤类型，由过滤的类别推断
+const (
+	classUnfiltered = "unfiltered" // 不过滤任何探测域名
+	classAdblock    = "adblock"    // 过滤广告
+	classMalware    = "malware"    // 过滤恶意软件但不过滤成人内容
+	classFamily     = "family"     // 过滤成人内容，通常也过滤恶意软件
+)
+
+// 各类别默认的探测域名：testcategory.com 是 Cloudflare 提供的测试域名，internetbadguys.com 是 OpenDNS 的钓鱼测试域名
+var defaultFilteringDomains = map[string][]string{
+	filterAds:     {"doubleclick.net", "pagead2.googlesyndication.com"},
+	filterMalware: {"malware.testcategory.com", "internetbadguys.com"},
+	filterAdult:   {"nudity.testcategory.com", "pornhub.com"},
+}
+
+// 解析 类别:域名 形式的探测域名列表，类别为 ads、malware 或 adult；未出现的类别使用默认域名
+func ParseFilteringDomains(items []string) (map[string][]string, error) {
+	domains := make(map[string][]string)
+	for _, item := range items {
+		category, domain, ok := strings.Cut(strings.TrimSpace(item), ":")
+		category = strings.ToLower(category)
+		if !ok || domain == "" || defaultFilteringDomains[category] == nil {
+			return nil, fmt.Errorf("无效的过滤探测域名 %q，应为 ads:域名、malware:域名 或 adult:域名", item)
+		}
+		domains[category] = append(domains[category], domain)
+	}
+	for category, defaults := range defaultFilteringDomains {
+		if domains[category] == nil {
+			domains[category] = defaults
+		}
+	}
+	return domains, nil
+}
+
+// 检查过滤类型的取值
+func validFilteringClasses(classes []string) error {
+	for _, class := range classes {
+		switch class {
+		case classUnfiltered, classAdblock, classMalware, classFamily:
+		default:
+			return fmt.Errorf("无效的过滤类型 %q，只能是 unfiltered、adblock、malware 或 family", class)
+		}
+	}
+	return nil
+}
+
+// 由过滤的类别推断过滤类型，成人内容优先，其次是恶意软件和广告
+func filteringClass(filtered map[string]bool) string {
+	switch {
+	case filtered[filterAdult]:
+		return classFamily
+	case filtered[filterMalware]:
+		return classMalware
+	case filtered[filterAds]:
+		return classAdblock
+	}
+	return classUnfiltered
+}
+
+// 查询各类别的探测域名，任一域名被拒绝、重定向或返回 NXDOMAIN 即视为过滤该类别。
+// 输出 filter_class 和过滤的类别，过滤类型不在 classes 中时返回失败
+func checkFiltering(r *Resolver) CheckResult {
+	filtered := make(map[string]bool)
+	for category, domains := range r.opts.filteringDomains {
+		for _, domain := range domains {
+			if censorStatus(r.c, r.Addr, domain, r.opts.probeRef[domain], r.opts.sinkholes) != "" {
+				filtered[category] = true
+				break
+			}
+		}
+	}
+	class := filteringClass(filtered)
+	if len(r.opts.filteringClasses) > 0 && !r.opts.filteringClasses[class] {
+		return CheckResult{Err: newFailure(failFilterClass, "过滤类型为 %s", class)}
+	}
+	attrs := []string{"filter_class=" + class}
+	if len(filtered) > 0 {
+		categories := make([]string, 0, len(filtered))
+		for category := range filtered {
+			categories = append(categories, category)
+		}
+		sort.Strings(categories)
+		attrs = append(attrs, "filtered="+strings.Join(categories, ","))
+	}
+	return CheckResult{Attrs: attrs}
+}
@@ -0,0 +1,80 @@
+package dnsvalidator
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// 按国家和 ASN 筛选服务器。作为 Options.Filter 时在检查前跳过不符合的服务器，不发出任何查询；
+// 也可以在检查后用 Allow 筛选结果。国家和 ASN 取自 Options.GeoIP 和 Options.ASN，
+// 没有设置时取自 public-dns.info 列表中的记录
+type GeoFilter struct {
+	Countries        []string // 只保留这些国家的服务器 (ISO 3166 代码)，为空不限制
+	ExcludeCountries []string // 跳过这些国家的服务器
+	ASNs             []uint   // 只保留这些自治系统的服务器，为空不限制
+	ExcludeASNs      []uint   // 跳过这些自治系统的服务器
+}
+
+// 是否设置了国家条件
+func (f *GeoFilter) byCountry() bool {
+	return f != nil && (len(f.Countries) > 0 || len(f.ExcludeCountries) > 0)
+}
+
+// 是否设置了 ASN 条件
+func (f *GeoFilter) byASN() bool {
+	return f != nil && (len(f.ASNs) > 0 || len(f.ExcludeASNs) > 0)
+}
+
+// 判断结果是否符合条件，f 为空时总是符合。指定了 Countries 或 ASNs 时，
+// 查不到国家或 ASN 的服务器 (如 DoH 服务器) 不符合；排除条件只排除确定属于其中的服务器
+func (f *GeoFilter) Allow(r *Result) bool {
+	if f == nil {
+		return true
+	}
+	if len(f.Countries) > 0 && !containsFold(f.Countries, r.Country) {
+		return false
+	}
+	if r.Country != "" && containsFold(f.ExcludeCountries, r.Country) {
+		return false
+	}
+	if len(f.ASNs) > 0 && !containsASN(f.ASNs, r.ASN) {
+		return false
+	}
+	if r.ASN != 0 && containsASN(f.ExcludeASNs, r.ASN) {
+		return false
+	}
+	return true
+}
+
+func containsFold(list []string, s string) bool {
+	for _, item := range list {
+		if s != "" && strings.EqualFold(item, s) {
+			return true
+		}
+	}
+	return false
+}
+
+func containsASN(list []uint, asn uint) bool {
+	for _, item := range list {
+		if asn != 0 && item == asn {
+			return true
+		}
+	}
+	return false
+}
+
+// 解析 ASN 列表，每项可以写成 13335 或 AS13335
+func ParseASNs(items []string) ([]uint, error) {
+	var asns []uint
+	for _, item := range items {
+		text := strings.TrimPrefix(strings.ToUpper(strings.TrimSpace(item)), "AS")
+		asn, err := strconv.ParseUint(text, 10, 32)
+		if err != nil || asn == 0 {
+			return nil, fmt.Errorf("无效的 ASN %q", item)
+		}
+		asns = append(asns, uint(asn))
+	}
+	return asns, nil
+}
@@ -0,0 +1,76 @@
+package dnsvalidator
+
+import (
+	"fmt"
+	"net/netip"
+	"strings"
+
+	"github.com/oschwald/maxminddb-golang/v2"
+)
+
+// MaxMind GeoIP2/GeoLite2 城市或国家数据库，用于标注服务器所在的国家、城市和坐标。
+// 可以在多个 Validator 之间共享
+type GeoIP struct {
+	db *maxminddb.Reader
+}
+
+// 数据库中用到的字段，国家数据库没有 city 和 location
+type geoRecord struct {
+	Country struct {
+		ISOCode string `maxminddb:"iso_code"`
+	} `maxminddb:"country"`
+	RegisteredCountry struct {
+		ISOCode string `maxminddb:"iso_code"`
+	} `maxminddb:"registered_country"`
+	City struct {
+		Names map[string]string `maxminddb:"names"`
+	} `maxminddb:"city"`
+	Location struct {
+		Latitude  float64 `maxminddb:"latitude"`
+		Longitude float64 `maxminddb:"longitude"`
+	} `maxminddb:"location"`
+}
+
+// 打开 mmdb 格式的 GeoIP2/GeoLite2 City 或 Country 数据库
+func OpenGeoIP(path string) (*GeoIP, error) {
+	db, err := maxminddb.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("无法打开 GeoIP 数据库: %v", err)
+	}
+	if t := db.Metadata.DatabaseType; !strings.Contains(t, "City") && !strings.Contains(t, "Country") {
+		db.Close()
+		return nil, fmt.Errorf("%s 不是 GeoIP 城市或国家数据库 (%s)", path, t)
+	}
+	return &GeoIP{db: db}, nil
+}
+
+// 关闭数据库
+func (g *GeoIP) Close() error {
+	return g.db.Close()
+}
+
+// 按服务器 IP 填入 Country、City、Latitude 和 Longitude，并在文本输出中加入 country 列；
+// g 为空、DoH 服务器或数据库中没有记录时不做修改
+func (g *GeoIP) annotate(r *Result) {
+	if g == nil || r.IP == "" {
+		return
+	}
+	ip, err := netip.ParseAddr(strings.Trim(r.IP, "[]"))
+	if err != nil {
+		return
+	}
+	var rec geoRecord
+	lookup := g.db.Lookup(ip.Unmap())
+	if !lookup.Found() || lookup.Decode(&rec) != nil {
+		return
+	}
+	r.Country = rec.Country.ISOCode
+	if r.Country == "" {
+		r.Country = rec.RegisteredCountry.ISOCode
+	}
+	r.City = rec.City.Names["en"]
+	r.Latitude, r.Longitude = rec.Location.Latitude, rec.Location.Longitude
+	if r.Country != "" {
+		r.attrs = append(r.attrs, "country="+r.Country)
+	}
+}
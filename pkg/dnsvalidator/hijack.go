@@ -0,0 +1,49 @@
+package dnsvalidator
+
+import (
+	"fmt"
+	"io"
+	"math/rand"
+	"sync"
+)
+
+// 并发安全的写入器
+type lockedWriter struct {
+	mu sync.Mutex
+	w  io.Writer
+}
+
+func (l *lockedWriter) WriteLine(line string) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	_, err := io.WriteString(l.w, line+"\n")
+	return err
+}
+
+// 生成指定长度的随机小写标签
+func randomLabel(n int) string {
+	const letters = "abcdefghijklmnopqrstuvwxyz0123456789"
+	b := make([]byte, n)
+	for i := range b {
+		b[i] = letters[rand.Intn(len(letters))]
+	}
+	return string(b)
+}
+
+// 查询随机不存在的子域名和随机顶级域名，检测泛解析和 NXDOMAIN 劫持
+func checkNXDomain(c *client, server, canary string) error {
+	names := []string{
+		randomLabel(16) + "." + canary,
+		randomLabel(12) + "." + randomLabel(10),
+	}
+	for _, name := range names {
+		resp, _, err := c.exchange(server, newQuery(name, typeA))
+		if err != nil {
+			return fmt.Errorf("查询不存在的域名 %s 失败: %w", name, err)
+		}
+		if ips := resp.answerValues(typeA); len(ips) > 0 {
+			return newFailure(failHijack, "对不存在的域名 %s 返回了地址 %s，疑似劫持 NXDOMAIN", name, ips[0])
+		}
+	}
+	return nil
+}
@@ -0,0 +1,57 @@
+package dnsvalidator
+
+import (
+	"net"
+	"strings"
+)
+
+// 判断条目是否为主机名而非 IP 地址或 DoH URL
+func isHostname(entry string) bool {
+	if isDoHURL(entry) {
+		return false
+	}
+	host, _ := splitServer(entry)
+	return host != "" && net.ParseIP(host) == nil
+}
+
+// 返回引导服务器的查询地址，未指定端口时使用 53，不带端口的 IPv6 地址可以带方括号
+func bootstrapAddr(bootstrap string) string {
+	if host, port := splitServer(bootstrap); port == "" {
+		return net.JoinHostPort(host, "53")
+	}
+	return bootstrap
+}
+
+// 通过引导服务器解析主机名条目，每个解析出的地址都作为独立条目检查，
+// 并保留原始主机名。不合法或无法解析的主机名会被拒绝，不是主机名的条目原样返回
+func resolveHostname(c *client, server string, cand candidate, rejects *rejectLog) []candidate {
+	if !isHostname(cand.server) {
+		return []candidate{cand}
+	}
+	host, port := splitServer(cand.server)
+	if strings.ContainsAny(cand.server, " \t") {
+		rejects.reject(cand, "包含空白字符")
+		return nil
+	}
+	if !validHostname(host) {
+		rejects.reject(cand, "无效的 IP 地址或主机名")
+		return nil
+	}
+	ips, err := lookupIPs(c, server, host)
+	if err != nil {
+		rejects.log.printf("无法通过引导服务器 %s 解析主机名 %s: %v\n", server, host, err)
+		rejects.reject(cand, "无法解析主机名")
+		return nil
+	}
+	var resolved []candidate
+	for _, ip := range ips {
+		entry := ip
+		if port != "" {
+			entry = net.JoinHostPort(ip, port)
+		}
+		resolvedCand := cand
+		resolvedCand.server, resolvedCand.hostname = entry, host
+		resolved = append(resolved, resolvedCand)
+	}
+	return resolved
+}
@@ -0,0 +1,43 @@
+package dnsvalidator
+
+import (
+	"fmt"
+	"net"
+)
+
+// 用于检测本机 IPv6 连通性的服务器
+const ipv6ProbeServer = "2001:4860:4860::8888"
+
+// 返回条目的地址族: 4、6，不是 IP 地址时返回 0
+func ipFamily(s string) int {
+	host, _ := splitServer(s)
+	ip := net.ParseIP(host)
+	switch {
+	case ip == nil:
+		return 0
+	case ip.To4() != nil:
+		return 4
+	}
+	return 6
+}
+
+// 判断条目是否属于指定的地址族，family 为 4、6 或 both，非 IP 条目总是保留
+func familyAllowed(server, family string) bool {
+	if family == "both" {
+		return true
+	}
+	f := ipFamily(server)
+	return f == 0 || fmt.Sprint(f) == family
+}
+
+// 通过 IPv6 服务器查询 AAAA 记录，检测本机是否具备 IPv6 连通性
+func checkIPv6Connectivity(c *client, domain string) error {
+	resp, _, err := c.exchange(net.JoinHostPort(ipv6ProbeServer, "53"), newQuery(domain, typeAAAA))
+	if err != nil {
+		return err
+	}
+	if resp.RCode != rcodeSuccess {
+		return rcodeFailure(resp.RCode)
+	}
+	return nil
+}
@@ -0,0 +1,58 @@
+package dnsvalidator
+
+import (
+	"strconv"
+	"time"
+)
+
+// 丢包和抖动探测的默认参数
+const (
+	defaultJitterProbes   = 10
+	defaultJitterInterval = 100 * time.Millisecond
+)
+
+// 以固定间隔逐个发送探测查询，不做超时重试，返回丢失查询的百分比和 RTT 抖动。
+// 抖动为相邻两次成功查询 RTT 之差的平均绝对值，成功少于两次时为 0
+func probeJitter(c *client, server, domain string, probes int, interval time.Duration) (loss float64, jitter time.Duration) {
+	var rtts []time.Duration
+	for i := 0; i < probes; i++ {
+		if i > 0 {
+			select {
+			case <-time.After(interval):
+			case <-c.ctx.Done():
+				return 100, 0
+			}
+		}
+		if _, rtt, err := c.exchangeOnce(server, newQuery(domain, typeA), c.timeout); err == nil {
+			rtts = append(rtts, rtt)
+		}
+	}
+	loss = float64(probes-len(rtts)) * 100 / float64(probes)
+	if len(rtts) < 2 {
+		return loss, 0
+	}
+	var sum time.Duration
+	for i := 1; i < len(rtts); i++ {
+		d := rtts[i] - rtts[i-1]
+		if d < 0 {
+			d = -d
+		}
+		sum += d
+	}
+	return loss, sum / time.Duration(len(rtts)-1)
+}
+
+// 输出 loss (百分比) 和 jitter_ms 列，丢包率或抖动超过上限时返回失败，上限为 0 不限制
+func checkJitter(c *client, server, domain string, opts *options) CheckResult {
+	loss, jitter := probeJitter(c, server, domain, opts.jitterProbes, opts.jitterInterval)
+	if opts.maxLoss > 0 && loss > opts.maxLoss {
+		return CheckResult{Err: newFailure(failLoss, "丢包率 %.1f%% 超过 %.1f%%", loss, opts.maxLoss)}
+	}
+	if opts.maxJitter > 0 && jitter > opts.maxJitter {
+		return CheckResult{Err: newFailure(failJitter, "RTT 抖动 %v 超过 %v", jitter.Round(time.Microsecond), opts.maxJitter)}
+	}
+	return CheckResult{Attrs: []string{
+		"loss=" + strconv.FormatFloat(loss, 'f', 1, 64),
+		"jitter_ms=" + formatMs(jitter),
+	}}
+}
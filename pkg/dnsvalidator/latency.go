@@ -0,0 +1,48 @@
+package dnsvalidator
+
+import (
+	"sort"
+	"strconv"
+	"time"
+)
+
+// 按名称启用 latency 检查且未指定 Samples 时的查询次数
+const defaultSamples = 10
+
+// 返回已排序 RTT 的第 p 百分位数 (最近秩法)
+func percentile(sorted []time.Duration, p int) time.Duration {
+	i := (len(sorted)*p + 99) / 100
+	if i < 1 {
+		i = 1
+	}
+	return sorted[i-1]
+}
+
+// 格式化为与 latency_ms 相同精度的毫秒数
+func formatMs(d time.Duration) string {
+	return strconv.FormatFloat(float64(d.Microseconds())/1000, 'f', -1, 64)
+}
+
+// 依次查询主检查域名 n 次，输出成功查询 RTT 的 p50/p90/p99 (毫秒) 和成功次数，并以中位数作为结果的延迟。
+// 单次 RTT 受缓存状态和网络抖动影响很大，中位数更适合排序；全部查询失败时不输出，保留原有的单次测量
+func checkLatency(c *client, server, domain string, n int, r *Result) []string {
+	var rtts []time.Duration
+	for i := 0; i < n; i++ {
+		if _, rtt, err := c.exchange(server, newQuery(domain, typeA)); err == nil {
+			rtts = append(rtts, rtt)
+		}
+	}
+	if len(rtts) == 0 {
+		return nil
+	}
+	sort.Slice(rtts, func(i, j int) bool { return rtts[i] < rtts[j] })
+	p50 := percentile(rtts, 50)
+	r.RTT = p50
+	r.LatencyMs = float64(p50.Microseconds()) / 1000
+	return []string{
+		"latency_p50=" + formatMs(p50),
+		"latency_p90=" + formatMs(percentile(rtts, 90)),
+		"latency_p99=" + formatMs(percentile(rtts, 99)),
+		"latency_samples=" + strconv.Itoa(len(rtts)) + "/" + strconv.Itoa(n),
+	}
+}
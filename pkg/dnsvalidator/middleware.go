@@ -0,0 +1,61 @@
+package dnsvalidator
+
+import (
+	"context"
+)
+
+// 查询中间件，在每次查询发送前和收到响应后以线上格式调用，可以检查或修改报文，
+// 如加入 EDNS 选项、记录原始报文或附加认证信息。为空的函数表示不做处理；
+// 多个中间件按注册顺序调用。修改查询的 ID 或问题段会导致响应被当作不匹配而丢弃
+type Middleware struct {
+	// 发送前调用，返回实际发送的报文；返回错误时放弃本次查询
+	Query func(ctx context.Context, info QueryInfo, req []byte) ([]byte, error)
+	// 收到响应后、解析前调用，返回交给检查的报文；返回错误时本次查询失败
+	Response func(ctx context.Context, info QueryInfo, resp []byte) ([]byte, error)
+}
+
+// 中间件收到的查询信息
+type QueryInfo struct {
+	Network string // udp、tcp、dot 或 doh
+	Server  string // host:port 或 DoH URL
+}
+
+type middlewareKey struct{}
+
+// 返回使用中间件 mws 的 ctx
+func withMiddleware(ctx context.Context, mws []Middleware) context.Context {
+	return context.WithValue(ctx, middlewareKey{}, mws)
+}
+
+// 编码查询报文并依次交给各中间件
+func packQuery(ctx context.Context, network, server string, query *dnsMessage) ([]byte, error) {
+	req, err := query.pack()
+	if err != nil {
+		return nil, err
+	}
+	mws, _ := ctx.Value(middlewareKey{}).([]Middleware)
+	for _, mw := range mws {
+		if mw.Query == nil {
+			continue
+		}
+		if req, err = mw.Query(ctx, QueryInfo{Network: network, Server: server}, req); err != nil {
+			return nil, err
+		}
+	}
+	return req, nil
+}
+
+// 将响应报文依次交给各中间件后解析
+func parseResponse(ctx context.Context, network, server string, resp []byte) (*dnsMessage, error) {
+	mws, _ := ctx.Value(middlewareKey{}).([]Middleware)
+	var err error
+	for _, mw := range mws {
+		if mw.Response == nil {
+			continue
+		}
+		if resp, err = mw.Response(ctx, QueryInfo{Network: network, Server: server}, resp); err != nil {
+			return nil, err
+		}
+	}
+	return parseMessage(resp)
+}
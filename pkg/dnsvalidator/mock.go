@@ -0,0 +1,278 @@
+package dnsvalidator
+
+import (
+	"context"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"math/rand"
+	"net"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+)
+
+// 进程内的模拟 DNS 服务器，按服务器地址返回配置的应答或模拟故障。
+// 作为 Options.Exchanger 使用时不访问网络；也可以用 ListenAndServe 在本机 UDP/TCP 端口上提供同样的应答
+type MockServer struct {
+	Default *MockBehavior // 未单独配置的服务器的行为，为空时连接被拒绝
+
+	mu      sync.Mutex
+	servers map[string]*MockBehavior
+}
+
+// 模拟服务器的行为
+type MockBehavior struct {
+	// 域名和记录类型到记录值的映射，键如 "google.com A"，值如 "93.184.216.34"；
+	// 支持 A、AAAA、CNAME、NS、PTR、MX ("10 mail.example.com") 和 TXT
+	Records map[string][]string
+	// 对未配置的域名返回的 A 记录，用于模拟泛解析和 NXDOMAIN 劫持；为空时返回 NXDOMAIN
+	Wildcard []string
+
+	RCode       string        // 非空时所有查询都返回该响应码，如 SERVFAIL、REFUSED
+	Delay       time.Duration // 每次应答前的延迟
+	DropRate    float64       // 丢弃查询的比例，1 表示从不应答
+	NoRecursion bool          // 不设置 RA 标志
+	TruncateUDP bool          // UDP 应答只设置 TC 标志而不带答案
+	NoTCP       bool          // 拒绝 TCP、DoT 和 DoH 连接
+}
+
+// 创建没有配置任何服务器的模拟服务器
+func NewMockServer() *MockServer {
+	return &MockServer{servers: make(map[string]*MockBehavior)}
+}
+
+// 设置服务器的行为，server 为 host:port 或 DoH URL
+func (m *MockServer) Handle(server string, b *MockBehavior) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.servers[server] = b
+}
+
+// 返回服务器的行为
+func (m *MockServer) behavior(server string) *MockBehavior {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if b, ok := m.servers[server]; ok {
+		return b
+	}
+	return m.Default
+}
+
+// 实现 Exchanger：按服务器的行为返回应答，丢弃的查询在 ctx 到期时返回超时错误
+func (m *MockServer) Exchange(ctx context.Context, network, server string, req []byte) ([]byte, error) {
+	netName := network
+	if network != "udp" {
+		netName = "tcp"
+	}
+	b := m.behavior(server)
+	if b == nil || b.NoTCP && netName == "tcp" {
+		return nil, &net.OpError{Op: "dial", Net: netName, Err: os.NewSyscallError("connect", syscall.ECONNREFUSED)}
+	}
+
+	var wait <-chan time.Time
+	if !b.drop() {
+		wait = time.After(b.Delay)
+	}
+	select {
+	case <-wait:
+	case <-ctx.Done():
+		if ctx.Err() == context.DeadlineExceeded {
+			return nil, &net.OpError{Op: "read", Net: netName, Err: os.ErrDeadlineExceeded}
+		}
+		return nil, ctx.Err()
+	}
+	return b.respond(req, netName == "udp")
+}
+
+// 判断是否丢弃本次查询
+func (b *MockBehavior) drop() bool {
+	return b.DropRate > 0 && rand.Float64() < b.DropRate
+}
+
+// 根据查询报文构造应答报文
+func (b *MockBehavior) respond(req []byte, udp bool) ([]byte, error) {
+	query, err := parseMessage(req)
+	if err != nil || len(query.Questions) != 1 {
+		return nil, errMalformed
+	}
+	q := query.Questions[0]
+	resp := &dnsMessage{
+		ID:                 query.ID,
+		Response:           true,
+		RecursionDesired:   query.RecursionDesired,
+		RecursionAvailable: !b.NoRecursion,
+		Questions:          query.Questions,
+	}
+	// 带 OPT 记录的查询回送 OPT 记录
+	if query.opt() != nil {
+		resp.setEDNS(1232, false)
+	}
+
+	switch {
+	case b.RCode != "":
+		rcode, ok := rcodeByName(b.RCode)
+		if !ok {
+			return nil, fmt.Errorf("未知的响应码 %s", b.RCode)
+		}
+		resp.RCode = rcode
+	case udp && b.TruncateUDP:
+		resp.Truncated = true
+	default:
+		if err := b.answer(resp, q); err != nil {
+			return nil, err
+		}
+	}
+	return resp.pack()
+}
+
+// 按 Records 和 Wildcard 填写答案
+func (b *MockBehavior) answer(resp *dnsMessage, q dnsQuestion) error {
+	name := strings.ToLower(strings.TrimSuffix(q.Name, "."))
+	values, ok := b.Records[name+" "+typeString(q.Type)]
+	if !ok {
+		known := false
+		for key := range b.Records {
+			if strings.HasPrefix(key, name+" ") {
+				known = true
+				break
+			}
+		}
+		switch {
+		case known:
+			// 域名存在但没有该类型的记录
+		case len(b.Wildcard) > 0 && q.Type == typeA:
+			values = b.Wildcard
+		default:
+			resp.RCode = rcodeNXDomain
+			return nil
+		}
+	}
+	for _, value := range values {
+		data, err := mockRData(q.Type, value)
+		if err != nil {
+			return err
+		}
+		resp.Answers = append(resp.Answers, dnsRR{Name: q.Name, Type: q.Type, Class: classINET, TTL: 300, Data: data})
+	}
+	return nil
+}
+
+// 将记录值编码为记录数据
+func mockRData(rrtype uint16, value string) ([]byte, error) {
+	switch rrtype {
+	case typeA, typeAAAA:
+		ip := net.ParseIP(value)
+		if rrtype == typeA {
+			ip = ip.To4()
+		}
+		if ip == nil {
+			return nil, fmt.Errorf("无效的 %s 记录值 %s", typeString(rrtype), value)
+		}
+		return ip, nil
+	case typeCNAME, typeNS, typePTR:
+		return appendName(nil, value)
+	case typeMX:
+		pref, host, _ := strings.Cut(value, " ")
+		n, err := strconv.Atoi(pref)
+		if err != nil {
+			return nil, fmt.Errorf("无效的 MX 记录值 %s", value)
+		}
+		return appendName(binary.BigEndian.AppendUint16(nil, uint16(n)), host)
+	case typeTXT:
+		var data []byte
+		for len(value) > 0 {
+			chunk := value
+			if len(chunk) > 255 {
+				chunk = chunk[:255]
+			}
+			data = append(append(data, byte(len(chunk))), chunk...)
+			value = value[len(chunk):]
+		}
+		return data, nil
+	}
+	return nil, fmt.Errorf("模拟服务器不支持 %s 记录", typeString(rrtype))
+}
+
+// 按名称查找响应码
+func rcodeByName(name string) (int, bool) {
+	for code, n := range rcodeNames {
+		if strings.EqualFold(n, name) {
+			return code, true
+		}
+	}
+	return 0, false
+}
+
+// 在 addr 上以 UDP 和 TCP 提供模拟应答，直到 ctx 取消，返回实际监听的地址；
+// 使用该地址配置的行为，未配置时使用 Default
+func (m *MockServer) ListenAndServe(ctx context.Context, addr string) (string, error) {
+	pc, err := net.ListenPacket("udp", addr)
+	if err != nil {
+		return "", err
+	}
+	actual := pc.LocalAddr().String()
+	ln, err := net.Listen("tcp", actual)
+	if err != nil {
+		pc.Close()
+		return "", err
+	}
+	context.AfterFunc(ctx, func() {
+		pc.Close()
+		ln.Close()
+	})
+
+	go func() {
+		buf := make([]byte, 65535)
+		for {
+			n, peer, err := pc.ReadFrom(buf)
+			if err != nil {
+				return
+			}
+			req := append([]byte(nil), buf[:n]...)
+			go func() {
+				if resp, err := m.Exchange(ctx, "udp", actual, req); err == nil {
+					pc.WriteTo(resp, peer)
+				}
+			}()
+		}
+	}()
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			go m.serveStream(ctx, conn, actual)
+		}
+	}()
+	return actual, nil
+}
+
+// 在 TCP 连接上逐个读取带长度前缀的查询并应答
+func (m *MockServer) serveStream(ctx context.Context, conn net.Conn, addr string) {
+	defer conn.Close()
+	if b := m.behavior(addr); b == nil || b.NoTCP {
+		return
+	}
+	for {
+		var length [2]byte
+		if _, err := io.ReadFull(conn, length[:]); err != nil {
+			return
+		}
+		req := make([]byte, binary.BigEndian.Uint16(length[:]))
+		if _, err := io.ReadFull(conn, req); err != nil {
+			return
+		}
+		resp, err := m.Exchange(ctx, "tcp", addr, req)
+		if err != nil {
+			return
+		}
+		if _, err := conn.Write(append(binary.BigEndian.AppendUint16(nil, uint16(len(resp))), resp...)); err != nil {
+			return
+		}
+	}
+}
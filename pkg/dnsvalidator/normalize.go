@@ -0,0 +1,118 @@
+package dnsvalidator
+
+import (
+	"net"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// 被拒绝的条目记录
+type rejectLog struct {
+	mu    sync.Mutex
+	w     *lockedWriter // 为空则只计数
+	log   *logger       // 需要说明原因的拒绝信息
+	count int
+}
+
+// 记录一条被拒绝的条目及原因
+func (r *rejectLog) reject(cand candidate, reason string) {
+	r.mu.Lock()
+	r.count++
+	r.mu.Unlock()
+	if r.w != nil {
+		if err := r.w.WriteLine(cand.server + "\t" + reason + "\t" + cand.source); err != nil {
+			r.log.printf("写入拒绝条目文件时出错：%v\n", err)
+		}
+	}
+}
+
+// 清理单行输入：去除 BOM、CR、首尾空白和 # 开头的注释
+func normalizeLine(line string) string {
+	line = strings.TrimPrefix(line, "\uFEFF")
+	if i := strings.Index(line, "#"); i >= 0 {
+		line = line[:i]
+	}
+	return strings.TrimSpace(strings.TrimRight(line, "\r"))
+}
+
+// 不应作为公共解析器的保留、私有和文档地址段
+var bogonNetworks = mustParseCIDRs(
+	"0.0.0.0/8", "100.64.0.0/10", "192.0.0.0/24", "192.0.2.0/24", "198.18.0.0/15",
+	"198.51.100.0/24", "203.0.113.0/24", "240.0.0.0/4", "255.255.255.255/32",
+	"::/128", "100::/64", "2001:db8::/32",
+)
+
+// 私有和本地地址段，使用 -allow-private 时允许
+var privateNetworks = mustParseCIDRs(
+	"10.0.0.0/8", "127.0.0.0/8", "169.254.0.0/16", "172.16.0.0/12", "192.168.0.0/16",
+	"::1/128", "fc00::/7", "fe80::/10",
+)
+
+// 解析内置的网段列表
+func mustParseCIDRs(cidrs ...string) []*net.IPNet {
+	networks := make([]*net.IPNet, 0, len(cidrs))
+	for _, cidr := range cidrs {
+		_, network, err := net.ParseCIDR(cidr)
+		if err != nil {
+			panic(err)
+		}
+		networks = append(networks, network)
+	}
+	return networks
+}
+
+// 检查条目是否为可用的服务器地址，返回拒绝原因，合法时返回空字符串
+func entryRejectReason(entry string, allowPrivate bool) string {
+	if isDoHURL(entry) {
+		u, err := url.Parse(entry)
+		if err != nil || u.Host == "" {
+			return "无效的 DoH URL"
+		}
+		return ""
+	}
+	if strings.ContainsAny(entry, " \t") {
+		return "包含空白字符"
+	}
+	host, port := splitServer(entry)
+	if port != "" {
+		if n, err := strconv.Atoi(port); err != nil || n < 1 || n > 65535 {
+			return "无效的端口"
+		}
+	}
+	ip := net.ParseIP(host)
+	if ip == nil {
+		return "无效的 IP 地址"
+	}
+	switch {
+	case ip.IsMulticast():
+		return "组播地址"
+	case ip.IsUnspecified() || containsIP(bogonNetworks, ip.String()):
+		return "保留地址 (bogon)"
+	case !allowPrivate && containsIP(privateNetworks, ip.String()):
+		return "私有或本地地址"
+	}
+	return ""
+}
+
+// 判断主机名是否符合语法：由字母、数字和连字符组成的标签，顶级域不全为数字
+func validHostname(host string) bool {
+	host = strings.TrimSuffix(host, ".")
+	if host == "" || len(host) > 253 {
+		return false
+	}
+	labels := strings.Split(host, ".")
+	for _, label := range labels {
+		if label == "" || len(label) > 63 || label[0] == '-' || label[len(label)-1] == '-' {
+			return false
+		}
+		for _, ch := range label {
+			if !(ch >= 'a' && ch <= 'z' || ch >= 'A' && ch <= 'Z' || ch >= '0' && ch <= '9' || ch == '-') {
+				return false
+			}
+		}
+	}
+	_, err := strconv.Atoi(labels[len(labels)-1])
+	return err != nil
+}
@@ -0,0 +1,134 @@
+package dnsvalidator
+
+import (
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestNormalizeLine(t *testing.T) {
+	tests := []struct {
+		line string
+		want string
+	}{
+		{"8.8.8.8", "8.8.8.8"},
+		{"  1.1.1.1\t", "1.1.1.1"},
+		{"9.9.9.9\r", "9.9.9.9"},
+		{"\uFEFF8.8.4.4", "8.8.4.4"},
+		{"1.0.0.1 # Cloudflare", "1.0.0.1"},
+		{"# 注释", ""},
+		{"", ""},
+		{"https://dns.google/dns-query", "https://dns.google/dns-query"},
+	}
+	for _, tt := range tests {
+		if got := normalizeLine(tt.line); got != tt.want {
+			t.Errorf("normalizeLine(%q) = %q，应为 %q", tt.line, got, tt.want)
+		}
+	}
+}
+
+func TestBootstrapAddr(t *testing.T) {
+	tests := []struct {
+		bootstrap string
+		want      string
+	}{
+		{"8.8.8.8", "8.8.8.8:53"},
+		{"8.8.8.8:5353", "8.8.8.8:5353"},
+		{"2001:4860:4860::8888", "[2001:4860:4860::8888]:53"},
+		{"[::1]", "[::1]:53"},
+		{"[::1]:5353", "[::1]:5353"},
+	}
+	for _, tt := range tests {
+		if got := bootstrapAddr(tt.bootstrap); got != tt.want {
+			t.Errorf("bootstrapAddr(%q) = %q，应为 %q", tt.bootstrap, got, tt.want)
+		}
+	}
+}
+
+func TestEntryRejectReason(t *testing.T) {
+	tests := []struct {
+		entry        string
+		allowPrivate bool
+		want         string
+	}{
+		{"8.8.8.8", false, ""},
+		{"8.8.8.8:5353", false, ""},
+		{"[2001:4860:4860::8888]:53", false, ""},
+		{"2606:4700:4700::1111", false, ""},
+		{"https://dns.google/dns-query", false, ""},
+		{"https:///dns-query", false, "无效的 DoH URL"},
+		{"8.8.8.8 1.1.1.1", false, "包含空白字符"},
+		{"8.8.8.8:0", false, "无效的端口"},
+		{"8.8.8.8:65536", false, "无效的端口"},
+		{"8.8.8.8:dns", false, "无效的端口"},
+		{"8.8.8", false, "无效的 IP 地址"},
+		{"224.0.0.251", false, "组播地址"},
+		{"ff02::fb", false, "组播地址"},
+		{"0.0.0.0", false, "保留地址 (bogon)"},
+		{"192.0.2.1", false, "保留地址 (bogon)"},
+		{"100.64.0.1", false, "保留地址 (bogon)"},
+		{"2001:db8::1", false, "保留地址 (bogon)"},
+		{"192.168.1.1", false, "私有或本地地址"},
+		{"127.0.0.1", false, "私有或本地地址"},
+		{"fe80::1", false, "私有或本地地址"},
+		{"192.168.1.1", true, ""},
+		{"::1", true, ""},
+	}
+	for _, tt := range tests {
+		if got := entryRejectReason(tt.entry, tt.allowPrivate); got != tt.want {
+			t.Errorf("entryRejectReason(%q, %v) = %q，应为 %q", tt.entry, tt.allowPrivate, got, tt.want)
+		}
+	}
+}
+
+func TestExpandCIDR(t *testing.T) {
+	tests := []struct {
+		entry      string
+		maxHosts   int
+		allowLarge bool
+		want       []string // 展开得到的条目，nil 表示展开失败
+		rejected   int
+	}{
+		{"8.8.8.8", 16, false, []string{"8.8.8.8"}, 0},
+		{"https://dns.example/a/b", 16, false, []string{"https://dns.example/a/b"}, 0},
+		{"8.8.8.8/32", 16, false, []string{"8.8.8.8"}, 0},
+		{"8.8.8.5/30", 16, false, []string{"8.8.8.4", "8.8.8.5", "8.8.8.6", "8.8.8.7"}, 0},
+		{"2001:4860::/126", 16, false, []string{"2001:4860::", "2001:4860::1", "2001:4860::2", "2001:4860::3"}, 0},
+		{"255.255.255.254/31", 16, false, []string{"255.255.255.254", "255.255.255.255"}, 0},
+		{"8.8.8.0/24", 16, false, nil, 0},
+		{"8.8.8.0/28", 16, false, make([]string, 16), 0},
+		{"8.8.8.0/27", 16, true, make([]string, 32), 0},
+		{"2001:4860::/64", 16, true, nil, 0},
+		{"8.8.8.8/33", 16, false, []string{}, 1},
+	}
+	for _, tt := range tests {
+		rejects := &rejectLog{}
+		var got []string
+		err := expandCIDR(candidate{server: tt.entry, source: "list.txt"}, tt.maxHosts, tt.allowLarge, rejects, func(c candidate) {
+			if c.source != "list.txt" {
+				t.Errorf("%s: 展开的条目 %s 丢失了来源", tt.entry, c.server)
+			}
+			got = append(got, c.server)
+		})
+		if tt.want == nil {
+			if err == nil {
+				t.Errorf("%s: 应返回错误", tt.entry)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%s: %v", tt.entry, err)
+			continue
+		}
+		if rejects.count != tt.rejected {
+			t.Errorf("%s: 拒绝了 %d 个条目，应为 %d 个", tt.entry, rejects.count, tt.rejected)
+		}
+		if len(got) != len(tt.want) {
+			t.Errorf("%s: 展开得到 %d 个条目，应为 %d 个", tt.entry, len(got), len(tt.want))
+			continue
+		}
+		if len(tt.want) > 0 && tt.want[0] != "" && !slices.Equal(got, tt.want) {
+			t.Errorf("%s: 展开得到 %s，应为 %s", tt.entry, strings.Join(got, ","), strings.Join(tt.want, ","))
+		}
+	}
+}
@@ -0,0 +1,164 @@
+package dnsvalidator
+
+import (
+	"io"
+	"net"
+	"time"
+)
+
+// NewValidator 的配置项，依次修改 Options 中的字段
+type Option func(*Options)
+
+// 按配置项创建 Validator，未设置的字段与 New 一样使用默认值
+//
+//	v, err := dnsvalidator.NewValidator(
+//		dnsvalidator.WithDomains("google.com", "example.com"),
+//		dnsvalidator.WithTimeout(2*time.Second),
+//		dnsvalidator.WithThreads(50),
+//		dnsvalidator.WithChecks("nxdomain", "dnssec"),
+//	)
+func NewValidator(opts ...Option) (*Validator, error) {
+	var cfg Options
+	for _, opt := range opts {
+		opt(&cfg)
+	}
+	return New(cfg)
+}
+
+// 以现有的 Options 为基础，之后的配置项在此基础上修改
+func WithOptions(cfg Options) Option {
+	return func(o *Options) { *o = cfg }
+}
+
+// 需要解析的域名，第一个为主检查域名
+func WithDomains(domains ...string) Option {
+	return func(o *Options) { o.Domains = domains }
+}
+
+// 单次查询的超时
+func WithTimeout(timeout time.Duration) Option {
+	return func(o *Options) { o.Timeout = timeout }
+}
+
+// 建立 TCP/TLS 连接的超时
+func WithConnectTimeout(timeout time.Duration) Option {
+	return func(o *Options) { o.ConnectTimeout = timeout }
+}
+
+// 整个运行的最长时间
+func WithDeadline(d time.Duration) Option {
+	return func(o *Options) { o.Deadline = d }
+}
+
+// 查询超时后的重试次数及第一次重试前的等待时间
+func WithRetries(retries int, backoff time.Duration) Option {
+	return func(o *Options) { o.Retries, o.Backoff = retries, backoff }
+}
+
+// 根据 RTT 动态调整查询超时，超时为 RTT 中位数的 factor 倍并限制在 [lo, hi] 内，零值使用默认值
+func WithAdaptiveTimeout(factor float64, lo, hi time.Duration) Option {
+	return func(o *Options) {
+		o.AdaptiveTimeout = true
+		o.AdaptiveFactor, o.AdaptiveMin, o.AdaptiveMax = factor, lo, hi
+	}
+}
+
+// 同时检查的服务器数
+func WithThreads(n int) Option {
+	return func(o *Options) { o.Threads, o.AutoThreads = n, false }
+}
+
+// 自动调整并发，最多 limit 个
+func WithAutoThreads(limit int) Option {
+	return func(o *Options) { o.AutoThreads, o.MaxThreads = true, limit }
+}
+
+// 所有 worker 合计每秒最多发出的查询数
+func WithRateLimit(qps int) Option {
+	return func(o *Options) { o.RateLimit = qps }
+}
+
+// 查询所用的传输协议: udp、tcp、both 或 dot
+func WithTransport(transport string) Option {
+	return func(o *Options) { o.Transport = transport }
+}
+
+// DoH 请求方法: GET 或 POST
+func WithDoHMethod(method string) Option {
+	return func(o *Options) { o.DoHMethod = method }
+}
+
+// 条目未指定端口时使用的端口
+func WithPort(port string) Option {
+	return func(o *Options) { o.Port = port }
+}
+
+// 替代网络发送所有查询，见 MockServer
+func WithExchanger(ex Exchanger) Option {
+	return func(o *Options) { o.Exchanger = ex }
+}
+
+// 每次查询发送前和收到响应后调用的中间件
+func WithMiddleware(mws ...Middleware) Option {
+	return func(o *Options) { o.Middleware = append(o.Middleware, mws...) }
+}
+
+// 可信基准服务器，丢弃答案与基准不一致的服务器
+func WithBaseline(servers ...string) Option {
+	return func(o *Options) { o.BaselineServers = servers }
+}
+
+// 域名到预期答案网段的映射，见 LoadExpected
+func WithExpected(expected map[string][]*net.IPNet) Option {
+	return func(o *Options) { o.Expected = expected }
+}
+
+// 按名称启用内置检查，见 CheckNames
+func WithChecks(names ...string) Option {
+	return func(o *Options) { o.EnableChecks = append(o.EnableChecks, names...) }
+}
+
+// 按名称跳过检查
+func WithoutChecks(names ...string) Option {
+	return func(o *Options) { o.DisableChecks = append(o.DisableChecks, names...) }
+}
+
+// 在内置检查之后依次执行的自定义检查
+func WithCheckers(checkers ...Checker) Option {
+	return func(o *Options) { o.Checkers = append(o.Checkers, checkers...) }
+}
+
+// 需要全部查询成功的记录类型
+func WithTypes(types ...uint16) Option {
+	return func(o *Options) { o.Types = types }
+}
+
+// 允许私有、回环和链路本地地址
+func WithAllowPrivate() Option {
+	return func(o *Options) { o.AllowPrivate = true }
+}
+
+// 找到 n 台可用服务器后停止
+func WithStopAfter(n int) Option {
+	return func(o *Options) { o.StopAfter = n }
+}
+
+// 逐台服务器的检查信息写入 w
+func WithProgress(w io.Writer) Option {
+	return func(o *Options) { o.Progress = w }
+}
+
+// 每台通过检查的服务器调用一次 fn
+func WithOnResult(fn func(*Result)) Option {
+	return func(o *Options) { o.OnResult = fn }
+}
+
+// 每台未通过检查的服务器调用一次 fn
+func WithOnFailure(fn func(server string, err error)) Option {
+	return func(o *Options) { o.OnFailure = fn }
+}
+
+// 未通过检查的服务器也作为 Result 送出
+func WithIncludeFailed() Option {
+	return func(o *Options) { o.IncludeFailed = true }
+}
@@ -0,0 +1,156 @@
+package dnsvalidator
+
+import (
+	"net"
+	"sync/atomic"
+	"time"
+)
+
+// 逐条预处理输入条目：解码 DNS Stamp、展开 CIDR、解析主机名、拒绝无效和被排除的地址、
+// 去重并按地址族筛选，然后交给 worker。整个过程不在内存中保存完整的列表，
+// 只保留用于去重的条目键
+type pipeline struct {
+	maxExpand    int
+	allowLarge   bool
+	bootstrap    *client
+	bootstrapSrv string
+	allowPrivate bool
+	excludes     []*net.IPNet
+	geoip        *GeoIP
+	asn          *ASNLookup
+	filter       *GeoFilter // 按国家和 ASN 跳过服务器，为空不筛选
+	minReliable  float64    // 列表中记录的可靠性下限
+	family       string     // 4、6 或 both
+	ipv6Domain   string     // 检测本机 IPv6 连通性时查询的域名
+	rejects      *rejectLog
+	log          *logger
+	state        *runState
+
+	reputation *ReputationStore
+	skipDead   time.Duration // 跳过历史记录中长期不可用的服务器，为 0 不跳过
+
+	seen        map[string]bool // 已投递的条目键，-resume 时预先填入上次运行完成检查的服务器
+	ipv6Checked bool
+	ipv6Down    bool // 本机没有 IPv6 连通性
+
+	// 运行中可能被 Validator.Progress 并发读取
+	read       atomic.Int64 // 读取的输入条目数
+	dispatched atomic.Int64 // 交给 worker 的条目数
+}
+
+// 处理 in 中的全部条目并投递到 jobs，遇到无法继续的错误 (如网段过大) 时停止并返回错误
+func (p *pipeline) run(in <-chan candidate, jobs chan<- candidate) error {
+	if p.seen == nil {
+		p.seen = make(map[string]bool)
+	}
+	for cand := range in {
+		// 收到中断信号或超过 -deadline 后不再投递新的条目
+		if p.state.stopping() {
+			go func() {
+				for range in {
+				}
+			}()
+			return nil
+		}
+		p.read.Add(1)
+		cand, ok := decodeStamp(cand, p.rejects)
+		if !ok {
+			continue
+		}
+		err := expandCIDR(cand, p.maxExpand, p.allowLarge, p.rejects, func(cand candidate) {
+			for _, resolved := range resolveHostname(p.bootstrap, p.bootstrapSrv, cand, p.rejects) {
+				p.dispatch(resolved, jobs)
+			}
+		})
+		if err != nil {
+			// 丢弃剩余的输入，让读取来源的 goroutine 退出
+			go func() {
+				for range in {
+				}
+			}()
+			return err
+		}
+	}
+	return nil
+}
+
+// 检查单个条目，通过后交给 worker
+func (p *pipeline) dispatch(cand candidate, jobs chan<- candidate) {
+	if p.state.stopping() {
+		return
+	}
+
+	// 拒绝无效、组播、保留和私有地址
+	if reason := entryRejectReason(cand.server, p.allowPrivate); reason != "" {
+		p.rejects.reject(cand, reason)
+		return
+	}
+
+	// 跳过排除列表中的地址
+	if excluded(cand, p.excludes) {
+		p.rejects.reject(cand, "在排除列表中")
+		return
+	}
+
+	// 重复条目只检查一次
+	key := dedupKey(cand.server)
+	if p.seen[key] {
+		return
+	}
+	p.seen[key] = true
+
+	// 按列表中记录的可靠性、国家和 ASN 跳过服务器
+	if m := cand.meta; m != nil && m.hasReliability && m.reliability < p.minReliable {
+		p.rejects.reject(cand, "列表中记录的可靠性过低")
+		return
+	}
+	if p.reputation.dead(cand.server, p.skipDead) {
+		p.rejects.reject(cand, "历史记录中长期不可用")
+		return
+	}
+	if !p.geoAllowed(cand) {
+		p.rejects.reject(cand, "不符合国家或 ASN 筛选条件")
+		return
+	}
+
+	// 按地址族筛选服务器
+	if !familyAllowed(cand.server, p.family) {
+		return
+	}
+
+	// 遇到第一台 IPv6 服务器时确认本机具备 IPv6 连通性，否则跳过所有 IPv6 服务器
+	if ipFamily(cand.server) == 6 {
+		if !p.ipv6Checked {
+			p.ipv6Checked = true
+			if err := checkIPv6Connectivity(p.bootstrap, p.ipv6Domain); err != nil {
+				p.log.printf("本机没有 IPv6 连通性 (%v)，跳过所有 IPv6 服务器\n", err)
+				p.ipv6Down = true
+			}
+		}
+		if p.ipv6Down {
+			return
+		}
+	}
+
+	p.dispatched.Add(1)
+	jobs <- cand
+}
+
+// 查询条目地址的国家和 ASN，判断是否符合筛选条件
+func (p *pipeline) geoAllowed(cand candidate) bool {
+	if p.filter == nil {
+		return true
+	}
+	r := &Result{}
+	if !isDoHURL(cand.server) {
+		r.IP, _ = splitServer(cand.server)
+	}
+	if p.filter.byCountry() {
+		p.geoip.annotate(r)
+	}
+	if p.filter.byASN() {
+		p.asn.annotate(p.bootstrap, r)
+	}
+	cand.meta.fill(r)
+	return p.filter.Allow(r)
+}
@@ -0,0 +1,81 @@
+package dnsvalidator
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// DNS-OARC 的源端口测试区域：递归解析这个名称时，权威服务器会让解析器连续发出多次查询，
+// 再以 TXT 记录返回评级，如 "192.0.2.1 is GREAT: 26 queries in 2.0 seconds from 26 ports with std dev 17685"
+const defaultPortTestZone = "porttest.dns-oarc.net"
+
+// 源端口随机化评级，由高到低
+var portGrades = []string{"great", "good", "fair", "poor"}
+
+var (
+	portGradePattern  = regexp.MustCompile(`(?i)\bis (GREAT|GOOD|FAIR|POOR)\b`)
+	portStdDevPattern = regexp.MustCompile(`std dev (\d+)`)
+	portCountPattern  = regexp.MustCompile(`from (\d+) ports`)
+)
+
+// 评级在 portGrades 中的位置，无法识别时返回 -1
+func portGradeRank(grade string) int {
+	for i, g := range portGrades {
+		if g == grade {
+			return i
+		}
+	}
+	return -1
+}
+
+// 查询源端口测试区域，返回评级、端口数和端口号标准差；服务器无法解析测试区域时评级为空
+func portTest(c *client, server, zone string) (grade, ports, stddev string) {
+	resp, _, err := c.exchange(server, newQuery(zone, typeTXT))
+	if err != nil || resp.RCode != rcodeSuccess {
+		return "", "", ""
+	}
+	for _, value := range resp.answerValues(typeTXT) {
+		m := portGradePattern.FindStringSubmatch(value)
+		if m == nil {
+			continue
+		}
+		grade = strings.ToLower(m[1])
+		if m := portCountPattern.FindStringSubmatch(value); m != nil {
+			ports = m[1]
+		}
+		if m := portStdDevPattern.FindStringSubmatch(value); m != nil {
+			stddev = m[1]
+		}
+		return grade, ports, stddev
+	}
+	return "", "", ""
+}
+
+// 记录递归查询的源端口随机化评级，低于 minGrade 时返回失败；无法测试时评级为 unknown，不会因此被丢弃
+func checkPortRandom(c *client, server, zone, minGrade string) CheckResult {
+	grade, ports, stddev := portTest(c, server, zone)
+	if grade == "" {
+		return CheckResult{Attrs: []string{"port_random=unknown"}}
+	}
+	if minGrade != "" && portGradeRank(grade) > portGradeRank(minGrade) {
+		return CheckResult{Err: newFailure(failPortRandom, "源端口随机化评级为 %s，低于 %s", grade, minGrade)}
+	}
+	attrs := []string{"port_random=" + grade}
+	if ports != "" {
+		attrs = append(attrs, "port_count="+ports)
+	}
+	if stddev != "" {
+		attrs = append(attrs, "port_stddev="+stddev)
+	}
+	return CheckResult{Attrs: attrs}
+}
+
+// 检查源端口随机化最低评级的取值
+func validPortGrade(grade string) error {
+	// poor 是最低评级，作为下限没有意义
+	if grade == "" || portGradeRank(grade) >= 0 && grade != "poor" {
+		return nil
+	}
+	return fmt.Errorf("源端口随机化最低评级只能是 great、good 或 fair")
+}
@@ -0,0 +1,107 @@
+package dnsvalidator
+
+import (
+	"io"
+	"os"
+	"sync/atomic"
+	"time"
+)
+
+// 统计从各来源读取的原始字节数、来源的总大小和已读出的条目数，用于估计输入的条目总数
+type inputSize struct {
+	read    atomic.Int64
+	total   atomic.Int64
+	entries atomic.Int64
+	unknown atomic.Bool // 有来源的大小未知，如管道输入或没有 Content-Length 的响应
+	done    atomic.Bool // 所有来源都已读完
+}
+
+// 返回记录读取字节数的 r，size 为来源的总字节数，小于 0 表示未知；s 为空时直接返回 r
+func (s *inputSize) track(r io.Reader, size int64) io.Reader {
+	if s == nil {
+		return r
+	}
+	if size < 0 {
+		s.unknown.Store(true)
+	} else {
+		s.total.Add(size)
+	}
+	return &countingReader{r: r, n: &s.read}
+}
+
+// 已读取的比例，所有来源读完时为 1；有来源大小未知或尚未读取时返回 false
+func (s *inputSize) fraction() (float64, bool) {
+	if s == nil {
+		return 0, false
+	}
+	if s.done.Load() {
+		return 1, true
+	}
+	if s.unknown.Load() {
+		return 0, false
+	}
+	read, total := s.read.Load(), s.total.Load()
+	if total == 0 {
+		return 1, true
+	}
+	if read == 0 {
+		return 0, false
+	}
+	return min(float64(read)/float64(total), 1), true
+}
+
+type countingReader struct {
+	r io.Reader
+	n *atomic.Int64
+}
+
+func (c *countingReader) Read(p []byte) (int, error) {
+	n, err := c.r.Read(p)
+	c.n.Add(int64(n))
+	return n, err
+}
+
+// 返回普通文件的大小，r 不是普通文件 (如管道) 时返回 -1
+func readerSize(r io.Reader) int64 {
+	f, ok := r.(*os.File)
+	if !ok {
+		return -1
+	}
+	info, err := f.Stat()
+	if err != nil || !info.Mode().IsRegular() {
+		return -1
+	}
+	return info.Size()
+}
+
+// 运行进度的快照，见 Validator.Progress
+type Progress struct {
+	Read      int           // 读取的输入条目数
+	Tested    int           // 完成检查的服务器数
+	Valid     int           // 通过检查的服务器数
+	Remaining int           // 估计尚未完成检查的服务器数，无法估计时为 -1
+	Elapsed   time.Duration // 自 Run 开始经过的时间
+}
+
+// 返回运行进度，可以在运行中并发调用。尚未完成检查的服务器数按来源已读取的字节比例估计，
+// 网段展开、重复和被拒绝的条目都计入估计，从断点文件恢复的服务器不计入；有大小未知的来源 (如管道输入) 且尚未读完时无法估计
+func (v *Validator) Progress() Progress {
+	p := Progress{Tested: v.opts.stats.Tested(), Valid: v.opts.stats.Valid(), Remaining: -1}
+	if v.pipe == nil {
+		return p
+	}
+	read, dispatched := v.pipe.read.Load(), v.pipe.dispatched.Load()
+	p.Read = int(read)
+	p.Elapsed = time.Since(v.started)
+	f, ok := v.input.fraction()
+	if !ok || f == 0 {
+		return p
+	}
+	// 尚未预处理的条目按已预处理条目的展开比例折算为服务器数，再加上已投递但未完成检查的服务器
+	pending := float64(v.input.entries.Load())/f - float64(read)
+	if read > 0 {
+		pending *= float64(dispatched) / float64(read)
+	}
+	p.Remaining = max(int(pending)+int(dispatched)-(p.Tested-v.resumed), 0)
+	return p
+}
@@ -0,0 +1,55 @@
+package dnsvalidator
+
+import (
+	"net/netip"
+	"strconv"
+	"strings"
+)
+
+// 通过 server 查询 ip 的 PTR 记录，返回第一个主机名 (去掉末尾的点)；查不到时返回空
+func lookupPTR(c *client, server string, ip netip.Addr) string {
+	zone := ".in-addr.arpa"
+	if ip.Is6() {
+		zone = ".ip6.arpa"
+	}
+	resp, _, err := c.exchange(server, newQuery(reverseLabels(ip)+zone, typePTR))
+	if err != nil || resp.RCode != rcodeSuccess {
+		return ""
+	}
+	names := resp.answerValues(typePTR)
+	if len(names) == 0 {
+		return ""
+	}
+	return strings.TrimSuffix(names[0], ".")
+}
+
+// 通过引导服务器反向解析结果的 IP，填入 PTR 并在文本输出中加入 ptr 列
+func annotatePTR(o *options, r *Result) {
+	if !o.ptr || r.IP == "" {
+		return
+	}
+	ip, err := netip.ParseAddr(strings.Trim(r.IP, "[]"))
+	if err != nil {
+		return
+	}
+	if r.PTR = lookupPTR(o.udpClient(), o.ptrServer, ip.Unmap()); r.PTR != "" {
+		r.attrs = append(r.attrs, "ptr="+r.PTR)
+	}
+}
+
+// 反向解析所用的标签：IPv4 为倒序的四段，IPv6 为倒序的 32 个半字节
+func reverseLabels(ip netip.Addr) string {
+	b := ip.AsSlice()
+	var labels []string
+	if ip.Is4() {
+		for i := len(b) - 1; i >= 0; i-- {
+			labels = append(labels, strconv.Itoa(int(b[i])))
+		}
+		return strings.Join(labels, ".")
+	}
+	const hex = "0123456789abcdef"
+	for i := len(b) - 1; i >= 0; i-- {
+		labels = append(labels, string(hex[b[i]&0xf]), string(hex[b[i]>>4]))
+	}
+	return strings.Join(labels, ".")
+}
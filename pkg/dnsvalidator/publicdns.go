@@ -0,0 +1,164 @@
+package dnsvalidator
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// public-dns.info 的 CSV 和 JSON 导出中每台服务器的信息，如
+// https://public-dns.info/nameservers.csv 和 https://public-dns.info/nameservers.json
+type listMeta struct {
+	name           string
+	country        string
+	city           string
+	asn            uint
+	asOrg          string
+	reliability    float64
+	hasReliability bool
+}
+
+// JSON 导出中的一条记录，CSV 导出的列名相同
+type publicDNSEntry struct {
+	IPAddress   string   `json:"ip_address"`
+	Name        string   `json:"name"`
+	ASNumber    uint     `json:"as_number"`
+	ASOrg       string   `json:"as_org"`
+	CountryCode string   `json:"country_code"`
+	City        string   `json:"city"`
+	Reliability *float64 `json:"reliability"`
+}
+
+func (e *publicDNSEntry) meta() *listMeta {
+	m := &listMeta{name: e.Name, country: e.CountryCode, city: e.City, asn: e.ASNumber, asOrg: e.ASOrg}
+	if e.Reliability != nil {
+		m.reliability, m.hasReliability = *e.Reliability, true
+	}
+	return m
+}
+
+// 判断列表格式：以 [{ 开头为 JSON 导出 ([2001:db8::1]:53 这样的条目不是)，首行以 ip_address 列开头为 CSV 导出，
+// 否则为每行一个条目。只预读判断所需的字节，流式输入的第一行不必等待更多数据
+func listFormat(br *bufio.Reader) string {
+	const csvHeader = "ip_address,"
+	for n := 1; n <= 512; n++ {
+		head, err := br.Peek(n)
+		if len(head) < 3 && bytes.HasPrefix([]byte("\uFEFF"), head) && err == nil {
+			continue
+		}
+		head = bytes.TrimLeft(bytes.TrimPrefix(head, []byte("\uFEFF")), " \t\r\n")
+		switch {
+		case len(head) > 0 && head[0] == '[':
+			rest := bytes.TrimLeft(head[1:], " \t\r\n")
+			if len(rest) > 0 && (rest[0] == '{' || rest[0] == ']') {
+				return "json"
+			}
+			if len(rest) > 0 {
+				return "text"
+			}
+		case !strings.HasPrefix(csvHeader, string(head[:min(len(head), len(csvHeader))])):
+			return "text"
+		case len(head) >= len(csvHeader):
+			return "csv"
+		}
+		if err != nil {
+			break
+		}
+	}
+	return "text"
+}
+
+// 逐条读取 JSON 导出
+func scanPublicDNSJSON(r io.Reader, emit func(string, *listMeta)) error {
+	dec := json.NewDecoder(r)
+	if _, err := dec.Token(); err != nil {
+		return err
+	}
+	for dec.More() {
+		var e publicDNSEntry
+		if err := dec.Decode(&e); err != nil {
+			return err
+		}
+		if ip := strings.TrimSpace(e.IPAddress); ip != "" {
+			emit(ip, e.meta())
+		}
+	}
+	_, err := dec.Token()
+	return err
+}
+
+// 逐行读取 CSV 导出，按表头定位各列，未知的列忽略
+func scanPublicDNSCSV(r io.Reader, emit func(string, *listMeta)) error {
+	cr := csv.NewReader(r)
+	cr.FieldsPerRecord = -1
+	header, err := cr.Read()
+	if err != nil {
+		return err
+	}
+	columns := make(map[string]int, len(header))
+	for i, name := range header {
+		columns[strings.TrimPrefix(strings.TrimSpace(name), "\uFEFF")] = i
+	}
+	get := func(record []string, name string) string {
+		if i, ok := columns[name]; ok && i < len(record) {
+			return strings.TrimSpace(record[i])
+		}
+		return ""
+	}
+	for line := 2; ; line++ {
+		record, err := cr.Read()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		e := publicDNSEntry{
+			IPAddress:   get(record, "ip_address"),
+			Name:        get(record, "name"),
+			ASOrg:       get(record, "as_org"),
+			CountryCode: get(record, "country_code"),
+			City:        get(record, "city"),
+		}
+		if text := get(record, "as_number"); text != "" {
+			asn, err := strconv.ParseUint(text, 10, 32)
+			if err != nil {
+				return fmt.Errorf("第 %d 行: 无效的 as_number %q", line, text)
+			}
+			e.ASNumber = uint(asn)
+		}
+		if text := get(record, "reliability"); text != "" {
+			reliability, err := strconv.ParseFloat(text, 64)
+			if err != nil {
+				return fmt.Errorf("第 %d 行: 无效的 reliability %q", line, text)
+			}
+			e.Reliability = &reliability
+		}
+		if e.IPAddress != "" {
+			emit(e.IPAddress, e.meta())
+		}
+	}
+}
+
+// 将列表中的信息填入结果：name、list_reliability 总是填入，
+// 国家、城市和 ASN 只在 GeoIP 和 ASN 数据库没有提供时填入
+func (m *listMeta) fill(r *Result) {
+	if m == nil {
+		return
+	}
+	r.Name = m.name
+	if m.hasReliability {
+		r.ListReliability = m.reliability
+	}
+	if r.Country == "" {
+		r.Country, r.City = m.country, m.city
+	}
+	if r.ASN == 0 {
+		r.ASN, r.ASOrg = m.asn, m.asOrg
+	}
+}
@@ -0,0 +1,24 @@
+package dnsvalidator
+
+import "strings"
+
+// internet.nl 的 QNAME 最小化测试区域：权威服务器根据收到的查询判断解析器是否做了最小化，
+// 以 TXT 记录返回 "HOORAY - QNAME minimisation is enabled on your resolver :)!" 或 "NO - QNAME minimisation is NOT enabled ..."
+const defaultQMinZone = "qnamemintest.internet.nl"
+
+// 查询测试区域，返回 true/false，服务器无法解析测试区域或返回无法识别的内容时为 unknown
+func checkQMin(c *client, server, zone string) []string {
+	result := "unknown"
+	resp, _, err := c.exchange(server, newQuery(zone, typeTXT))
+	if err == nil && resp.RCode == rcodeSuccess {
+		for _, value := range resp.answerValues(typeTXT) {
+			switch upper := strings.ToUpper(value); {
+			case strings.HasPrefix(upper, "HOORAY"):
+				result = "true"
+			case strings.HasPrefix(upper, "NO"):
+				result = "false"
+			}
+		}
+	}
+	return []string{"qname_min=" + result}
+}
@@ -0,0 +1,81 @@
+package dnsvalidator
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// 单个记录类型的查询结果
+type typeResult struct {
+	Type    uint16
+	RCode   int
+	Answers int
+	RTT     time.Duration
+	EDE     string // SERVFAIL 或 REFUSED 时的扩展错误描述
+	Err     error
+}
+
+// 返回查询失败的原因，成功时返回 nil
+func (r typeResult) failure() error {
+	switch {
+	case r.Err != nil:
+		return r.Err
+	case r.RCode != rcodeSuccess:
+		return rcodeFailureEDE(r.RCode, r.EDE)
+	case r.Answers == 0:
+		return newFailure(failNoAnswer, "没有返回 %s 记录", typeString(r.Type))
+	}
+	return nil
+}
+
+// 输出列格式为 类型=响应码/答案数/耗时
+func (r typeResult) String() string {
+	if r.Err != nil {
+		return typeString(r.Type) + "=ERROR"
+	}
+	return fmt.Sprintf("%s=%s/%d/%dms", typeString(r.Type), rcodeString(r.RCode), r.Answers, r.RTT.Milliseconds())
+}
+
+// 将逗号分隔的类型名称解析为类型编号
+func ParseTypes(s string) ([]uint16, error) {
+	var types []uint16
+	for _, name := range SplitList(s) {
+		t, ok := TypeByName(name)
+		if !ok {
+			return nil, fmt.Errorf("不支持的记录类型: %s", name)
+		}
+		types = append(types, t)
+	}
+	return types, nil
+}
+
+// 根据名称查找记录类型
+func TypeByName(name string) (uint16, bool) {
+	for t, n := range typeNames {
+		if strings.EqualFold(n, name) && t != typeOPT {
+			return t, true
+		}
+	}
+	return 0, false
+}
+
+// 依次查询每种记录类型
+func checkTypes(c *client, server, domain string, types []uint16) []typeResult {
+	results := make([]typeResult, 0, len(types))
+	for _, t := range types {
+		r := typeResult{Type: t}
+		query := newQuery(domain, t)
+		resp, rtt, err := c.exchange(server, query)
+		if err != nil {
+			r.Err = err
+		} else {
+			r.RCode = resp.RCode
+			r.Answers = len(resp.answerValues(t))
+			r.RTT = rtt
+			r.EDE = fetchExtendedError(c, server, query, resp)
+		}
+		results = append(results, r)
+	}
+	return results
+}
@@ -0,0 +1,44 @@
+package dnsvalidator
+
+import (
+	"sort"
+)
+
+// 检查排序依据是否受支持
+func ValidSort(by string) bool {
+	return by == "none" || by == "latency" || by == "reliability"
+}
+
+// 按延迟比较，没有测得延迟的服务器排在最后
+func fasterThan(a, b *Result) bool {
+	if (a.LatencyMs == 0) != (b.LatencyMs == 0) {
+		return b.LatencyMs == 0
+	}
+	return a.LatencyMs < b.LatencyMs
+}
+
+// 收集全部结果并按指定依据排序，返回按顺序输出的通道
+func SortResults(results <-chan *Result, by string) <-chan *Result {
+	var all []*Result
+	for r := range results {
+		all = append(all, r)
+	}
+	switch by {
+	case "latency":
+		sort.SliceStable(all, func(i, j int) bool { return fasterThan(all[i], all[j]) })
+	case "reliability":
+		// 可靠性相同时延迟低的优先
+		sort.SliceStable(all, func(i, j int) bool {
+			if all[i].Reliability != all[j].Reliability {
+				return all[i].Reliability > all[j].Reliability
+			}
+			return fasterThan(all[i], all[j])
+		})
+	}
+	sorted := make(chan *Result, len(all))
+	for _, r := range all {
+		sorted <- r
+	}
+	close(sorted)
+	return sorted
+}
@@ -0,0 +1,53 @@
+package dnsvalidator
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// 令牌桶限速器，每秒补充 rate 个令牌，最多积攒 burst 个
+type rateLimiter struct {
+	mu     sync.Mutex
+	rate   float64
+	burst  float64
+	tokens float64
+	last   time.Time
+}
+
+func newRateLimiter(qps int) *rateLimiter {
+	burst := float64(qps) / 10 // 最多允许 100ms 的突发
+	if burst < 1 {
+		burst = 1
+	}
+	return &rateLimiter{rate: float64(qps), burst: burst, tokens: burst, last: time.Now()}
+}
+
+// 取出一个令牌，令牌不足时等待；l 为空时立即返回，等待期间 ctx 被取消时返回其错误
+func (l *rateLimiter) wait(ctx context.Context) error {
+	if l == nil {
+		return nil
+	}
+	l.mu.Lock()
+	now := time.Now()
+	l.tokens += now.Sub(l.last).Seconds() * l.rate
+	if l.tokens > l.burst {
+		l.tokens = l.burst
+	}
+	l.last = now
+	// 令牌可以预支为负数，预支的调用者按欠下的令牌数等待，保证总体速率不超过 rate
+	l.tokens--
+	delay := time.Duration(-l.tokens / l.rate * float64(time.Second))
+	l.mu.Unlock()
+	if delay <= 0 {
+		return nil
+	}
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
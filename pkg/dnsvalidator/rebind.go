@@ -0,0 +1,70 @@
+package dnsvalidator
+
+import (
+	"fmt"
+	"strconv"
+)
+
+// 重绑定探测默认查询的域名，公网权威服务器分别返回 127.0.0.1、10.0.0.1 和 192.168.0.1
+var defaultRebindDomains = []string{"localtest.me", "10.0.0.1.nip.io", "192.168.0.1.nip.io"}
+
+// 确认服务器能解析 nip.io 的对照域名，公网权威服务器返回 1.1.1.1
+const rebindControl = "1.1.1.1.nip.io"
+
+// 查询解析到私有或回环地址的域名，判断服务器是否过滤这类应答 (DNS 重绑定保护)。
+// 任一查询返回了私有地址即为未保护；NOERROR 应答中没有私有地址才算有保护，其中答案为空的应答
+// 还需要对照域名能正常解析，以排除服务器根本无法访问探测域名的权威服务器的情况。
+// 查询出错、NXDOMAIN 或 SERVFAIL 等都无法判断，全部无法判断时第二个返回值为 false
+func checkRebind(c *client, server string, domains []string) (protected, known bool) {
+	empty := false // 有 NOERROR 应答不含任何地址
+	for _, domain := range domains {
+		resp, _, err := c.exchange(server, newQuery(domain, typeA))
+		if err != nil || resp.RCode != rcodeSuccess {
+			continue
+		}
+		answers := resp.answerValues(typeA)
+		for _, ip := range answers {
+			if containsIP(privateNetworks, ip) {
+				return false, true
+			}
+		}
+		if len(answers) > 0 {
+			protected = true
+		} else {
+			empty = true
+		}
+	}
+	if protected {
+		return true, true
+	}
+	if empty {
+		resp, _, err := c.exchange(server, newQuery(rebindControl, typeA))
+		if err == nil && resp.RCode == rcodeSuccess && len(resp.answerValues(typeA)) > 0 {
+			return true, true
+		}
+	}
+	return false, false
+}
+
+// 记录重绑定保护，filter 为 exclude 时丢弃有保护的服务器，为 only 时只保留有保护的服务器；无法判断时不输出也不过滤
+func checkRebindFilter(c *client, server string, domains []string, filter string) CheckResult {
+	protected, known := checkRebind(c, server, domains)
+	if !known {
+		return CheckResult{}
+	}
+	if filter == "exclude" && protected {
+		return CheckResult{Err: newFailure(failRebind, "过滤解析到私有地址的应答")}
+	}
+	if filter == "only" && !protected {
+		return CheckResult{Err: newFailure(failRebind, "返回解析到私有地址的应答，没有重绑定保护")}
+	}
+	return CheckResult{Attrs: []string{"rebind_protect=" + strconv.FormatBool(protected)}}
+}
+
+// 检查重绑定过滤方式的取值
+func validRebindFilter(filter string) error {
+	if filter != "" && filter != "exclude" && filter != "only" {
+		return fmt.Errorf("重绑定过滤方式只能是 exclude 或 only")
+	}
+	return nil
+}
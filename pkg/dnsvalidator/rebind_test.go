@@ -0,0 +1,41 @@
+package dnsvalidator
+
+import (
+	"testing"
+	"time"
+)
+
+func TestCheckRebind(t *testing.T) {
+	control := map[string][]string{rebindControl + " A": {"1.1.1.1"}}
+	with := func(records map[string][]string) map[string][]string {
+		for k, v := range control {
+			records[k] = v
+		}
+		return records
+	}
+	tests := []struct {
+		name      string
+		behavior  *MockBehavior
+		protected bool
+		known     bool
+	}{
+		{"返回私有地址", &MockBehavior{Records: with(map[string][]string{"10.0.0.1.nip.io A": {"10.0.0.1"}})}, false, true},
+		{"改写为公网地址", &MockBehavior{Records: map[string][]string{"10.0.0.1.nip.io A": {"203.0.113.1"}}}, true, true},
+		{"去掉私有地址的空应答", &MockBehavior{Records: with(map[string][]string{"10.0.0.1.nip.io AAAA": {"::1"}})}, true, true},
+		{"空应答且对照域名无法解析", &MockBehavior{Records: map[string][]string{"10.0.0.1.nip.io AAAA": {"::1"}}}, false, false},
+		{"NXDOMAIN", &MockBehavior{Records: control}, false, false},
+		{"SERVFAIL", &MockBehavior{RCode: "SERVFAIL"}, false, false},
+		{"REFUSED", &MockBehavior{RCode: "REFUSED"}, false, false},
+		{"不应答", &MockBehavior{DropRate: 1}, false, false},
+	}
+	for _, tt := range tests {
+		mock := NewMockServer()
+		mock.Handle("198.51.100.1:53", tt.behavior)
+		c := mockClient(mock)
+		c.timeout = 50 * time.Millisecond
+		protected, known := checkRebind(c, "198.51.100.1:53", []string{"10.0.0.1.nip.io"})
+		if protected != tt.protected || known != tt.known {
+			t.Errorf("%s: checkRebind = (%v, %v)，应为 (%v, %v)", tt.name, protected, known, tt.protected, tt.known)
+		}
+	}
+}
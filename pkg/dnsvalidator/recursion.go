@@ -0,0 +1,26 @@
+package dnsvalidator
+
+import (
+	"fmt"
+)
+
+// 根据响应的 RA 标志和响应码判断服务器是否为开放递归解析器
+// 只有权威服务器通常不设置 RA，或对非自身区域的查询返回 REFUSED
+func checkRecursion(c *client, server, domain string) (bool, error) {
+	resp, _, err := c.exchange(server, newQuery(domain, typeA))
+	if err != nil {
+		return false, fmt.Errorf("无法查询域名 %s: %w", domain, err)
+	}
+	if !resp.RecursionAvailable || resp.RCode == rcodeRefused {
+		return false, nil
+	}
+	return true, nil
+}
+
+// 返回递归能力对应的输出列值
+func recursionString(recursive bool) string {
+	if recursive {
+		return "open"
+	}
+	return "authoritative"
+}
@@ -0,0 +1,169 @@
+package dnsvalidator
+
+import (
+	htmltemplate "html/template"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+	"text/template"
+	"time"
+)
+
+// 延迟分布的分桶上限 (毫秒)
+var latencyBuckets = []float64{10, 25, 50, 100, 200, 500}
+
+// 延迟分布中的一个分桶
+type latencyBucket struct {
+	Label   string
+	Count   int
+	Percent int    // 占可用服务器的百分比
+	Bar     string // Markdown 中使用的文本柱
+}
+
+// 报告模板使用的数据
+type reportData struct {
+	Generated string
+	Tested    int
+	Valid     int
+	Failed    int
+	Failures  []categoryCount
+	Latency   []latencyBucket
+	Resolvers []*Result
+}
+
+// 汇总运行统计和最终输出的服务器
+func newReportData(stats *Stats, resolvers []*Result) *reportData {
+	stats.mu.Lock()
+	data := &reportData{
+		Generated: time.Now().Format("2006-01-02 15:04:05"),
+		Tested:    stats.tested,
+		Valid:     stats.valid,
+		Failed:    stats.tested - stats.valid,
+	}
+	stats.mu.Unlock()
+	data.Failures = stats.breakdown()
+	data.Resolvers = resolvers
+	data.Latency = latencyDistribution(resolvers)
+	return data
+}
+
+// 按 latencyBuckets 统计可用服务器的延迟分布
+func latencyDistribution(resolvers []*Result) []latencyBucket {
+	var dist []latencyBucket
+	counts := make([]int, len(latencyBuckets)+1)
+	for _, r := range resolvers {
+		i := 0
+		for i < len(latencyBuckets) && r.LatencyMs >= latencyBuckets[i] {
+			i++
+		}
+		counts[i]++
+	}
+	for i, count := range counts {
+		label := ""
+		switch {
+		case i == 0:
+			label = "< " + formatMS(latencyBuckets[0])
+		case i == len(latencyBuckets):
+			label = ">= " + formatMS(latencyBuckets[i-1])
+		default:
+			label = formatMS(latencyBuckets[i-1]) + "-" + formatMS(latencyBuckets[i])
+		}
+		bucket := latencyBucket{Label: label, Count: count}
+		if len(resolvers) > 0 {
+			bucket.Percent = count * 100 / len(resolvers)
+		}
+		bucket.Bar = strings.Repeat("█", bucket.Percent/2)
+		dist = append(dist, bucket)
+	}
+	return dist
+}
+
+func formatMS(ms float64) string {
+	return strconv.FormatFloat(ms, 'f', -1, 64) + "ms"
+}
+
+// 将报告按 html 或 md 格式写入文件
+func WriteReport(path, format string, stats *Stats, resolvers []*Result) error {
+	file, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+	return renderReport(file, format, newReportData(stats, resolvers))
+}
+
+// 按格式渲染报告
+func renderReport(w io.Writer, format string, data *reportData) error {
+	if format == "html" {
+		return htmltemplate.Must(htmltemplate.New("report").Parse(htmlReport)).Execute(w, data)
+	}
+	return template.Must(template.New("report").Parse(markdownReport)).Execute(w, data)
+}
+
+const markdownReport = `# DNS 服务器检查报告
+
+生成时间: {{.Generated}}
+
+## 汇总
+
+| 检查 | 可用 | 失败 |
+|---:|---:|---:|
+| {{.Tested}} | {{.Valid}} | {{.Failed}} |
+{{if .Failures}}
+## 失败原因
+
+| 类别 | 数量 |
+|---|---:|
+{{range .Failures}}| {{.Category}} | {{.Count}} |
+{{end}}{{end}}
+## 延迟分布
+
+` + "```" + `
+{{range .Latency}}{{printf "%-12s" .Label}} {{printf "%5d" .Count}} {{.Bar}}
+{{end}}` + "```" + `
+
+## 可用服务器
+
+| 服务器 | 传输协议 | 延迟 (ms) | 通过的检查 |
+|---|---|---:|---|
+{{range .Resolvers}}| {{.Server}} | {{.Transport}} | {{.LatencyMs}} | {{range $i, $c := .Checks}}{{if $i}}, {{end}}{{$c}}{{end}} |
+{{end}}`
+
+const htmlReport = `<!DOCTYPE html>
+<html lang="zh-CN">
+<head>
+<meta charset="utf-8">
+<title>DNS 服务器检查报告</title>
+<style>
+body { font-family: sans-serif; margin: 2em; }
+table { border-collapse: collapse; margin-bottom: 1.5em; }
+th, td { border: 1px solid #ccc; padding: 4px 10px; text-align: left; }
+.bar { background: #4a90d9; height: 1em; }
+</style>
+</head>
+<body>
+<h1>DNS 服务器检查报告</h1>
+<p>生成时间: {{.Generated}}</p>
+<h2>汇总</h2>
+<table>
+<tr><th>检查</th><th>可用</th><th>失败</th></tr>
+<tr><td>{{.Tested}}</td><td>{{.Valid}}</td><td>{{.Failed}}</td></tr>
+</table>
+{{if .Failures}}<h2>失败原因</h2>
+<table>
+<tr><th>类别</th><th>数量</th></tr>
+{{range .Failures}}<tr><td>{{.Category}}</td><td>{{.Count}}</td></tr>
+{{end}}</table>
+{{end}}<h2>延迟分布</h2>
+<table>
+{{range .Latency}}<tr><td>{{.Label}}</td><td>{{.Count}}</td><td style="width: 300px"><div class="bar" style="width: {{.Percent}}%"></div></td></tr>
+{{end}}</table>
+<h2>可用服务器</h2>
+<table>
+<tr><th>服务器</th><th>传输协议</th><th>延迟 (ms)</th><th>通过的检查</th></tr>
+{{range .Resolvers}}<tr><td>{{.Server}}</td><td>{{.Transport}}</td><td>{{.LatencyMs}}</td><td>{{range $i, $c := .Checks}}{{if $i}}, {{end}}{{$c}}{{end}}</td></tr>
+{{end}}</table>
+</body>
+</html>
+`
@@ -0,0 +1,190 @@
+package dnsvalidator
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"time"
+
+	bolt "go.etcd.io/bbolt"
+)
+
+// 历史记录数据库中保存服务器记录的 bucket，键为去重后的条目，值为 Reputation 的 JSON
+var reputationBucket = []byte("servers")
+
+// 每台服务器最多保留的最近延迟样本数
+const reputationSamples = 20
+
+// 跨运行保存的服务器历史记录，存放在 bbolt 数据库文件中，见 OpenReputation。
+// 设置 Options.Reputation 后每台完成检查的服务器都会更新记录，多个 Validator 可以共用同一个 ReputationStore
+type ReputationStore struct {
+	db *bolt.DB
+}
+
+// 单台服务器的历史记录
+type Reputation struct {
+	Server    string         `json:"server"`
+	FirstSeen time.Time      `json:"first_seen"`           // 第一次完成检查的时间
+	LastSeen  time.Time      `json:"last_seen"`            // 最近一次完成检查的时间
+	LastValid time.Time      `json:"last_valid,omitzero"`  // 最近一次通过检查的时间，从未通过时为零值
+	Checks    int            `json:"checks"`               // 完成检查的次数
+	Passes    int            `json:"passes"`               // 通过检查的次数
+	Failures  map[string]int `json:"failures,omitempty"`   // 各失败类别的次数
+	Latency   []float64      `json:"latency_ms,omitempty"` // 最近几次通过检查时的延迟 (毫秒)，最后一个为最近一次
+}
+
+// 通过检查的比例
+func (r *Reputation) SuccessRate() float64 {
+	if r.Checks == 0 {
+		return 0
+	}
+	return float64(r.Passes) / float64(r.Checks)
+}
+
+// 延迟趋势：较新一半样本的平均延迟减去较早一半的平均延迟 (毫秒)，为正表示变慢；样本少于 4 个时为 0
+func (r *Reputation) LatencyTrend() float64 {
+	n := len(r.Latency)
+	if n < 4 {
+		return 0
+	}
+	mean := func(s []float64) float64 {
+		var sum float64
+		for _, v := range s {
+			sum += v
+		}
+		return sum / float64(len(s))
+	}
+	return mean(r.Latency[n-n/2:]) - mean(r.Latency[:n/2])
+}
+
+// 判断服务器是否已经长期不可用：最近一次检查未通过，且距最近一次通过 (从未通过时为第一次检查) 已超过 after
+func (r *Reputation) dead(after time.Duration, now time.Time) bool {
+	if r.LastValid.Equal(r.LastSeen) && !r.LastValid.IsZero() {
+		return false
+	}
+	since := r.LastValid
+	if since.IsZero() {
+		since = r.FirstSeen
+	}
+	return now.Sub(since) > after
+}
+
+// 打开或创建历史记录数据库，文件被其他进程占用时等待 1 秒后返回错误
+func OpenReputation(path string) (*ReputationStore, error) {
+	db, err := bolt.Open(path, 0o644, &bolt.Options{Timeout: time.Second})
+	if err != nil {
+		return nil, fmt.Errorf("无法打开历史记录数据库 %s: %v", path, err)
+	}
+	err = db.Update(func(tx *bolt.Tx) error {
+		_, err := tx.CreateBucketIfNotExists(reputationBucket)
+		return err
+	})
+	if err != nil {
+		db.Close()
+		return nil, fmt.Errorf("无法初始化历史记录数据库 %s: %v", path, err)
+	}
+	return &ReputationStore{db: db}, nil
+}
+
+// 关闭数据库
+func (s *ReputationStore) Close() error {
+	return s.db.Close()
+}
+
+// 读取一台服务器的记录，没有记录时返回 nil
+func (s *ReputationStore) Get(server string) (*Reputation, error) {
+	var rep *Reputation
+	err := s.db.View(func(tx *bolt.Tx) error {
+		data := tx.Bucket(reputationBucket).Get([]byte(dedupKey(server)))
+		if data == nil {
+			return nil
+		}
+		rep = &Reputation{}
+		return json.Unmarshal(data, rep)
+	})
+	return rep, err
+}
+
+// 按条目顺序读取全部记录
+func (s *ReputationStore) All() ([]*Reputation, error) {
+	var all []*Reputation
+	err := s.db.View(func(tx *bolt.Tx) error {
+		return tx.Bucket(reputationBucket).ForEach(func(_, data []byte) error {
+			rep := &Reputation{}
+			if err := json.Unmarshal(data, rep); err != nil {
+				return err
+			}
+			all = append(all, rep)
+			return nil
+		})
+	})
+	return all, err
+}
+
+// 记录一次完成的检查，category 为空表示通过。并发的更新合并为一个事务写入，减少落盘次数；s 为空时不记录
+func (s *ReputationStore) record(server string, r *Result, category string) error {
+	if s == nil {
+		return nil
+	}
+	key := []byte(dedupKey(server))
+	now := time.Now()
+	return s.db.Batch(func(tx *bolt.Tx) error {
+		b := tx.Bucket(reputationBucket)
+		rep := &Reputation{Server: server, FirstSeen: now}
+		if data := b.Get(key); data != nil {
+			if err := json.Unmarshal(data, rep); err != nil {
+				return err
+			}
+		}
+		rep.LastSeen = now
+		rep.Checks++
+		if category == "" {
+			rep.Passes++
+			rep.LastValid = now
+			if r != nil && r.RTT > 0 {
+				rep.Latency = append(rep.Latency, float64(r.RTT.Microseconds())/1000)
+				if len(rep.Latency) > reputationSamples {
+					rep.Latency = rep.Latency[len(rep.Latency)-reputationSamples:]
+				}
+			}
+		} else {
+			if rep.Failures == nil {
+				rep.Failures = make(map[string]int)
+			}
+			rep.Failures[category]++
+		}
+		data, err := json.Marshal(rep)
+		if err != nil {
+			return err
+		}
+		return b.Put(key, data)
+	})
+}
+
+// 判断服务器是否长期不可用，after 为 0、s 为空或没有记录时返回 false
+func (s *ReputationStore) dead(server string, after time.Duration) bool {
+	if s == nil || after <= 0 {
+		return false
+	}
+	rep, err := s.Get(server)
+	if err != nil || rep == nil {
+		return false
+	}
+	return rep.dead(after, time.Now())
+}
+
+// 将记录写出为 JSON 数组，每条记录附带 success_rate 和 latency_trend_ms
+func WriteReputation(w io.Writer, reps []*Reputation) error {
+	type exported struct {
+		*Reputation
+		SuccessRate  float64 `json:"success_rate"`
+		LatencyTrend float64 `json:"latency_trend_ms"`
+	}
+	list := make([]exported, len(reps))
+	for i, rep := range reps {
+		list[i] = exported{Reputation: rep, SuccessRate: rep.SuccessRate(), LatencyTrend: rep.LatencyTrend()}
+	}
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(list)
+}
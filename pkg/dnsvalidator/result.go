@@ -0,0 +1,331 @@
+package dnsvalidator
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+	"text/template"
+	"time"
+)
+
+// 单台服务器的检查结果。Run 只送出通过检查的服务器，设置 Options.IncludeFailed 时
+// 未通过检查的服务器也会送出，Error 和 Category 记录失败原因
+type Result struct {
+	Server      string            `json:"server"`
+	IP          string            `json:"ip,omitempty"`
+	Port        string            `json:"port,omitempty"`
+	Transport   string            `json:"transport"`
+	RTT         time.Duration     `json:"-"` // 主检查域名 A 记录查询的往返时间，JSON 中为 latency_ms
+	LatencyMs   float64           `json:"latency_ms"`
+	Reliability float64           `json:"reliability"` // 检查域名查询的成功比例
+	Attempts    int               `json:"attempts"`    // 发送的查询总数，包括重试
+	Retries     int               `json:"retries"`     // 超时后重试的次数
+	RCode       string            `json:"rcode,omitempty"`
+	Answers     []string          `json:"answers,omitempty"`
+	Flags       []string          `json:"flags,omitempty"` // 主检查域名响应中设置的标志，如 qr、rd、ra、ad
+	Checks      []string          `json:"checks"`          // 通过的检查项
+	Details     map[string]string `json:"details,omitempty"`
+	Protocol    string            `json:"protocol,omitempty"`
+	Provider    string            `json:"provider,omitempty"`
+	Hostname    string            `json:"hostname,omitempty"`
+	Country     string            `json:"country,omitempty"`   // ISO 3166 国家代码，设置 Options.GeoIP 时填入
+	City        string            `json:"city,omitempty"`      // 城市的英文名称
+	Latitude    float64           `json:"latitude,omitempty"`  // 纬度
+	Longitude   float64           `json:"longitude,omitempty"` // 经度
+	ASN         uint              `json:"asn,omitempty"`       // 所属自治系统，设置 Options.ASN 时填入
+	ASOrg       string            `json:"as_org,omitempty"`    // 自治系统的组织名称
+	Name        string            `json:"name,omitempty"`      // public-dns.info 列表中记录的名称
+	PTR         string            `json:"ptr,omitempty"`       // IP 的反向解析结果，设置 Options.PTR 时填入
+
+	Censored map[string]string `json:"censored,omitempty"` // 审查探测中被审查的域名及方式: blocked、redirected 或 nxdomain
+
+	ListReliability float64   `json:"list_reliability,omitempty"` // public-dns.info 列表中记录的可靠性
+	Source          string    `json:"source,omitempty"`
+	Error           string    `json:"error,omitempty"`    // 未通过检查的原因
+	Category        string    `json:"category,omitempty"` // 未通过检查的失败类别
+	Timestamp       time.Time `json:"timestamp"`          // 完成检查的时间
+
+	attrs []string // 文本输出中的 key=value 属性列，保持检查顺序
+}
+
+// 解析 JSON 输出中的结果，由 latency_ms 恢复 RTT、由 details 恢复文本输出的属性列 (按名称排序)，
+// 使其他实例输出的结果可以重新排序并按任意格式输出
+func (r *Result) UnmarshalJSON(data []byte) error {
+	type plain Result
+	if err := json.Unmarshal(data, (*plain)(r)); err != nil {
+		return err
+	}
+	r.RTT = time.Duration(r.LatencyMs * float64(time.Millisecond))
+	r.attrs = r.attrs[:0]
+	keys := make([]string, 0, len(r.Details))
+	for key := range r.Details {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		r.attrs = append(r.attrs, key+"="+r.Details[key])
+	}
+	return nil
+}
+
+// 记录一项通过的检查及其属性列
+func (r *Result) pass(check string, attrs ...string) {
+	r.Checks = append(r.Checks, check)
+	r.attrs = append(r.attrs, attrs...)
+}
+
+// 记录服务器地址和主检查域名的查询结果
+func (r *Result) setLookup(addr string, l *lookup) {
+	if !isDoHURL(addr) {
+		r.IP, r.Port = splitServer(addr)
+	}
+	if l != nil {
+		r.RTT = l.rtt
+		r.LatencyMs = float64(l.rtt.Microseconds()) / 1000
+		r.Flags = l.flags
+		r.RCode = rcodeString(l.rcode)
+		r.Answers = l.answers
+	}
+}
+
+// 返回文本格式的输出行：服务器名称加空格分隔的属性列
+func (r *Result) line() string {
+	return strings.Join(append([]string{r.Server}, r.attrs...), " ")
+}
+
+// 将属性列转换为 JSON 中的 details 字段
+func (r *Result) fillDetails() {
+	if len(r.attrs) > 0 {
+		r.Details = r.Attributes()
+	}
+}
+
+// 文本输出中 key=value 属性列组成的映射，与 JSON 中的 details 字段相同；没有属性列时为空
+func (r *Result) Attributes() map[string]string {
+	if len(r.attrs) == 0 {
+		return r.Details
+	}
+	details := make(map[string]string, len(r.attrs))
+	for _, attr := range r.attrs {
+		key, value, _ := strings.Cut(attr, "=")
+		details[key] = value
+	}
+	return details
+}
+
+// CSV 输出的默认列
+var DefaultFields = []string{"server", "ip", "port", "transport", "latency_ms", "rcode", "answers", "checks"}
+
+// 返回指定列的值，基本字段之外的列取自各项检查的属性，如 dnssec、edns_size
+func (r *Result) field(name string) string {
+	switch name {
+	case "server":
+		return r.Server
+	case "ip":
+		return r.IP
+	case "port":
+		return r.Port
+	case "transport":
+		return r.Transport
+	case "rtt":
+		return r.RTT.String()
+	case "latency_ms":
+		return strconv.FormatFloat(r.LatencyMs, 'f', -1, 64)
+	case "reliability":
+		return strconv.FormatFloat(r.Reliability, 'f', -1, 64)
+	case "attempts":
+		return strconv.Itoa(r.Attempts)
+	case "retries":
+		return strconv.Itoa(r.Retries)
+	case "rcode":
+		return r.RCode
+	case "answers":
+		return strings.Join(r.Answers, ";")
+	case "flags":
+		return strings.Join(r.Flags, ";")
+	case "checks":
+		return strings.Join(r.Checks, ";")
+	case "protocol":
+		return r.Protocol
+	case "provider":
+		return r.Provider
+	case "hostname":
+		return r.Hostname
+	case "country":
+		return r.Country
+	case "city":
+		return r.City
+	case "latitude", "longitude":
+		// 数据库中没有坐标时为空
+		if r.Latitude == 0 && r.Longitude == 0 {
+			return ""
+		}
+		if name == "latitude" {
+			return strconv.FormatFloat(r.Latitude, 'f', -1, 64)
+		}
+		return strconv.FormatFloat(r.Longitude, 'f', -1, 64)
+	case "asn":
+		if r.ASN == 0 {
+			return ""
+		}
+		return strconv.FormatUint(uint64(r.ASN), 10)
+	case "as_org":
+		return r.ASOrg
+	case "name":
+		return r.Name
+	case "ptr":
+		return r.PTR
+	case "list_reliability":
+		if r.ListReliability == 0 {
+			return ""
+		}
+		return strconv.FormatFloat(r.ListReliability, 'f', -1, 64)
+	case "source":
+		return r.Source
+	case "error":
+		return r.Error
+	case "category":
+		return r.Category
+	case "timestamp":
+		if r.Timestamp.IsZero() {
+			return ""
+		}
+		return r.Timestamp.Format(time.RFC3339)
+	}
+	for _, attr := range r.attrs {
+		if key, value, _ := strings.Cut(attr, "="); key == name {
+			return value
+		}
+	}
+	return ""
+}
+
+// 按指定格式写出检查结果
+type Writer struct {
+	w      io.Writer
+	format string   // text、json、jsonl、csv、template 或配置片段格式
+	fields []string // CSV 输出的列
+	csv    *csv.Writer
+	tls    bool            // unbound 配置是否使用 TLS 转发
+	names  map[string]bool // dnscrypt 列表中已使用的名称
+	tmpl   *template.Template
+	count  int
+}
+
+// 创建按 format 写出结果的 Writer；fields 为 CSV 输出的列，为空时使用 DefaultFields；
+// tmpl 为 template 格式使用的模板，见 ParseOutputTemplate
+func NewWriter(w io.Writer, format string, fields []string, tmpl *template.Template) *Writer {
+	if len(fields) == 0 {
+		fields = DefaultFields
+	}
+	return &Writer{w: w, format: format, fields: fields, tmpl: tmpl}
+}
+
+// 返回已写出的结果数
+func (rw *Writer) Count() int {
+	return rw.count
+}
+
+// 解析 -template 指定的输出模板，并用空结果试执行以尽早发现不存在的字段
+// 模板中的 \t 和 \n 会被替换为制表符和换行
+func ParseOutputTemplate(text string) (*template.Template, error) {
+	text = strings.NewReplacer(`\t`, "\t", `\n`, "\n").Replace(text)
+	tmpl, err := template.New("output").Funcs(template.FuncMap{"join": strings.Join}).Parse(text)
+	if err != nil {
+		return nil, err
+	}
+	if err := tmpl.Execute(io.Discard, &Result{}); err != nil {
+		return nil, err
+	}
+	return tmpl, nil
+}
+
+// 检查输出格式是否受支持
+func ValidFormat(format string) bool {
+	switch format {
+	case "text", "json", "jsonl", "csv", "template":
+		return true
+	}
+	return IsConfigFormat(format)
+}
+
+// 写出一条结果
+func (rw *Writer) Write(r *Result) error {
+	if IsConfigFormat(rw.format) {
+		return rw.writeConfig(r)
+	}
+	defer func() { rw.count++ }()
+	switch rw.format {
+	case "template":
+		r.fillDetails()
+		var b strings.Builder
+		if err := rw.tmpl.Execute(&b, r); err != nil {
+			return err
+		}
+		line := b.String()
+		if !strings.HasSuffix(line, "\n") {
+			line += "\n"
+		}
+		_, err := io.WriteString(rw.w, line)
+		return err
+	case "csv":
+		if rw.csv == nil {
+			rw.csv = csv.NewWriter(rw.w)
+			if err := rw.csv.Write(rw.fields); err != nil {
+				return err
+			}
+		}
+		record := make([]string, len(rw.fields))
+		for i, name := range rw.fields {
+			record[i] = r.field(name)
+		}
+		if err := rw.csv.Write(record); err != nil {
+			return err
+		}
+		rw.csv.Flush()
+		return rw.csv.Error()
+	case "json", "jsonl":
+		r.fillDetails()
+		data, err := json.Marshal(r)
+		if err != nil {
+			return err
+		}
+		prefix := ""
+		if rw.format == "json" {
+			prefix = ",\n  "
+			if rw.count == 0 {
+				prefix = "[\n  "
+			}
+		}
+		_, err = fmt.Fprint(rw.w, prefix+string(data))
+		if err == nil && rw.format == "jsonl" {
+			_, err = fmt.Fprintln(rw.w)
+		}
+		return err
+	}
+	_, err := fmt.Fprintln(rw.w, r.line())
+	return err
+}
+
+// 结束输出，JSON 格式需要闭合数组，CSV 在没有结果时也写出表头
+func (rw *Writer) Close() error {
+	if rw.format == "csv" && rw.csv == nil {
+		rw.csv = csv.NewWriter(rw.w)
+		rw.csv.Write(rw.fields)
+		rw.csv.Flush()
+		return rw.csv.Error()
+	}
+	if rw.format != "json" {
+		return nil
+	}
+	if rw.count == 0 {
+		_, err := fmt.Fprintln(rw.w, "[]")
+		return err
+	}
+	_, err := fmt.Fprint(rw.w, "\n]\n")
+	return err
+}
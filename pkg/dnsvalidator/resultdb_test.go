@@ -0,0 +1,145 @@
+package dnsvalidator
+
+import (
+	"database/sql"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// 返回 SQLite 结果数据库的表结构版本和 results 表的列
+func sqliteSchema(t *testing.T, path string) (int, map[string]bool) {
+	t.Helper()
+	db, err := sql.Open("sqlite", path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	var version int
+	if err := db.QueryRow("SELECT COALESCE(MAX(version), 0) FROM " + schemaTable).Scan(&version); err != nil {
+		t.Fatal(err)
+	}
+	rows, err := db.Query("SELECT name FROM pragma_table_info('results')")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer rows.Close()
+	columns := make(map[string]bool)
+	for rows.Next() {
+		var name string
+		rows.Scan(&name)
+		columns[name] = true
+	}
+	return version, columns
+}
+
+func TestResultDBMigrations(t *testing.T) {
+	latest := len(findDialect("sqlite://").migrations)
+	tests := []struct {
+		name  string
+		setup []string // 打开前执行的语句，模拟旧版本建立的数据库
+	}{
+		{"新数据库", nil},
+		// 第 1 版之前没有版本表
+		{"没有版本表的第 1 版", []string{
+			`CREATE TABLE results (` + resultColumnDefs("TEXT", "TEXT", "REAL", "INTEGER", "TIMESTAMP") + `)`,
+			`CREATE INDEX results_run_id ON results (run_id)`,
+			`INSERT INTO results (run_id, server, timestamp) VALUES ('old', '8.8.8.8', '2024-01-01 00:00:00')`,
+		}},
+		{"已记录第 1 版", []string{
+			`CREATE TABLE results (` + resultColumnDefs("TEXT", "TEXT", "REAL", "INTEGER", "TIMESTAMP") + `)`,
+			`CREATE TABLE ` + schemaTable + ` (version INTEGER NOT NULL)`,
+			`INSERT INTO ` + schemaTable + ` (version) VALUES (1)`,
+		}},
+	}
+	for _, tt := range tests {
+		path := filepath.Join(t.TempDir(), "results.db")
+		if len(tt.setup) > 0 {
+			db, err := sql.Open("sqlite", path)
+			if err != nil {
+				t.Fatal(err)
+			}
+			for _, stmt := range tt.setup {
+				if _, err := db.Exec(stmt); err != nil {
+					t.Fatalf("%s: %v", tt.name, err)
+				}
+			}
+			db.Close()
+		}
+
+		// 重复打开不会重复升级
+		for i := 0; i < 2; i++ {
+			rdb, err := OpenResultDB("sqlite://" + path)
+			if err != nil {
+				t.Fatalf("%s: 第 %d 次打开: %v", tt.name, i+1, err)
+			}
+			rdb.SetProbe("probe-a")
+			run, err := rdb.Begin(NewRunID(time.Now()))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if err := run.Write(&Result{Server: "1.1.1.1", IP: "1.1.1.1", Port: "53", Timestamp: time.Now()}); err != nil {
+				t.Fatalf("%s: %v", tt.name, err)
+			}
+			run.Close()
+			if err := run.Commit(); err != nil {
+				t.Fatal(err)
+			}
+			rdb.Close()
+		}
+
+		version, columns := sqliteSchema(t, path)
+		if version != latest {
+			t.Errorf("%s: 表结构版本为 %d，应为 %d", tt.name, version, latest)
+		}
+		for _, col := range insertColumns {
+			if !columns[col] {
+				t.Errorf("%s: results 表缺少 %s 列", tt.name, col)
+			}
+		}
+		db, _ := sql.Open("sqlite", path)
+		var versions, probes int
+		db.QueryRow("SELECT COUNT(*) FROM " + schemaTable).Scan(&versions)
+		db.QueryRow("SELECT COUNT(*) FROM results WHERE probe = 'probe-a'").Scan(&probes)
+		db.Close()
+		if versions != latest {
+			t.Errorf("%s: 版本表有 %d 行，应为 %d 行", tt.name, versions, latest)
+		}
+		if probes != 2 {
+			t.Errorf("%s: 写入了 %d 行带 probe 的结果，应为 2 行", tt.name, probes)
+		}
+	}
+}
+
+// Rollback 丢弃本次运行写入的全部结果
+func TestResultDBRollback(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "results.db")
+	rdb, err := OpenResultDB("sqlite://" + path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer rdb.Close()
+	run, err := rdb.Begin("r1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, server := range []string{"8.8.8.8", "1.1.1.1"} {
+		if err := run.Write(&Result{Server: server, Timestamp: time.Now()}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if run.Count() != 2 {
+		t.Errorf("写入了 %d 个结果，应为 2 个", run.Count())
+	}
+	run.Close()
+	if err := run.Rollback(); err != nil {
+		t.Fatal(err)
+	}
+	var n int
+	if err := rdb.db.QueryRow("SELECT COUNT(*) FROM results").Scan(&n); err != nil {
+		t.Fatal(err)
+	}
+	if n != 0 {
+		t.Errorf("回滚后 results 表有 %d 行，应为 0 行", n)
+	}
+}
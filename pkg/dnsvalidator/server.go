@@ -0,0 +1,29 @@
+package dnsvalidator
+
+import (
+	"net"
+	"strings"
+)
+
+// 拆分条目中的主机和端口，支持 ip、ip:port、ipv6 和 [ipv6]:port 形式，未指定端口时返回空端口
+func splitServer(entry string) (host, port string) {
+	if h, p, err := net.SplitHostPort(entry); err == nil {
+		return h, p
+	}
+	return strings.Trim(entry, "[]"), ""
+}
+
+// 返回输出中使用的服务器名称，带有非默认端口时保留端口
+func serverName(entry, defaultPort string) string {
+	if isDoHURL(entry) {
+		return entry
+	}
+	host, port := splitServer(entry)
+	if port == "" {
+		port = defaultPort
+	}
+	if port == "" {
+		return host
+	}
+	return net.JoinHostPort(host, port)
+}
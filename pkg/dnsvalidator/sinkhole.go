@@ -0,0 +1,42 @@
+package dnsvalidator
+
+import (
+	"fmt"
+	"net"
+)
+
+// 过滤型解析器拦截域名时常用的应答地址
+var defaultSinkholes = []string{"0.0.0.0", "127.0.0.1", "::", "::1"}
+
+// 由默认地址和 extra 组成拦截地址集合，地址统一为 net.IP 的字符串形式
+func sinkholeSet(extra []string) (map[string]bool, error) {
+	set := make(map[string]bool, len(defaultSinkholes)+len(extra))
+	for _, s := range append(append([]string(nil), defaultSinkholes...), extra...) {
+		ip := net.ParseIP(s)
+		if ip == nil {
+			return nil, fmt.Errorf("无效的拦截地址 %q", s)
+		}
+		set[ip.String()] = true
+	}
+	return set, nil
+}
+
+// 检查答案中是否有拦截地址，有则返回 sinkhole 类别的失败，错误中记录该地址
+func checkSinkhole(domain string, answers []string, sinkholes map[string]bool) error {
+	for _, answer := range answers {
+		if ip := net.ParseIP(answer); ip != nil && sinkholes[ip.String()] {
+			return newFailure(failSinkhole, "域名 %s 被解析到拦截地址 %s，服务器在过滤或拦截域名", domain, ip)
+		}
+	}
+	return nil
+}
+
+// 检查答案是否落在保留或私有地址段，公网检查域名不应解析到这些地址，多见于篡改应答的中间设备
+func checkBogonAnswer(domain string, answers []string) error {
+	for _, answer := range answers {
+		if containsIP(bogonNetworks, answer) || containsIP(privateNetworks, answer) {
+			return newFailure(failBogon, "域名 %s 被解析到保留或私有地址 %s", domain, answer)
+		}
+	}
+	return nil
+}
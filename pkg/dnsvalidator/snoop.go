@@ -0,0 +1,31 @@
+package dnsvalidator
+
+import (
+	"fmt"
+	"strconv"
+)
+
+// 缓存探测默认查询的热门域名，几乎所有被使用的解析器都会缓存
+var defaultSnoopDomains = []string{"google.com", "facebook.com", "youtube.com", "amazon.com", "wikipedia.org"}
+
+// 以 RD=0 查询热门域名，服务器不做递归，非权威的应答只可能来自缓存。
+// 返回 cache_snoop=true/false 及命中数；全部查询失败时不输出
+func checkCacheSnoop(c *client, server string, domains []string) []string {
+	hits, answered := 0, 0
+	for _, domain := range domains {
+		query := newQuery(domain, typeA)
+		query.RecursionDesired = false
+		resp, _, err := c.exchange(server, query)
+		if err != nil {
+			continue
+		}
+		answered++
+		if resp.RCode == rcodeSuccess && !resp.Authoritative && len(resp.answerValues(typeA)) > 0 {
+			hits++
+		}
+	}
+	if answered == 0 {
+		return nil
+	}
+	return []string{"cache_snoop=" + strconv.FormatBool(hits > 0), fmt.Sprintf("cache_hits=%d/%d", hits, len(domains))}
+}
@@ -0,0 +1,220 @@
+package dnsvalidator
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+)
+
+// 待检查的服务器条目及其来源
+type candidate struct {
+	server   string
+	source   string // 来源文件、URL 或 stdin，多个来源用逗号分隔
+	hostname string // 条目原本是主机名时，记录该主机名
+
+	// 以下字段来自 DNS Stamp
+	protocol  string            // Stamp 中的协议
+	provider  string            // 提供者名称或主机名
+	transport string            // 覆盖 -transport 的传输协议
+	tlsName   string            // DoT 握手时使用的服务器名称
+	pin       *stampPin         // DoH/DoT 连接的地址和固定的证书哈希
+	crypt     *dnscryptProvider // DNSCrypt 提供者名称和公钥
+
+	meta *listMeta // 来自 public-dns.info CSV 或 JSON 导出的信息，为空表示普通列表
+}
+
+// 打开 DNS 服务器列表文件，按扩展名或文件头自动解压，size 记录读取的字节数
+func openDNSFile(path string, size *inputSize) (io.ReadCloser, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("无法打开文件 %s: %v", path, err)
+	}
+	r, err := decompressReader(size.track(file, readerSize(file)), path, "")
+	if err != nil {
+		file.Close()
+		return nil, err
+	}
+	return &multiCloser{Reader: r, closers: []io.Closer{r, file}}, nil
+}
+
+// 关闭时依次关闭解压器和底层文件或响应体
+type multiCloser struct {
+	io.Reader
+	closers []io.Closer
+}
+
+func (m *multiCloser) Close() error {
+	var first error
+	for _, c := range m.closers {
+		if err := c.Close(); err != nil && first == nil {
+			first = err
+		}
+	}
+	return first
+}
+
+// 并发打开所有文件和 URL，任一来源无法打开时立即返回错误；
+// 之后并发地逐行读取，将条目写入返回的通道，不在内存中保存整个列表；size 为空时不统计读取进度。
+// 通道关闭后调用 wait 获取读取过程中的错误
+func streamSources(ctx context.Context, srcs Sources, size *inputSize) (<-chan candidate, func() error, error) {
+	type source struct {
+		name string
+		r    io.ReadCloser
+		err  error
+	}
+
+	var sources []*source
+	var wg sync.WaitGroup
+	open := func(name string, openFn func() (io.ReadCloser, error)) {
+		src := &source{name: name}
+		sources = append(sources, src)
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			src.r, src.err = openFn()
+		}()
+	}
+
+	if len(srcs.Servers) > 0 {
+		open("list", func() (io.ReadCloser, error) {
+			list := strings.Join(srcs.Servers, "\n")
+			return io.NopCloser(size.track(strings.NewReader(list), int64(len(list)))), nil
+		})
+	}
+	if srcs.Stdin != nil {
+		open("stdin", func() (io.ReadCloser, error) {
+			return decompressReader(size.track(srcs.Stdin, readerSize(srcs.Stdin)), "stdin", "")
+		})
+	}
+	for _, path := range srcs.Files {
+		path := path
+		open(path, func() (io.ReadCloser, error) { return openDNSFile(path, size) })
+	}
+	for _, url := range srcs.URLs {
+		url := url
+		open(url, func() (io.ReadCloser, error) { return openDNSList(ctx, url, size) })
+	}
+	wg.Wait()
+
+	for _, src := range sources {
+		if src.err != nil {
+			for _, other := range sources {
+				if other.r != nil {
+					other.r.Close()
+				}
+			}
+			return nil, nil, src.err
+		}
+	}
+
+	out := make(chan candidate, 1024)
+	var mu sync.Mutex
+	var readErr error
+	for _, src := range sources {
+		wg.Add(1)
+		go func(src *source) {
+			defer wg.Done()
+			defer src.r.Close()
+			err := scanDNSList(src.r, func(server string, meta *listMeta) {
+				if size != nil {
+					size.entries.Add(1)
+				}
+				out <- candidate{server: server, source: src.name, meta: meta}
+			})
+			if err != nil {
+				mu.Lock()
+				if readErr == nil {
+					readErr = fmt.Errorf("读取 %s 时出错: %v", src.name, err)
+				}
+				mu.Unlock()
+			}
+		}(src)
+	}
+	go func() {
+		wg.Wait()
+		close(out)
+	}()
+
+	wait := func() error {
+		mu.Lock()
+		defer mu.Unlock()
+		return readErr
+	}
+	return out, wait, nil
+}
+
+// 读取所有来源中的条目，每个条目调用一次 fn，source 为来源文件、URL、list 或 stdin。
+// 条目只做去除注释和空白的处理，不展开网段也不解析主机名
+func ReadSources(ctx context.Context, srcs Sources, fn func(entry, source string)) error {
+	entries, wait, err := streamSources(ctx, srcs, nil)
+	if err != nil {
+		return err
+	}
+	for c := range entries {
+		fn(c.server, c.source)
+	}
+	return wait()
+}
+
+// 从指定的URL下载DNS服务器列表，返回的响应体在读取时逐步下载，size 记录读取的字节数
+func openDNSList(ctx context.Context, url string, size *inputSize) (io.ReadCloser, error) {
+	// 发起GET请求，声明支持压缩传输
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, fmt.Errorf("无法从 %s 下载 DNS 服务器列表: %v", url, err)
+	}
+	req.Header.Set("Accept-Encoding", "gzip, zstd")
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("无法从 %s 下载 DNS 服务器列表: %v", url, err)
+	}
+	// 错误页面不能当作服务器列表解析
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		resp.Body.Close()
+		return nil, fmt.Errorf("无法从 %s 下载 DNS 服务器列表: HTTP 状态码 %d", url, resp.StatusCode)
+	}
+
+	// 按 Content-Encoding 或文件名解压响应体
+	body, err := decompressReader(size.track(resp.Body, resp.ContentLength), url, resp.Header.Get("Content-Encoding"))
+	if err != nil {
+		resp.Body.Close()
+		return nil, err
+	}
+	return &multiCloser{Reader: body, closers: []io.Closer{body, resp.Body}}, nil
+}
+
+// 逐行读取 DNS 服务器列表，每读到一个条目调用一次 emit；
+// public-dns.info 的 CSV 和 JSON 导出按内容自动识别，meta 为其中记录的信息
+func scanDNSList(r io.Reader, emit func(string, *listMeta)) error {
+	br := bufio.NewReader(r)
+	switch listFormat(br) {
+	case "json":
+		return scanPublicDNSJSON(br, emit)
+	case "csv":
+		return scanPublicDNSCSV(br, emit)
+	}
+	scanner := bufio.NewScanner(br)
+	for scanner.Scan() {
+		if line := normalizeLine(scanner.Text()); line != "" {
+			emit(line, nil)
+		}
+	}
+	return scanner.Err()
+}
+
+// 按逗号拆分列表并去除空白项
+func SplitList(s string) []string {
+	var items []string
+	for _, item := range strings.Split(s, ",") {
+		item = strings.TrimSpace(item)
+		if item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
@@ -0,0 +1,310 @@
+package dnsvalidator
+
+import (
+	"bytes"
+	"context"
+	"crypto/ed25519"
+	"crypto/sha256"
+	"crypto/tls"
+	"encoding/base64"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+// DNS Stamp 协议编号
+const (
+	stampPlain    byte = 0x00
+	stampDNSCrypt byte = 0x01
+	stampDoH      byte = 0x02
+	stampDoT      byte = 0x03
+	stampDoQ      byte = 0x04
+	stampODoH     byte = 0x05
+	stampRelay    byte = 0x81
+	stampODoHRely byte = 0x85
+)
+
+// 协议名称
+var stampProtocolNames = map[byte]string{
+	stampPlain:    "do53",
+	stampDNSCrypt: "dnscrypt",
+	stampDoH:      "doh",
+	stampDoT:      "dot",
+	stampDoQ:      "doq",
+	stampODoH:     "odoh",
+	stampRelay:    "dnscrypt-relay",
+	stampODoHRely: "odoh-relay",
+}
+
+var errBadStamp = errors.New("无效的 DNS Stamp")
+
+// 解码后的 DNS Stamp
+type dnsStamp struct {
+	Protocol     byte
+	Props        uint64
+	Address      string   // IP 或 IP:端口，可能为空
+	Hostname     string   // DoH/DoT 的主机名，或 DNSCrypt 的提供者名称
+	Path         string   // DoH 路径
+	PublicKey    []byte   // DNSCrypt 提供者公钥
+	Hashes       [][]byte // 证书链中的 TBS 证书哈希
+	Bootstrap    []string // 引导服务器
+	ProviderName string
+}
+
+// 判断条目是否为 DNS Stamp
+func isStamp(entry string) bool {
+	return strings.HasPrefix(entry, "sdns://")
+}
+
+// 解码 sdns:// 格式的 DNS Stamp
+func parseStamp(s string) (*dnsStamp, error) {
+	data, err := base64.RawURLEncoding.DecodeString(strings.TrimPrefix(s, "sdns://"))
+	if err != nil || len(data) < 1 {
+		return nil, errBadStamp
+	}
+	st := &dnsStamp{Protocol: data[0]}
+	r := &stampReader{data: data[1:]}
+
+	// DNSCrypt 中继的 Stamp 没有属性字段，ODoH 中继有
+	if st.Protocol != stampRelay {
+		if len(r.data) < 8 {
+			return nil, errBadStamp
+		}
+		st.Props = binary.LittleEndian.Uint64(r.data)
+		r.data = r.data[8:]
+	}
+
+	switch st.Protocol {
+	case stampPlain, stampRelay:
+		st.Address = string(r.lp())
+	case stampDNSCrypt:
+		st.Address = string(r.lp())
+		st.PublicKey = r.lp()
+		st.ProviderName = string(r.lp())
+		st.Hostname = st.ProviderName
+	case stampDoH, stampODoH, stampODoHRely:
+		if st.Protocol != stampODoH {
+			st.Address = string(r.lp())
+			st.Hashes = r.vlp()
+		}
+		st.Hostname = string(r.lp())
+		st.Path = string(r.lp())
+		for _, b := range r.vlp() {
+			st.Bootstrap = append(st.Bootstrap, string(b))
+		}
+	case stampDoT, stampDoQ:
+		st.Address = string(r.lp())
+		st.Hashes = r.vlp()
+		st.Hostname = string(r.lp())
+		for _, b := range r.vlp() {
+			st.Bootstrap = append(st.Bootstrap, string(b))
+		}
+	default:
+		return nil, fmt.Errorf("不支持的 DNS Stamp 协议 0x%02x", st.Protocol)
+	}
+	if r.err {
+		return nil, errBadStamp
+	}
+	return st, nil
+}
+
+// Stamp 属性位
+const (
+	stampPropDNSSEC   uint64 = 1 << 0
+	stampPropNoLog    uint64 = 1 << 1
+	stampPropNoFilter uint64 = 1 << 2
+)
+
+// 将 DoH、DoT 或明文 DNS 的 Stamp 编码为 sdns:// 格式
+func (st *dnsStamp) String() string {
+	data := []byte{st.Protocol}
+	data = binary.LittleEndian.AppendUint64(data, st.Props)
+	lp := func(s string) {
+		data = append(data, byte(len(s)))
+		data = append(data, s...)
+	}
+	switch st.Protocol {
+	case stampPlain:
+		lp(st.Address)
+	case stampDoH, stampDoT:
+		lp(st.Address)
+		data = append(data, 0) // 不固定证书哈希
+		lp(st.Hostname)
+		if st.Protocol == stampDoH {
+			lp(st.Path)
+		}
+	}
+	return "sdns://" + base64.RawURLEncoding.EncodeToString(data)
+}
+
+// 按长度前缀读取 Stamp 字段
+type stampReader struct {
+	data []byte
+	err  bool
+}
+
+// 读取一个单字节长度前缀的字段，数据已读完时返回空
+func (r *stampReader) lp() []byte {
+	if len(r.data) == 0 {
+		return nil
+	}
+	l := int(r.data[0])
+	if 1+l > len(r.data) {
+		r.err = true
+		r.data = nil
+		return nil
+	}
+	b := r.data[1 : 1+l]
+	r.data = r.data[1+l:]
+	return b
+}
+
+// 读取可变个数的字段，长度字节最高位为 1 表示后面还有字段
+func (r *stampReader) vlp() [][]byte {
+	var items [][]byte
+	for len(r.data) > 0 {
+		more := r.data[0]&0x80 != 0
+		l := int(r.data[0] & 0x7f)
+		if 1+l > len(r.data) {
+			r.err = true
+			r.data = nil
+			return items
+		}
+		if l > 0 {
+			items = append(items, r.data[1:1+l])
+		}
+		r.data = r.data[1+l:]
+		if !more {
+			break
+		}
+	}
+	return items
+}
+
+// 将 DNS Stamp 条目转换为对应协议的检查条目，其他条目原样返回；
+// 无法解析或协议不受支持的 Stamp 会被拒绝，此时返回 false
+func decodeStamp(cand candidate, rejects *rejectLog) (candidate, bool) {
+	if !isStamp(cand.server) {
+		return cand, true
+	}
+	st, err := parseStamp(cand.server)
+	if err != nil {
+		rejects.log.printf("跳过无法解析的 DNS Stamp %s: %v\n", cand.server, err)
+		rejects.reject(cand, "无效的 DNS Stamp")
+		return cand, false
+	}
+	cand.protocol = stampProtocolNames[st.Protocol]
+	cand.provider = st.Hostname
+
+	switch st.Protocol {
+	case stampPlain:
+		cand.server = st.Address
+	case stampDoT:
+		cand.transport = "dot"
+		cand.tlsName = hostOnly(st.Hostname)
+		cand.server = st.Address
+		if cand.server == "" {
+			cand.server = st.Hostname
+		}
+		cand.server = withDefaultPort(cand.server, "853")
+		cand.pin = &stampPin{hashes: st.Hashes}
+	case stampDoH:
+		// URL 中的主机名用于 SNI、证书校验和 Host 头，Stamp 给出地址时直接连接该地址
+		cand.server = "https://" + st.Hostname + st.Path
+		cand.pin = &stampPin{hashes: st.Hashes}
+		if st.Address != "" {
+			cand.pin.addr = withDefaultPort(st.Address, "443")
+		}
+	case stampDNSCrypt:
+		if len(st.PublicKey) != ed25519.PublicKeySize || st.ProviderName == "" || st.Address == "" {
+			rejects.log.printf("跳过 DNS Stamp %s: 缺少 DNSCrypt 提供者公钥、名称或地址\n", cand.server)
+			rejects.reject(cand, "无效的 DNS Stamp")
+			return cand, false
+		}
+		cand.transport = "dnscrypt"
+		cand.crypt = &dnscryptProvider{name: st.ProviderName, key: st.PublicKey}
+		cand.server = withDefaultPort(st.Address, "443")
+	default:
+		rejects.log.printf("跳过 DNS Stamp %s: 暂不支持 %s 协议\n", cand.server, cand.protocol)
+		rejects.reject(cand, "不支持的 DNS Stamp 协议 "+cand.protocol)
+		return cand, false
+	}
+	return cand, true
+}
+
+// 来自 DoH 或 DoT Stamp 的连接参数
+type stampPin struct {
+	addr   string   // 实际连接的 IP:端口，为空时解析 URL 中的主机名
+	hashes [][]byte // 证书链中 TBS 证书的 SHA-256 哈希，为空表示不固定证书
+
+	once      sync.Once
+	transport *http.Transport
+}
+
+// 检查握手得到的证书链中至少有一个证书与 Stamp 固定的哈希相符
+func (p *stampPin) verify(state tls.ConnectionState) error {
+	if p == nil || len(p.hashes) == 0 {
+		return nil
+	}
+	for _, cert := range state.PeerCertificates {
+		sum := sha256.Sum256(cert.RawTBSCertificate)
+		for _, h := range p.hashes {
+			if bytes.Equal(sum[:], h) {
+				return nil
+			}
+		}
+	}
+	return newFailure(failTLS, "证书链与 DNS Stamp 固定的证书哈希不符")
+}
+
+// 返回连接 Stamp 地址并校验固定哈希的 HTTP Transport，同一个服务器的所有 DoH 查询共用
+func (p *stampPin) dohTransport(n *netState, queryTimeout time.Duration) http.RoundTripper {
+	if p == nil || p.addr == "" && len(p.hashes) == 0 {
+		return n.dohTransport(queryTimeout)
+	}
+	p.once.Do(func() {
+		t := http.DefaultTransport.(*http.Transport).Clone()
+		dialer := n.dialer(queryTimeout)
+		t.DialContext = func(ctx context.Context, network, addr string) (net.Conn, error) {
+			if p.addr != "" {
+				addr = p.addr
+			}
+			return dialer.DialContext(ctx, network, addr)
+		}
+		t.TLSHandshakeTimeout = n.dialTimeout(queryTimeout)
+		t.TLSClientConfig = &tls.Config{VerifyConnection: p.verify}
+		p.transport = t
+	})
+	return p.transport
+}
+
+// 关闭 dohTransport 留下的空闲连接
+func (p *stampPin) close() {
+	if p == nil {
+		return
+	}
+	p.once.Do(func() {})
+	if p.transport != nil {
+		p.transport.CloseIdleConnections()
+	}
+}
+
+// 去掉 host:port 中的端口
+func hostOnly(s string) string {
+	host, _ := splitServer(s)
+	return host
+}
+
+// 条目未指定端口时补上默认端口
+func withDefaultPort(entry, port string) string {
+	host, p := splitServer(entry)
+	if p != "" {
+		return entry
+	}
+	return net.JoinHostPort(host, port)
+}
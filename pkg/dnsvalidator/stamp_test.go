@@ -0,0 +1,121 @@
+package dnsvalidator
+
+import (
+	"crypto/sha256"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/binary"
+	"net/http/httptest"
+	"testing"
+)
+
+// 按协议编号、属性和各字段编码一个 Stamp，字段为 nil 时写入单字节 0
+func testStamp(protocol byte, props bool, fields ...[]byte) string {
+	data := []byte{protocol}
+	if props {
+		data = binary.LittleEndian.AppendUint64(data, stampPropDNSSEC)
+	}
+	for _, f := range fields {
+		data = append(append(data, byte(len(f))), f...)
+	}
+	return "sdns://" + base64.RawURLEncoding.EncodeToString(data)
+}
+
+func TestParseStamp(t *testing.T) {
+	hash := make([]byte, 32)
+	tests := []struct {
+		name  string
+		stamp string
+		want  dnsStamp
+	}{
+		{"明文 DNS", testStamp(stampPlain, true, []byte("9.9.9.9")), dnsStamp{Protocol: stampPlain, Props: stampPropDNSSEC, Address: "9.9.9.9"}},
+		{"DoH", testStamp(stampDoH, true, []byte("1.1.1.1"), hash, []byte("cloudflare-dns.com"), []byte("/dns-query")),
+			dnsStamp{Protocol: stampDoH, Props: stampPropDNSSEC, Address: "1.1.1.1", Hostname: "cloudflare-dns.com", Path: "/dns-query"}},
+		{"DNSCrypt 中继没有属性", testStamp(stampRelay, false, []byte("192.0.2.1:443")), dnsStamp{Protocol: stampRelay, Address: "192.0.2.1:443"}},
+		{"ODoH 中继带属性", testStamp(stampODoHRely, true, []byte("192.0.2.2"), nil, []byte("relay.example.com"), []byte("/proxy")),
+			dnsStamp{Protocol: stampODoHRely, Props: stampPropDNSSEC, Address: "192.0.2.2", Hostname: "relay.example.com", Path: "/proxy"}},
+		{"ODoH 目标没有地址", testStamp(stampODoH, true, []byte("odoh.example.com"), []byte("/dns-query")),
+			dnsStamp{Protocol: stampODoH, Props: stampPropDNSSEC, Hostname: "odoh.example.com", Path: "/dns-query"}},
+	}
+	for _, tt := range tests {
+		st, err := parseStamp(tt.stamp)
+		if err != nil {
+			t.Errorf("%s: %v", tt.name, err)
+			continue
+		}
+		if st.Protocol != tt.want.Protocol || st.Props != tt.want.Props || st.Address != tt.want.Address ||
+			st.Hostname != tt.want.Hostname || st.Path != tt.want.Path {
+			t.Errorf("%s: 解析结果为 %+v，应为 %+v", tt.name, st, tt.want)
+		}
+	}
+}
+
+func TestDecodeStamp(t *testing.T) {
+	key := make([]byte, 32)
+	tests := []struct {
+		name      string
+		stamp     string
+		ok        bool
+		server    string
+		transport string
+		pinAddr   string
+	}{
+		{"DoH 连接 Stamp 中的地址", testStamp(stampDoH, true, []byte("1.1.1.1"), nil, []byte("cloudflare-dns.com"), []byte("/dns-query")),
+			true, "https://cloudflare-dns.com/dns-query", "", "1.1.1.1:443"},
+		{"DoH 没有地址时解析主机名", testStamp(stampDoH, true, nil, nil, []byte("dns.google"), []byte("/dns-query")),
+			true, "https://dns.google/dns-query", "", ""},
+		{"明文 DNS", testStamp(stampPlain, true, []byte("9.9.9.9:5353")), true, "9.9.9.9:5353", "", ""},
+		{"DoT 没有地址时连接主机名", testStamp(stampDoT, true, nil, nil, []byte("dns.quad9.net")), true, "dns.quad9.net:853", "dot", ""},
+		{"DoT 默认 853 端口", testStamp(stampDoT, true, []byte("9.9.9.9"), nil, []byte("dns.quad9.net")), true, "9.9.9.9:853", "dot", ""},
+		{"DNSCrypt 默认 443 端口", testStamp(stampDNSCrypt, true, []byte("208.67.222.222"), key, []byte("2.dnscrypt-cert.opendns.com")),
+			true, "208.67.222.222:443", "dnscrypt", ""},
+		{"DNSCrypt 缺少公钥", testStamp(stampDNSCrypt, true, []byte("208.67.222.222"), nil, []byte("2.dnscrypt-cert.opendns.com")), false, "", "", ""},
+		{"不支持 DoQ", testStamp(stampDoQ, true, []byte("94.140.14.14"), nil, []byte("dns.adguard.com")), false, "", "", ""},
+		{"无效的 Stamp", "sdns://!!", false, "", "", ""},
+	}
+	for _, tt := range tests {
+		cand, ok := decodeStamp(candidate{server: tt.stamp}, &rejectLog{})
+		if ok != tt.ok {
+			t.Errorf("%s: decodeStamp 返回 %v，应为 %v", tt.name, ok, tt.ok)
+			continue
+		}
+		if !ok {
+			continue
+		}
+		pinAddr := ""
+		if cand.pin != nil {
+			pinAddr = cand.pin.addr
+		}
+		if cand.server != tt.server || cand.transport != tt.transport || pinAddr != tt.pinAddr {
+			t.Errorf("%s: 得到 server=%s transport=%s pin=%s，应为 %s %s %s", tt.name, cand.server, cand.transport, pinAddr, tt.server, tt.transport, tt.pinAddr)
+		}
+	}
+}
+
+// 只有证书链中有证书与固定的哈希相符时才通过
+func TestStampPinVerify(t *testing.T) {
+	srv := httptest.NewTLSServer(nil)
+	defer srv.Close()
+	state := tls.ConnectionState{PeerCertificates: []*x509.Certificate{srv.Certificate()}}
+	sum := sha256.Sum256(srv.Certificate().RawTBSCertificate)
+
+	tests := []struct {
+		name string
+		pin  *stampPin
+		ok   bool
+	}{
+		{"没有固定哈希", &stampPin{}, true},
+		{"哈希相符", &stampPin{hashes: [][]byte{make([]byte, 32), sum[:]}}, true},
+		{"哈希不符", &stampPin{hashes: [][]byte{make([]byte, 32)}}, false},
+	}
+	for _, tt := range tests {
+		err := tt.pin.verify(state)
+		if (err == nil) != tt.ok {
+			t.Errorf("%s: verify 返回 %v", tt.name, err)
+		}
+		if err != nil && failureCategory(err) != failTLS {
+			t.Errorf("%s: 失败类别为 %s，应为 %s", tt.name, failureCategory(err), failTLS)
+		}
+	}
+}
@@ -0,0 +1,28 @@
+package dnsvalidator
+
+import "sync/atomic"
+
+// 可用服务器数量达到 -stop-after 后停止运行
+type stopAfter struct {
+	limit int64 // 为 0 表示不限制
+	found atomic.Int64
+	run   *runState
+}
+
+// 记录一台可用服务器，返回是否保留该结果；达到上限时停止分发新的检查并取消进行中的查询，
+// 之后完成的检查不再保留
+func (s *stopAfter) collect() bool {
+	if s == nil || s.limit <= 0 {
+		return true
+	}
+	n := s.found.Add(1)
+	if n == s.limit {
+		s.run.stop(0)
+	}
+	return n <= s.limit
+}
+
+// 判断是否已达到上限
+func (s *stopAfter) reached() bool {
+	return s != nil && s.limit > 0 && s.found.Load() >= s.limit
+}
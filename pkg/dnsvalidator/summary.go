@@ -0,0 +1,159 @@
+package dnsvalidator
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"time"
+)
+
+// 一个国家的可用服务器数
+type CountryCount struct {
+	Country string `json:"country"`
+	Count   int    `json:"count"`
+}
+
+// 延迟分布中的一个分桶
+type LatencyCount struct {
+	Label string `json:"label"` // 如 "10ms-25ms"
+	Count int    `json:"count"`
+}
+
+// 可用服务器的延迟统计 (毫秒)
+type LatencySummary struct {
+	Min     float64        `json:"min"`
+	P50     float64        `json:"p50"`
+	P90     float64        `json:"p90"`
+	P99     float64        `json:"p99"`
+	Max     float64        `json:"max"`
+	Buckets []LatencyCount `json:"buckets"`
+}
+
+// 一次运行的汇总统计，见 NewSummary
+type Summary struct {
+	Tested     int            `json:"tested"`
+	Valid      int            `json:"valid"`
+	Failed     int            `json:"failed"`
+	Failures   map[string]int `json:"failures,omitempty"` // 各失败类别的服务器数
+	Latency    LatencySummary `json:"latency"`
+	Countries  []CountryCount `json:"top_countries,omitempty"`
+	ASNs       []ASNCount     `json:"top_asns,omitempty"`
+	DurationMs int64          `json:"duration_ms"`
+	Queries    int64          `json:"queries,omitempty"` // 发出的查询总数，包括重试和各项检查的查询
+	QPS        float64        `json:"qps,omitempty"`     // 整个运行平均每秒发出的查询数
+}
+
+// 按国家统计通过检查的服务器，返回数量最多的 n 个 (n <= 0 时全部返回)；没有国家的结果不计入
+func TopCountries(results []*Result, n int) []CountryCount {
+	counts := make(map[string]int)
+	for _, r := range results {
+		if r.Country != "" && r.Error == "" {
+			counts[r.Country]++
+		}
+	}
+	top := make([]CountryCount, 0, len(counts))
+	for country, count := range counts {
+		top = append(top, CountryCount{Country: country, Count: count})
+	}
+	sort.Slice(top, func(i, j int) bool {
+		if top[i].Count != top[j].Count {
+			return top[i].Count > top[j].Count
+		}
+		return top[i].Country < top[j].Country
+	})
+	if n > 0 && len(top) > n {
+		top = top[:n]
+	}
+	return top
+}
+
+// 由运行统计和输出的可用服务器生成汇总，queries 为发出的查询总数，为 0 时不计算 QPS
+func NewSummary(stats *Stats, results []*Result, elapsed time.Duration, queries int64) *Summary {
+	s := &Summary{
+		Tested:     stats.Tested(),
+		Valid:      stats.Valid(),
+		Failures:   stats.Failures(),
+		Countries:  TopCountries(results, 10),
+		ASNs:       TopASNs(results, 10),
+		DurationMs: elapsed.Milliseconds(),
+		Queries:    queries,
+	}
+	s.Failed = s.Tested - s.Valid
+	if queries > 0 && elapsed > 0 {
+		s.QPS = float64(queries) / elapsed.Seconds()
+	}
+
+	var valid []*Result
+	var rtts []time.Duration
+	for _, r := range results {
+		if r.Error == "" {
+			valid = append(valid, r)
+			if r.RTT > 0 {
+				rtts = append(rtts, r.RTT)
+			}
+		}
+	}
+	if len(rtts) > 0 {
+		sort.Slice(rtts, func(i, j int) bool { return rtts[i] < rtts[j] })
+		ms := func(d time.Duration) float64 { return float64(d.Microseconds()) / 1000 }
+		s.Latency.Min, s.Latency.Max = ms(rtts[0]), ms(rtts[len(rtts)-1])
+		s.Latency.P50, s.Latency.P90, s.Latency.P99 = ms(percentile(rtts, 50)), ms(percentile(rtts, 90)), ms(percentile(rtts, 99))
+	}
+	for _, b := range latencyDistribution(valid) {
+		s.Latency.Buckets = append(s.Latency.Buckets, LatencyCount{Label: b.Label, Count: b.Count})
+	}
+	return s
+}
+
+// 打印汇总：数量和失败原因、延迟分布、可用服务器最多的国家和 ASN、用时和查询速率
+func (s *Summary) Print(w io.Writer) {
+	fmt.Fprintf(w, "共检查 %d 台服务器，可用 %d 台，失败 %d 台\n", s.Tested, s.Valid, s.Failed)
+	categories := make([]string, 0, len(s.Failures))
+	for category := range s.Failures {
+		categories = append(categories, category)
+	}
+	sort.Slice(categories, func(i, j int) bool {
+		if s.Failures[categories[i]] != s.Failures[categories[j]] {
+			return s.Failures[categories[i]] > s.Failures[categories[j]]
+		}
+		return categories[i] < categories[j]
+	})
+	for _, category := range categories {
+		fmt.Fprintf(w, "  %-16s %d\n", category, s.Failures[category])
+	}
+	if s.Latency.Max > 0 {
+		fmt.Fprintf(w, "延迟 (毫秒): 最小 %g，p50 %g，p90 %g，p99 %g，最大 %g\n", s.Latency.Min, s.Latency.P50, s.Latency.P90, s.Latency.P99, s.Latency.Max)
+		for _, b := range s.Latency.Buckets {
+			if b.Count > 0 {
+				fmt.Fprintf(w, "  %-16s %d\n", b.Label, b.Count)
+			}
+		}
+	}
+	if len(s.Countries) > 0 {
+		fmt.Fprintln(w, "可用服务器最多的国家:")
+		for _, c := range s.Countries {
+			fmt.Fprintf(w, "  %-16s %d\n", c.Country, c.Count)
+		}
+	}
+	if len(s.ASNs) > 0 {
+		fmt.Fprintln(w, "可用服务器最多的 ASN:")
+		for _, c := range s.ASNs {
+			fmt.Fprintf(w, "  AS%-10d %5d  %s\n", c.ASN, c.Count, c.Org)
+		}
+	}
+	duration := time.Duration(s.DurationMs) * time.Millisecond
+	if s.Queries > 0 {
+		fmt.Fprintf(w, "用时 %v，发出 %d 个查询，平均每秒 %.1f 个\n", duration, s.Queries, s.QPS)
+	} else {
+		fmt.Fprintf(w, "用时 %v\n", duration)
+	}
+}
+
+// 将汇总以 JSON 写入 w
+func (s *Summary) WriteJSON(w io.Writer) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	enc.SetEscapeHTML(false)
+	return enc.Encode(s)
+}
@@ -0,0 +1,124 @@
+package dnsvalidator
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// 一次运行中所有查询共享的网络设置：连接超时、自适应超时、查询速率限制和 DoH 的 HTTP Transport。
+// 为空时使用默认设置：连接超时与查询超时相同，不调整超时也不限速
+type netState struct {
+	connectTimeout time.Duration    // 建立 TCP/TLS 连接的超时，为 0 时使用查询超时
+	adaptive       *adaptiveTimeout // 为空表示使用固定的查询超时
+	limit          *rateLimiter     // 为空表示不限制
+
+	dohOnce sync.Once
+	doh     *http.Transport
+}
+
+// 返回建立连接使用的超时
+func (n *netState) dialTimeout(queryTimeout time.Duration) time.Duration {
+	if n != nil && n.connectTimeout > 0 {
+		return n.connectTimeout
+	}
+	return queryTimeout
+}
+
+// 返回建立连接所用的 Dialer
+func (n *netState) dialer(queryTimeout time.Duration) *net.Dialer {
+	return &net.Dialer{Timeout: n.dialTimeout(queryTimeout)}
+}
+
+// 发出查询前等待速率限制，等待期间 ctx 被取消时返回其错误
+func (n *netState) wait(ctx context.Context) error {
+	if n == nil {
+		return nil
+	}
+	return n.limit.wait(ctx)
+}
+
+// 返回下一次查询的超时，own 为该服务器此前的 RTT
+func (n *netState) timeout(own []time.Duration, fallback time.Duration) time.Duration {
+	if n == nil {
+		return fallback
+	}
+	return n.adaptive.timeout(own, fallback)
+}
+
+// 记录一次成功查询的 RTT
+func (n *netState) observe(rtt time.Duration) {
+	if n != nil {
+		n.adaptive.observe(rtt)
+	}
+}
+
+// 返回从 start 开始的查询截止时间，不超过 ctx 的截止时间
+func queryDeadline(ctx context.Context, start time.Time, timeout time.Duration) time.Time {
+	deadline := start.Add(timeout)
+	if d, ok := ctx.Deadline(); ok && d.Before(deadline) {
+		return d
+	}
+	return deadline
+}
+
+// ctx 被取消时立即结束连接上进行中的读写，返回的函数用于停止监视
+func watchConn(ctx context.Context, conn net.Conn) func() {
+	stop := context.AfterFunc(ctx, func() {
+		conn.SetDeadline(time.Now())
+	})
+	return func() { stop() }
+}
+
+// 一次运行的停止状态：stopped 后不再分发新的检查，ctx 取消后进行中的查询立即结束
+type runState struct {
+	ctx     context.Context
+	cancel  context.CancelFunc
+	stopped atomic.Bool
+}
+
+// 停止分发新的检查，进行中的检查最多再等待 grace 后取消
+func (s *runState) stop(grace time.Duration) {
+	if s == nil {
+		return
+	}
+	s.stopped.Store(true)
+	if grace <= 0 {
+		s.cancel()
+		return
+	}
+	time.AfterFunc(grace, s.cancel)
+}
+
+// 判断是否应停止分发新的检查
+func (s *runState) stopping() bool {
+	return s.stopped.Load() || s.ctx.Err() != nil
+}
+
+// 返回 DoH 查询共用的 HTTP Transport，建立连接和 TLS 握手使用连接超时；n 为空时使用默认的 Transport
+func (n *netState) dohTransport(queryTimeout time.Duration) http.RoundTripper {
+	if n == nil {
+		return http.DefaultTransport
+	}
+	n.dohOnce.Do(func() {
+		t := http.DefaultTransport.(*http.Transport).Clone()
+		t.DialContext = n.dialer(queryTimeout).DialContext
+		t.TLSHandshakeTimeout = n.dialTimeout(queryTimeout)
+		n.doh = t
+	})
+	return n.doh
+}
+
+// 运行结束后关闭 DoH 的空闲连接
+func (n *netState) close() {
+	if n == nil {
+		return
+	}
+	n.dohOnce.Do(func() {})
+	if n.doh != nil {
+		n.doh.CloseIdleConnections()
+	}
+}
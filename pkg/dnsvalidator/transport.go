@@ -0,0 +1,18 @@
+package dnsvalidator
+
+import ()
+
+// 分别通过 UDP 和 TCP 查询域名，返回各自的错误
+func checkTransports(c *client, server, domain string) (udpErr, tcpErr error) {
+	_, udpErr = lookupIPs(&client{ctx: c.ctx, network: "udp", timeout: c.timeout, net: c.net}, server, domain)
+	_, tcpErr = lookupIPs(&client{ctx: c.ctx, network: "tcp", timeout: c.timeout, net: c.net}, server, domain)
+	return udpErr, tcpErr
+}
+
+// 返回传输协议状态的输出列值，失败时为失败类别
+func transportStatus(err error) string {
+	if err == nil {
+		return "ok"
+	}
+	return failureCategory(err)
+}
@@ -0,0 +1,24 @@
+package dnsvalidator
+
+import "fmt"
+
+// 不带 EDNS0 查询应答超过 512 字节的记录，要求服务器在 UDP 上设置 TC 标志，
+// 并能通过 TCP 取回完整应答。截断却拒绝 TCP 的服务器会导致大应答查询静默失败
+func checkTruncation(c *client, server, name string, qtype uint16) ([]string, error) {
+	resp, _, err := exchangeUDP(c.ctx, c.net, server, newQuery(name, qtype), c.timeout)
+	if err != nil {
+		return nil, fmt.Errorf("截断测试查询失败: %w", err)
+	}
+	if !resp.Truncated {
+		return []string{"tc=false"}, nil
+	}
+
+	full, _, err := exchangeTCP(c.ctx, c.net, server, newQuery(name, qtype), c.timeout)
+	if err != nil {
+		return nil, &CheckError{Category: failTruncated, Err: fmt.Errorf("UDP 应答被截断但无法通过 TCP 重试: %v", err)}
+	}
+	if full.RCode != rcodeSuccess || len(full.answerValues(qtype)) == 0 {
+		return nil, newFailure(failTruncated, "UDP 应答被截断但 TCP 未返回完整应答 (%s)", rcodeString(full.RCode))
+	}
+	return []string{"tc=true", "tcp_retry=ok"}, nil
+}
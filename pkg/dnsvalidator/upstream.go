@@ -0,0 +1,70 @@
+package dnsvalidator
+
+import (
+	"net"
+	"net/netip"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// 返回查询者 IP 的域名：whoami.akamai.net 以 A 记录返回，o-o.myaddr.l.google.com 以 TXT 记录返回
+var whoamiQueries = []struct {
+	name  string
+	qtype uint16
+}{
+	{"whoami.akamai.net", typeA},
+	{"o-o.myaddr.l.google.com", typeTXT},
+}
+
+// 通过 whoami 类域名获取服务器向权威服务器发起递归查询时使用的出口 IP，去重后排序
+func lookupUpstream(c *client, server string) []string {
+	seen := make(map[netip.Addr]bool)
+	for _, q := range whoamiQueries {
+		resp, _, err := c.exchange(server, newQuery(q.name, q.qtype))
+		if err != nil || resp.RCode != rcodeSuccess {
+			continue
+		}
+		// Google 的 TXT 应答在携带 ECS 时还有一条 edns0-client-subnet 记录，只取能解析为 IP 的值
+		for _, value := range resp.answerValues(q.qtype) {
+			if ip, err := netip.ParseAddr(value); err == nil {
+				seen[ip.Unmap()] = true
+			}
+		}
+	}
+	ips := make([]netip.Addr, 0, len(seen))
+	for ip := range seen {
+		ips = append(ips, ip)
+	}
+	sort.Slice(ips, func(i, j int) bool { return ips[i].Less(ips[j]) })
+	upstream := make([]string, len(ips))
+	for i, ip := range ips {
+		upstream[i] = ip.String()
+	}
+	return upstream
+}
+
+// 记录服务器的递归出口 IP。出口 IP 与服务器地址都不相同时，服务器很可能只是把查询转发给了其他解析器；
+// 大型公共解析器的出口通常也与服务 IP 不同，forwarder 只作为参考。查不到出口 IP 时不输出
+func checkUpstream(c *client, server string) []string {
+	upstream := lookupUpstream(c, server)
+	if len(upstream) == 0 {
+		return nil
+	}
+	attrs := []string{"upstream=" + strings.Join(upstream, ",")}
+	host, _, err := net.SplitHostPort(server)
+	if err != nil {
+		return attrs
+	}
+	addr, err := netip.ParseAddr(host)
+	if err != nil {
+		return attrs
+	}
+	forwarder := true
+	for _, ip := range upstream {
+		if ip == addr.Unmap().String() {
+			forwarder = false
+		}
+	}
+	return append(attrs, "forwarder="+strconv.FormatBool(forwarder))
+}
@@ -0,0 +1,811 @@
+// Package dnsvalidator 检查 DNS 服务器是否可用、答案是否可信，并按多种格式输出结果。
+//
+// 典型用法：
+//
+//	v, err := dnsvalidator.New(dnsvalidator.Options{Domains: []string{"google.com"}, NXCheck: true})
+//	if err != nil {
+//		return err
+//	}
+//	results, err := v.Run(ctx, dnsvalidator.Sources{Servers: []string{"1.1.1.1", "8.8.8.8"}})
+//	if err != nil {
+//		return err
+//	}
+//	for r := range results {
+//		fmt.Println(r.Server, r.LatencyMs)
+//	}
+//	return v.Err()
+package dnsvalidator
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"strings"
+	"sync"
+	"time"
+)
+
+// 默认的在线 DNS 服务器列表
+const DefaultListURL = "https://public-dns.info/nameservers.txt"
+
+// Validator 的配置，零值字段使用括号中的默认值
+type Options struct {
+	Domains    []string // 需要解析的域名，第一个为主检查域名
+	Quorum     int      // 至少需要正确解析的域名个数 (全部)
+	Repeat     int      // 每个域名的查询次数 (1)
+	RepeatPass int      // 每个域名至少需要成功的查询次数 (全部)
+	Samples    int      // 测量延迟的查询次数，大于 1 时输出 p50/p90/p99 并以中位数作为延迟 (1)
+
+	Timeout        time.Duration // 单次查询的超时 (5s)
+	ConnectTimeout time.Duration // 建立 TCP/TLS 连接的超时 (与 Timeout 相同)
+	Deadline       time.Duration // 整个运行的最长时间，到期后取消未完成的查询 (不限制)
+	Retries        int           // 查询超时后的最多重试次数
+	Backoff        time.Duration // 第一次重试前的等待时间，之后每次翻倍并加入随机抖动
+	RateLimit      int           // 所有 worker 合计每秒最多发出的查询数 (不限制)
+
+	AdaptiveTimeout bool          // 根据观测到的 RTT 动态调整每次查询的超时
+	AdaptiveFactor  float64       // 自适应超时为 RTT 中位数的倍数 (3)
+	AdaptiveMin     time.Duration // 自适应超时的下限 (200ms)
+	AdaptiveMax     time.Duration // 自适应超时的上限 (Timeout 的 2 倍)
+
+	Transport string // 查询所用的传输协议: udp、tcp、both 或 dot (udp)
+	DoHMethod string // DoH 请求方法: GET 或 POST (POST)
+	Port      string // 条目未指定端口时使用的端口 (按传输协议取 53 或 853)
+
+	BaselineServers     []string                // 可信基准服务器 (IP、IP:端口或 DoH URL)，非空时丢弃答案与基准没有相同地址、网段或 ASN 的服务器
+	Expected            map[string][]*net.IPNet // 域名到预期答案网段的映射，见 LoadExpected
+	AllowPrivateAnswers bool                    // 允许检查域名解析到保留或私有地址，用于检查解析内部域名的服务器；默认丢弃这类服务器
+	SinkholeIPs         []string                // 除 0.0.0.0、127.0.0.1、::、::1 外视为拦截的应答地址，检查域名被解析到这些地址的服务器归为 sinkhole
+	NXCheck             bool                    // 检测泛解析和 NXDOMAIN 劫持
+	Canary              string                  // 生成随机不存在子域名所用的域名 (example.com)
+
+	DNSSEC     bool   // 检测 DNSSEC 验证能力
+	DNSSECOnly bool   // 只保留 DNSSEC 验证型服务器
+	SignedZone string // 已正确签名的域名 (isc.org)
+	BogusZone  string // 签名故意损坏的域名 (dnssec-failed.org)
+
+	Recursion        bool     // 检测 RA 标志
+	RecursiveOnly    bool     // 只保留开放递归解析器
+	Types            []uint16 // 需要全部查询成功的记录类型，见 ParseTypes
+	EDNS             bool     // 检测 EDNS0 支持及 UDP 缓冲区大小
+	EDNSCompliance   bool     // 测试 EDNS 合规性 (版本协商、未知选项和标志位)，输出 ok、partial 或 broken 等级
+	EDNSMinGrade     string   // 丢弃 EDNS 合规性低于该等级的服务器: ok 或 partial，隐含 EDNSCompliance
+	TC               bool     // 检测截断及 TCP 重试
+	TCName           string   // 截断测试的域名 (.)
+	TCType           uint16   // 截断测试的记录类型 (DNSKEY)
+	ECS              bool     // 检测 EDNS Client Subnet 支持
+	ECSFilter        string   // 按 ECS 支持情况过滤: exclude 或 only
+	Amplification    bool     // 估算 ANY、DNSKEY 和 TXT 查询的应答放大倍数
+	MaxAmplification float64  // 丢弃放大倍数超过该值的服务器，为 0 不限制，隐含 Amplification
+	Case0x20         bool     // 用大小写混合的域名查询，检查响应是否原样保留大小写
+	Require0x20      bool     // 只保留原样保留大小写的服务器，隐含 Case0x20
+	Rebind           bool     // 查询解析到私有或回环地址的域名，检测服务器是否过滤这类应答 (DNS 重绑定保护)
+	RebindFilter     string   // 按重绑定保护过滤: exclude 或 only，隐含 Rebind
+	RebindDomains    []string // 重绑定探测的域名，默认是 localtest.me 等，隐含 Rebind
+	Cookie           bool     // 检测 DNS Cookie 支持
+	NodeID           bool     // 通过 CHAOS hostname.bind 和 NSID 记录应答的 anycast 节点
+	Upstream         bool     // 通过 whoami.akamai.net 等域名记录递归出口 IP，判断服务器是否只是转发查询
+	QMin             bool     // 通过 internet.nl 的测试区域检测递归查询是否做了 QNAME 最小化 (RFC 9156)
+	QMinZone         string   // QNAME 最小化测试区域 (qnamemintest.internet.nl)
+	PortRandom       bool     // 通过 DNS-OARC 源端口测试区域评估递归查询的源端口随机化
+	PortTestZone     string   // 源端口测试区域 (porttest.dns-oarc.net)
+	PortMinGrade     string   // 丢弃源端口随机化评级低于该等级的服务器: great、good 或 fair，隐含 PortRandom
+	AXFR             bool     // 尝试对服务器自身的反向区域做区域传送，标记允许开放 AXFR 的服务器
+	AXFRZones        []string // 额外尝试传送的区域，隐含 AXFR
+	Censorship       bool     // 查询常见的被封锁域名，记录被拒绝、重定向或返回 NXDOMAIN 的域名，参考答案来自 Bootstrap
+	CensorDomains    []string // 审查探测的域名，默认是 twitter.com 等常见的被封锁网站，隐含 Censorship
+	CacheSnoop       bool     // 以 RD=0 查询热门域名，标记会暴露缓存内容的服务器
+	SnoopDomains     []string // 缓存探测查询的域名，默认是 google.com 等热门域名，隐含 CacheSnoop
+	CacheLatency     bool     // 分别测量主检查域名 (命中缓存) 和随机子域名 (需要递归) 的查询延迟
+	Software         bool     // 通过 CHAOS version.bind 识别服务器软件和版本
+	ExcludeRisky     bool     // 丢弃运行明显过时或家用路由器软件的服务器，隐含 Software
+
+	Filtering        bool                // 查询广告、恶意软件和成人内容的探测域名，将服务器归类为 unfiltered、adblock、malware 或 family
+	FilteringDomains map[string][]string // 各类别 (ads、malware、adult) 的探测域名，见 ParseFilteringDomains，隐含 Filtering
+	FilteringClasses []string            // 只保留这些过滤类型的服务器，隐含 Filtering
+
+	Jitter         bool          // 以固定间隔发送一组探测查询 (不重试)，估算丢包率和 RTT 抖动
+	JitterProbes   int           // 探测查询的个数 (10)
+	JitterInterval time.Duration // 探测查询的间隔 (100ms)
+	MaxLoss        float64       // 丢弃丢包率 (百分比，如 5) 超过该值的服务器，为 0 不限制，隐含 Jitter
+	MaxJitter      time.Duration // 丢弃 RTT 抖动超过该值的服务器，为 0 不限制，隐含 Jitter
+
+	Checkers      []Checker // 自定义检查，在内置检查之后依次执行
+	EnableChecks  []string  // 按名称启用内置检查，见 CheckNames
+	DisableChecks []string  // 按名称跳过内置或自定义检查，resolve 不能跳过
+
+	MaxExpand    int          // 单个 CIDR 网段最多展开的地址数 (65536)
+	AllowLarge   bool         // 允许展开超过 MaxExpand 的网段
+	Bootstrap    string       // 解析主机名条目所用的引导服务器 (1.1.1.1)
+	AllowPrivate bool         // 允许私有、回环和链路本地地址
+	Excludes     []*net.IPNet // 跳过的网段，见 LoadExcludes
+	IPVersion    string       // 只检查指定地址族的服务器: 4、6 或 both (both)
+	GeoIP        *GeoIP       // 标注服务器所在国家、城市和坐标的数据库，见 OpenGeoIP
+	ASN          *ASNLookup   // 标注服务器所属 ASN 和组织，见 OpenASN 和 CymruASN
+	Filter       *GeoFilter   // 检查前按国家和 ASN 跳过服务器
+	PTR          bool         // 通过 Bootstrap 反向解析通过检查的服务器 IP，填入 Result.PTR
+
+	MinReliability float64 // 跳过 public-dns.info 列表中记录的可靠性低于该值的服务器，普通列表中的条目不受影响
+
+	Reputation *ReputationStore // 跨运行保存每台服务器的历史记录，见 OpenReputation，为空不记录
+	SkipDead   time.Duration    // 跳过历史记录中最近一次检查未通过、且已超过该时间没有通过检查的服务器，不发出查询 (不跳过)
+
+	Threads     int  // 同时检查的服务器数 (10)
+	AutoThreads bool // 根据超时比例和本机错误自动调整并发，最多 MaxThreads
+	Interactive bool // 允许运行中用 Pause、Resume 和 SetThreads 控制检查，最多 MaxThreads
+	MaxThreads  int  // AutoThreads 或 Interactive 时的最大并发 (500)
+	StopAfter   int  // 找到该数量的可用服务器后停止 (不限制)
+
+	Checkpoint string // 断点文件，持续记录已完成的检查
+	Resume     bool   // 从 Checkpoint 继续上次的运行，跳过已完成检查的服务器
+
+	ShowSource  bool // 在结果中记录条目来源，有多个来源时自动开启
+	ShowLatency bool // 在文本输出的属性列中加入 latency_ms
+
+	Progress io.Writer // 逐台服务器的检查信息写入此处，为空则不输出
+	Tainted  io.Writer // 劫持 NXDOMAIN 的服务器写入此处，每行一个
+	Invalid  io.Writer // 未通过检查的服务器写入此处，每行为 服务器 # [失败类别] 原因
+	Rejected io.Writer // 被拒绝的条目写入此处，每行为 条目<TAB>原因<TAB>来源
+
+	// 每台通过检查的服务器依次调用一次，调用之间不会并发；
+	// 设置后结果只交给 OnResult，Run 返回的通道不再送出结果，只在运行结束时关闭
+	OnResult func(*Result)
+
+	// 每台未通过检查的服务器调用一次，调用之间不会并发；err 为 *CheckError，
+	// 可以用 errors.Is 与 ErrTimeout、ErrRefused 等比较。Resume 时上次的失败记录同样会送出
+	OnFailure func(server string, err error)
+
+	IncludeFailed bool // 未通过检查的服务器也作为 Result 送出，见 Result.Error
+
+	Exchanger  Exchanger    // 替代网络发送所有查询，用于测试或模拟故障，见 MockServer
+	Middleware []Middleware // 每次查询发送前和收到响应后调用的中间件
+}
+
+// 待检查的 DNS 服务器列表来源，各来源并发读取
+type Sources struct {
+	Servers []string  // 直接指定的条目，来源记为 list
+	Files   []string  // 列表文件，按扩展名或文件头自动解压
+	URLs    []string  // 在线列表 URL
+	Stdin   io.Reader // 其他流式来源，如标准输入，来源记为 stdin
+}
+
+// 来源的数量
+func (s Sources) count() int {
+	n := len(s.Files) + len(s.URLs)
+	if len(s.Servers) > 0 {
+		n++
+	}
+	if s.Stdin != nil {
+		n++
+	}
+	return n
+}
+
+// 按 Options 检查 DNS 服务器
+type Validator struct {
+	cfg     Options
+	opts    *options
+	rejects *rejectLog
+	pipe    *pipeline
+	parent  context.Context // 传给 Run 的 ctx
+	results <-chan *Result
+	input   *inputSize // 来源的读取进度
+	started time.Time  // Run 开始的时间
+	resumed int        // 从断点文件恢复的通过检查的服务器数
+}
+
+// 逐台服务器的检查信息，w 为空时不输出
+type logger struct {
+	mu sync.Mutex
+	w  io.Writer
+}
+
+func (l *logger) printf(format string, args ...interface{}) {
+	if l == nil || l.w == nil {
+		return
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	fmt.Fprintf(l.w, format, args...)
+}
+
+// 运行中遇到的第一个无法继续的错误
+type runError struct {
+	mu  sync.Mutex
+	err error
+}
+
+// 记录错误，只保留第一个错误，返回是否为第一个错误
+func (e *runError) set(err error) bool {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	if e.err != nil {
+		return false
+	}
+	e.err = err
+	return true
+}
+
+func (e *runError) get() error {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	return e.err
+}
+
+// 记录无法继续运行的错误并停止运行
+func (o *options) abort(err error) {
+	if o.err.set(err) {
+		o.run.stop(0)
+	}
+}
+
+// 检查配置并填入默认值
+func New(cfg Options) (*Validator, error) {
+	if len(cfg.Domains) == 0 {
+		return nil, fmt.Errorf("至少需要一个检查域名")
+	}
+	if err := enableChecks(&cfg); err != nil {
+		return nil, err
+	}
+	if cfg.Quorum <= 0 || cfg.Quorum > len(cfg.Domains) {
+		cfg.Quorum = len(cfg.Domains)
+	}
+	if cfg.Repeat < 1 {
+		cfg.Repeat = 1
+	}
+	if cfg.RepeatPass <= 0 || cfg.RepeatPass > cfg.Repeat {
+		cfg.RepeatPass = cfg.Repeat
+	}
+	if cfg.Timeout <= 0 {
+		cfg.Timeout = 5 * time.Second
+	}
+	if cfg.AdaptiveFactor <= 0 {
+		cfg.AdaptiveFactor = 3
+	}
+	if cfg.AdaptiveMin <= 0 {
+		cfg.AdaptiveMin = 200 * time.Millisecond
+	}
+	if cfg.AdaptiveMax <= 0 {
+		cfg.AdaptiveMax = 2 * cfg.Timeout
+	}
+	if cfg.Transport == "" {
+		cfg.Transport = "udp"
+	}
+	if cfg.Transport != "udp" && cfg.Transport != "tcp" && cfg.Transport != "both" && cfg.Transport != "dot" {
+		return nil, fmt.Errorf("传输协议只能是 udp、tcp、both 或 dot")
+	}
+	cfg.DoHMethod = strings.ToUpper(cfg.DoHMethod)
+	if cfg.DoHMethod == "" {
+		cfg.DoHMethod = "POST"
+	}
+	if cfg.DoHMethod != "GET" && cfg.DoHMethod != "POST" {
+		return nil, fmt.Errorf("DoH 请求方法只能是 GET 或 POST")
+	}
+	if cfg.Canary == "" {
+		cfg.Canary = "example.com"
+	}
+	if cfg.SignedZone == "" {
+		cfg.SignedZone = "isc.org"
+	}
+	if cfg.BogusZone == "" {
+		cfg.BogusZone = "dnssec-failed.org"
+	}
+	if cfg.TCName == "" {
+		cfg.TCName = "."
+	}
+	if cfg.TCType == 0 {
+		cfg.TCType = typeDNSKEY
+	}
+	if cfg.ECSFilter != "" && cfg.ECSFilter != "exclude" && cfg.ECSFilter != "only" {
+		return nil, fmt.Errorf("ECS 过滤方式只能是 exclude 或 only")
+	}
+	if err := validEDNSGrade(cfg.EDNSMinGrade); err != nil {
+		return nil, err
+	}
+	cfg.PortMinGrade = strings.ToLower(cfg.PortMinGrade)
+	if err := validPortGrade(cfg.PortMinGrade); err != nil {
+		return nil, err
+	}
+	if err := validRebindFilter(cfg.RebindFilter); err != nil {
+		return nil, err
+	}
+	if cfg.RebindFilter != "" || len(cfg.RebindDomains) > 0 {
+		cfg.Rebind = true
+	}
+	if len(cfg.RebindDomains) == 0 {
+		cfg.RebindDomains = defaultRebindDomains
+	}
+	if err := validFilteringClasses(cfg.FilteringClasses); err != nil {
+		return nil, err
+	}
+	if len(cfg.FilteringClasses) > 0 || cfg.FilteringDomains != nil {
+		cfg.Filtering = true
+	}
+	if cfg.FilteringDomains == nil {
+		cfg.FilteringDomains = defaultFilteringDomains
+	}
+	var filteringClasses map[string]bool
+	if len(cfg.FilteringClasses) > 0 {
+		filteringClasses = make(map[string]bool)
+		for _, class := range cfg.FilteringClasses {
+			filteringClasses[class] = true
+		}
+	}
+	if len(cfg.CensorDomains) > 0 {
+		cfg.Censorship = true
+	} else {
+		cfg.CensorDomains = defaultCensorDomains
+	}
+	if len(cfg.SnoopDomains) > 0 {
+		cfg.CacheSnoop = true
+	} else {
+		cfg.SnoopDomains = defaultSnoopDomains
+	}
+	if cfg.JitterProbes <= 0 {
+		cfg.JitterProbes = defaultJitterProbes
+	}
+	if cfg.JitterInterval <= 0 {
+		cfg.JitterInterval = defaultJitterInterval
+	}
+	if cfg.QMinZone == "" {
+		cfg.QMinZone = defaultQMinZone
+	}
+	if cfg.PortTestZone == "" {
+		cfg.PortTestZone = defaultPortTestZone
+	}
+	if cfg.IPVersion == "" {
+		cfg.IPVersion = "both"
+	}
+	if cfg.IPVersion != "4" && cfg.IPVersion != "6" && cfg.IPVersion != "both" {
+		return nil, fmt.Errorf("地址族只能是 4、6 或 both")
+	}
+	if cfg.MaxExpand <= 0 {
+		cfg.MaxExpand = 65536
+	}
+	if cfg.Bootstrap == "" {
+		cfg.Bootstrap = "1.1.1.1"
+	}
+	if cfg.Threads < 1 {
+		cfg.Threads = 10
+	}
+	if cfg.MaxThreads < 1 {
+		cfg.MaxThreads = 500
+	}
+	if cfg.Resume && cfg.Checkpoint == "" {
+		return nil, fmt.Errorf("继续上次的运行需要指定断点文件")
+	}
+
+	log := &logger{w: cfg.Progress}
+	opts := &options{
+		domain:     cfg.Domains[0],
+		domains:    cfg.Domains,
+		quorum:     cfg.Quorum,
+		repeat:     cfg.Repeat,
+		repeatPass: cfg.RepeatPass,
+		samples:    cfg.Samples,
+		timeout:    cfg.Timeout,
+		retries:    cfg.Retries,
+		backoff:    cfg.Backoff,
+		transport:  cfg.Transport,
+		dohMethod:  cfg.DoHMethod,
+		port:       cfg.Port,
+		expected:   cfg.Expected,
+		nxcheck:    cfg.NXCheck,
+		canary:     cfg.Canary,
+
+		dnssec:     cfg.DNSSEC || cfg.DNSSECOnly,
+		dnssecOnly: cfg.DNSSECOnly,
+		signedZone: cfg.SignedZone,
+		bogusZone:  cfg.BogusZone,
+
+		recursion:     cfg.Recursion || cfg.RecursiveOnly,
+		recursiveOnly: cfg.RecursiveOnly,
+
+		types: cfg.Types,
+
+		edns: cfg.EDNS,
+
+		ednsComp:     cfg.EDNSCompliance || cfg.EDNSMinGrade != "",
+		ednsMinGrade: cfg.EDNSMinGrade,
+
+		tcCheck: cfg.TC,
+		tcName:  cfg.TCName,
+		tcType:  cfg.TCType,
+
+		ecs:       cfg.ECS || cfg.ECSFilter != "",
+		ecsFilter: cfg.ECSFilter,
+
+		amplification:    cfg.Amplification || cfg.MaxAmplification > 0,
+		maxAmplification: cfg.MaxAmplification,
+
+		case0x20:    cfg.Case0x20 || cfg.Require0x20,
+		require0x20: cfg.Require0x20,
+
+		rebind:        cfg.Rebind,
+		rebindFilter:  cfg.RebindFilter,
+		rebindDomains: cfg.RebindDomains,
+
+		cookie: cfg.Cookie,
+
+		nodeID: cfg.NodeID,
+
+		upstream: cfg.Upstream,
+
+		qmin:     cfg.QMin,
+		qminZone: cfg.QMinZone,
+
+		portRandom:   cfg.PortRandom || cfg.PortMinGrade != "",
+		portTestZone: cfg.PortTestZone,
+		portMinGrade: cfg.PortMinGrade,
+
+		axfr:      cfg.AXFR || len(cfg.AXFRZones) > 0,
+		axfrZones: cfg.AXFRZones,
+
+		censorship:    cfg.Censorship,
+		censorDomains: cfg.CensorDomains,
+
+		filtering:        cfg.Filtering,
+		filteringDomains: cfg.FilteringDomains,
+		filteringClasses: filteringClasses,
+
+		jitter:         cfg.Jitter || cfg.MaxLoss > 0 || cfg.MaxJitter > 0,
+		jitterProbes:   cfg.JitterProbes,
+		jitterInterval: cfg.JitterInterval,
+		maxLoss:        cfg.MaxLoss,
+		maxJitter:      cfg.MaxJitter,
+
+		cacheSnoop:   cfg.CacheSnoop,
+		snoopDomains: cfg.SnoopDomains,
+
+		cacheLatency: cfg.CacheLatency,
+
+		software:     cfg.Software || cfg.ExcludeRisky,
+		excludeRisky: cfg.ExcludeRisky,
+
+		allowPrivateAnswers: cfg.AllowPrivateAnswers,
+
+		showSource:    cfg.ShowSource,
+		showLatency:   cfg.ShowLatency,
+		log:           log,
+		stats:         newStats(),
+		err:           &runError{},
+		stopAfter:     &stopAfter{limit: int64(cfg.StopAfter)},
+		onFailure:     cfg.OnFailure,
+		includeFailed: cfg.IncludeFailed,
+		events:        newEventBus(),
+		geoip:         cfg.GeoIP,
+		asn:           cfg.ASN,
+		ptr:           cfg.PTR,
+		ptrServer:     bootstrapAddr(cfg.Bootstrap),
+		reputation:    cfg.Reputation,
+	}
+	if cfg.Tainted != nil {
+		opts.tainted = &lockedWriter{w: cfg.Tainted}
+	}
+	if cfg.Invalid != nil {
+		opts.invalid = &lockedWriter{w: cfg.Invalid}
+	}
+
+	var err error
+	if opts.sinkholes, err = sinkholeSet(cfg.SinkholeIPs); err != nil {
+		return nil, err
+	}
+
+	// 组成检查链
+	opts.checks, err = buildChecks(opts, cfg.Checkers, cfg.DisableChecks)
+	if err != nil {
+		return nil, err
+	}
+
+	rejects := &rejectLog{log: log}
+	if cfg.Rejected != nil {
+		rejects.w = &lockedWriter{w: cfg.Rejected}
+	}
+	return &Validator{cfg: cfg, opts: opts, rejects: rejects}, nil
+}
+
+// 读取所有来源并检查其中的服务器，返回的通道逐个送出通过检查的服务器，全部检查完成后关闭。
+// 列表边读取边检查，不会整体载入内存；重复条目只检查一次，source 记录首次出现的来源。
+// 设置了 BaselineServers 时先查询基准服务器；任一来源无法打开时直接返回错误，
+// 读取过程中的错误在通道关闭后由 Err 返回。
+// ctx 被取消或超过 Deadline 时停止分发新的检查并立即结束进行中的查询，已完成的结果照常送出
+func (v *Validator) Run(ctx context.Context, src Sources) (<-chan *Result, error) {
+	cfg, opts := v.cfg, v.opts
+	opts.showSource = cfg.ShowSource || src.count() > 1
+
+	// 整个运行的截止时间和取消状态
+	run := &runState{}
+	if cfg.Deadline > 0 {
+		run.ctx, run.cancel = context.WithTimeout(ctx, cfg.Deadline)
+	} else {
+		run.ctx, run.cancel = context.WithCancel(ctx)
+	}
+	if cfg.Exchanger != nil {
+		run.ctx = withExchanger(run.ctx, cfg.Exchanger)
+	}
+	if len(cfg.Middleware) > 0 {
+		run.ctx = withMiddleware(run.ctx, cfg.Middleware)
+	}
+	v.parent, opts.run, opts.stopAfter.run = ctx, run, run
+	v.started = time.Now()
+
+	// 本次运行的连接超时、自适应超时和查询速率限制，每次运行重新开始
+	opts.net = &netState{connectTimeout: cfg.ConnectTimeout}
+	if cfg.AdaptiveTimeout {
+		opts.net.adaptive = newAdaptiveTimeout(cfg.AdaptiveFactor, cfg.AdaptiveMin, cfg.AdaptiveMax)
+	}
+	if cfg.RateLimit > 0 {
+		opts.net.limit = newRateLimiter(cfg.RateLimit)
+	}
+
+	// 获取可信基准服务器的答案
+	if len(cfg.BaselineServers) > 0 {
+		var err error
+		opts.baseline, err = buildBaseline(opts, cfg.BaselineServers, cfg.Domains)
+		if err != nil {
+			run.cancel()
+			return nil, err
+		}
+	}
+
+	// 获取审查和过滤探测域名的参考答案
+	var probes []string
+	if opts.censorship {
+		probes = append(probes, cfg.CensorDomains...)
+	}
+	if opts.filtering {
+		for _, domains := range opts.filteringDomains {
+			probes = append(probes, domains...)
+		}
+	}
+	if len(probes) > 0 {
+		opts.probeRef = buildProbeRef(opts.udpClient(), bootstrapAddr(cfg.Bootstrap), probes, opts.log)
+	}
+
+	// 读取上次运行的进度，并继续记录本次完成的检查
+	var resumed *checkpointState
+	if cfg.Resume {
+		var err error
+		resumed, err = loadCheckpoint(cfg.Checkpoint)
+		if err != nil {
+			run.cancel()
+			return nil, err
+		}
+		if err := resumed.replayFailures(opts); err != nil {
+			run.cancel()
+			return nil, fmt.Errorf("写入上次运行的失败记录时出错：%v", err)
+		}
+		opts.log.printf("从断点文件恢复了 %d 台已完成检查的服务器 (可用 %d 台)\n", len(resumed.done), len(resumed.results))
+	}
+	if cfg.Checkpoint != "" {
+		var err error
+		opts.checkpoint, err = openCheckpoint(cfg.Checkpoint, cfg.Resume)
+		if err != nil {
+			run.cancel()
+			return nil, err
+		}
+	}
+
+	// 打开 DNS 服务器列表，之后边读取边检查
+	v.input = &inputSize{}
+	input, waitSources, err := streamSources(run.ctx, src, v.input)
+	if err != nil {
+		opts.checkpoint.close()
+		run.cancel()
+		return nil, err
+	}
+
+	// 逐条预处理输入条目
+	v.pipe = &pipeline{
+		maxExpand:    cfg.MaxExpand,
+		allowLarge:   cfg.AllowLarge,
+		bootstrap:    opts.udpClient(),
+		bootstrapSrv: bootstrapAddr(cfg.Bootstrap),
+		allowPrivate: cfg.AllowPrivate,
+		excludes:     cfg.Excludes,
+		geoip:        cfg.GeoIP,
+		asn:          cfg.ASN,
+		filter:       cfg.Filter,
+		minReliable:  cfg.MinReliability,
+		reputation:   cfg.Reputation,
+		skipDead:     cfg.SkipDead,
+		family:       cfg.IPVersion,
+		ipv6Domain:   opts.domain,
+		rejects:      v.rejects,
+		log:          opts.log,
+		state:        run,
+	}
+	if resumed != nil {
+		v.pipe.seen = resumed.done
+		v.resumed = len(resumed.results)
+	}
+
+	// 启动固定数量的 worker，从任务通道中取出服务器进行检查；
+	// 自动调整并发或 Interactive 时启动 MaxThreads 个 worker，由 autoThreads 限制同时进行检查的数量
+	workers := cfg.Threads
+	if cfg.AutoThreads {
+		workers = cfg.MaxThreads
+		opts.threads = newAutoThreads(autoThreadsStart, workers, false, opts.log)
+	} else if cfg.Interactive {
+		workers = cfg.MaxThreads
+		opts.threads = newAutoThreads(cfg.Threads, workers, true, opts.log)
+	}
+	if opts.threads != nil {
+		// 暂停时结束运行，让等待名额的 worker 继续取出并丢弃剩余的任务
+		go func() {
+			<-run.ctx.Done()
+			opts.threads.pause(false)
+		}()
+	}
+	var wg sync.WaitGroup
+	jobs := make(chan candidate, workers)
+	results := make(chan *Result, workers)
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for {
+				opts.threads.acquire()
+				cand, ok := <-jobs
+				if !ok {
+					opts.threads.release()
+					return
+				}
+				// 停止后丢弃尚未开始检查的服务器
+				if !run.stopping() {
+					opts.events.publish(Event{Type: EventStarted, Server: cand.server})
+					checkDNS(cand, opts, results)
+				}
+				opts.threads.release()
+			}
+		}()
+	}
+
+	// 上次运行通过检查的服务器与本次的结果一起输出
+	if resumed != nil {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for _, r := range resumed.results {
+				if !opts.stopAfter.collect() {
+					break
+				}
+				opts.stats.pass()
+				results <- r
+			}
+		}()
+	}
+
+	// 边读取边预处理并投递待检查的服务器，任务通道满时等待 worker 空闲
+	var pipeErr error
+	go func() {
+		pipeErr = v.pipe.run(input, jobs)
+		v.input.done.Store(true)
+		close(jobs)
+	}()
+
+	// 等待所有 worker 执行完成后记录错误、保存断点文件并关闭 results 通道
+	go func() {
+		wg.Wait()
+		if pipeErr == nil {
+			pipeErr = waitSources()
+		}
+		if pipeErr != nil {
+			opts.abort(pipeErr)
+		}
+		if err := opts.checkpoint.close(); err != nil {
+			opts.abort(fmt.Errorf("写入断点文件时出错：%v", err))
+		}
+		run.cancel()
+		opts.net.close()
+		opts.events.finish(opts.stats)
+		close(results)
+	}()
+
+	// 设置了 OnResult 时逐个交给回调，全部处理完后关闭返回的通道
+	v.results = results
+	if cfg.OnResult != nil {
+		done := make(chan *Result)
+		go func() {
+			for r := range results {
+				cfg.OnResult(r)
+			}
+			close(done)
+		}()
+		v.results = done
+	}
+	return v.results, nil
+}
+
+// 返回 Run 送出结果的通道，与 Run 的返回值相同，调用 Run 之前为 nil
+func (v *Validator) Results() <-chan *Result {
+	return v.results
+}
+
+// 请求停止运行：不再分发新的检查，进行中的检查最多再等待 grace，之后 Run 返回的通道关闭。
+// 暂停中的运行同时恢复，以便丢弃尚未开始的检查
+func (v *Validator) Stop(grace time.Duration) {
+	v.opts.run.stop(grace)
+	v.opts.threads.pause(false)
+}
+
+// 暂停开始新的检查，进行中的检查照常完成；只在 Interactive 或 AutoThreads 时有效
+func (v *Validator) Pause() {
+	v.opts.threads.pause(true)
+}
+
+// 恢复暂停的检查
+func (v *Validator) Resume() {
+	v.opts.threads.pause(false)
+}
+
+// 设置同时检查的服务器数，限制在 1 到 MaxThreads 之间，返回设置后的值；
+// 只在 Interactive 或 AutoThreads 时有效，否则返回 Threads。自动调整并发时设置后不再自动调整
+func (v *Validator) SetThreads(n int) int {
+	if v.opts.threads == nil {
+		return v.cfg.Threads
+	}
+	return v.opts.threads.set(n)
+}
+
+// 返回当前同时检查的服务器数上限和是否暂停
+func (v *Validator) Threads() (n int, paused bool) {
+	if v.opts.threads == nil {
+		return v.cfg.Threads, false
+	}
+	return v.opts.threads.state()
+}
+
+// 返回运行中遇到的无法继续的错误，如读取列表或写入文件失败；应在 Run 返回的通道关闭后调用
+func (v *Validator) Err() error {
+	return v.opts.err.get()
+}
+
+// 返回按执行顺序启用的检查名称，包括自定义检查
+func (v *Validator) Checks() []string {
+	names := make([]string, len(v.opts.checks))
+	for i, c := range v.opts.checks {
+		names[i] = c.Name()
+	}
+	return names
+}
+
+// 返回运行统计
+func (v *Validator) Stats() *Stats {
+	return v.opts.stats
+}
+
+// 返回读取的输入条目数
+func (v *Validator) Read() int {
+	if v.pipe == nil {
+		return 0
+	}
+	return int(v.pipe.read.Load())
+}
+
+// 返回被拒绝的无效或被排除的条目数
+func (v *Validator) Rejected() int {
+	v.rejects.mu.Lock()
+	defer v.rejects.mu.Unlock()
+	return v.rejects.count
+}
+
+// 判断是否因找到 StopAfter 台可用服务器而提前停止
+func (v *Validator) StopAfterReached() bool {
+	return v.opts.stopAfter.reached()
+}
+
+// 判断运行是否被 Stop 或取消 ctx 中断
+func (v *Validator) Stopped() bool {
+	if v.opts.run == nil {
+		return false
+	}
+	return v.opts.run.stopped.Load() || errors.Is(v.parent.Err(), context.Canceled)
+}
+
+// 判断是否已超过 Deadline 或 ctx 的截止时间
+func (v *Validator) DeadlineExceeded() bool {
+	return v.opts.run != nil && errors.Is(v.opts.run.ctx.Err(), context.DeadlineExceeded)
+}
@@ -0,0 +1,78 @@
+package dnsvalidator
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"testing"
+	"time"
+)
+
+// 返回对 google.com 应答固定地址的模拟服务器行为
+func mockResolver() *MockBehavior {
+	return &MockBehavior{Records: map[string][]string{"google.com A": {"142.250.80.46"}}}
+}
+
+// 返回经由 mock 发送 UDP 查询的客户端
+func mockClient(mock *MockServer) *client {
+	return &client{ctx: withExchanger(context.Background(), mock), network: "udp", timeout: time.Second}
+}
+
+// 以 mock 作为 Exchanger 检查 servers，返回通过检查的服务器
+func runMock(t *testing.T, mock *MockServer, servers []string, opts ...Option) []string {
+	t.Helper()
+	opts = append([]Option{WithDomains("google.com"), WithTimeout(time.Second), WithExchanger(mock)}, opts...)
+	v, err := NewValidator(opts...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	results, err := v.Run(context.Background(), Sources{Servers: servers})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var valid []string
+	for r := range results {
+		valid = append(valid, r.Server)
+	}
+	if err := v.Err(); err != nil {
+		t.Fatal(err)
+	}
+	sort.Strings(valid)
+	return valid
+}
+
+// 连接超时、自适应超时和速率限制属于各自的运行，多个 Validator 可以并发运行，同一个 Validator 可以反复运行
+func TestConcurrentRuns(t *testing.T) {
+	mock := NewMockServer()
+	mock.Default = mockResolver()
+	servers := []string{"8.8.8.8", "8.8.4.4", "1.1.1.1", "9.9.9.9"}
+
+	var wg sync.WaitGroup
+	for i := 0; i < 4; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			v, err := NewValidator(WithDomains("google.com"), WithTimeout(time.Second), WithExchanger(mock),
+				WithAdaptiveTimeout(3, 10*time.Millisecond, time.Second), WithRateLimit(1000), WithConnectTimeout(time.Second))
+			if err != nil {
+				t.Error(err)
+				return
+			}
+			for run := 0; run < 2; run++ {
+				results, err := v.Run(context.Background(), Sources{Servers: servers})
+				if err != nil {
+					t.Error(err)
+					return
+				}
+				n := 0
+				for range results {
+					n++
+				}
+				if n != len(servers) {
+					t.Errorf("第 %d 次运行通过检查的服务器为 %d 台，应为 %d 台", run+1, n, len(servers))
+				}
+			}
+		}()
+	}
+	wg.Wait()
+}
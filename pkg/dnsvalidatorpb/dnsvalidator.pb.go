@@ -0,0 +1,692 @@
+// dnsvalidator 的 gRPC 接口，由 dnsvalidator -grpc 提供。
+// 修改后在 pkg/dnsvalidatorpb 下运行 go generate 重新生成 Go 代码
+
+// Code generated by protoc-gen-go. DO NOT EDIT.
+// versions:
+// 	protoc-gen-go v1.36.12
+// 	protoc        (unknown)
+// source: dnsvalidator.proto
+
+package dnsvalidatorpb
+
+import (
+	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
+	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
+	timestamppb "google.golang.org/protobuf/types/known/timestamppb"
+	reflect "reflect"
+	sync "sync"
+	unsafe "unsafe"
+)
+
+const (
+	// Verify that this generated code is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(20 - protoimpl.MinVersion)
+	// Verify that runtime/protoimpl is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
+)
+
+// 未填写的字段使用启动服务时的命令行参数
+type SubmitJobRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Servers       []string               `protobuf:"bytes,1,rep,name=servers,proto3" json:"servers,omitempty"`                         // 待检查的条目
+	Urls          []string               `protobuf:"bytes,2,rep,name=urls,proto3" json:"urls,omitempty"`                               // 在线列表 URL
+	Domains       []string               `protobuf:"bytes,3,rep,name=domains,proto3" json:"domains,omitempty"`                         // 检查域名
+	Checks        []string               `protobuf:"bytes,4,rep,name=checks,proto3" json:"checks,omitempty"`                           // 按名称启用的内置检查
+	SkipChecks    []string               `protobuf:"bytes,5,rep,name=skip_checks,json=skipChecks,proto3" json:"skip_checks,omitempty"` // 按名称跳过的检查
+	Transport     string                 `protobuf:"bytes,6,opt,name=transport,proto3" json:"transport,omitempty"`                     // udp、tcp、both 或 dot
+	Timeout       string                 `protobuf:"bytes,7,opt,name=timeout,proto3" json:"timeout,omitempty"`                         // 单次查询的超时，如 2s
+	StopAfter     int32                  `protobuf:"varint,8,opt,name=stop_after,json=stopAfter,proto3" json:"stop_after,omitempty"`   // 找到该数量的可用服务器后停止
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *SubmitJobRequest) Reset() {
+	*x = SubmitJobRequest{}
+	mi := &file_dnsvalidator_proto_msgTypes[0]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *SubmitJobRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SubmitJobRequest) ProtoMessage() {}
+
+func (x *SubmitJobRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_dnsvalidator_proto_msgTypes[0]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SubmitJobRequest.ProtoReflect.Descriptor instead.
+func (*SubmitJobRequest) Descriptor() ([]byte, []int) {
+	return file_dnsvalidator_proto_rawDescGZIP(), []int{0}
+}
+
+func (x *SubmitJobRequest) GetServers() []string {
+	if x != nil {
+		return x.Servers
+	}
+	return nil
+}
+
+func (x *SubmitJobRequest) GetUrls() []string {
+	if x != nil {
+		return x.Urls
+	}
+	return nil
+}
+
+func (x *SubmitJobRequest) GetDomains() []string {
+	if x != nil {
+		return x.Domains
+	}
+	return nil
+}
+
+func (x *SubmitJobRequest) GetChecks() []string {
+	if x != nil {
+		return x.Checks
+	}
+	return nil
+}
+
+func (x *SubmitJobRequest) GetSkipChecks() []string {
+	if x != nil {
+		return x.SkipChecks
+	}
+	return nil
+}
+
+func (x *SubmitJobRequest) GetTransport() string {
+	if x != nil {
+		return x.Transport
+	}
+	return ""
+}
+
+func (x *SubmitJobRequest) GetTimeout() string {
+	if x != nil {
+		return x.Timeout
+	}
+	return ""
+}
+
+func (x *SubmitJobRequest) GetStopAfter() int32 {
+	if x != nil {
+		return x.StopAfter
+	}
+	return 0
+}
+
+type SubmitJobResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Id            string                 `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *SubmitJobResponse) Reset() {
+	*x = SubmitJobResponse{}
+	mi := &file_dnsvalidator_proto_msgTypes[1]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *SubmitJobResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SubmitJobResponse) ProtoMessage() {}
+
+func (x *SubmitJobResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_dnsvalidator_proto_msgTypes[1]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SubmitJobResponse.ProtoReflect.Descriptor instead.
+func (*SubmitJobResponse) Descriptor() ([]byte, []int) {
+	return file_dnsvalidator_proto_rawDescGZIP(), []int{1}
+}
+
+func (x *SubmitJobResponse) GetId() string {
+	if x != nil {
+		return x.Id
+	}
+	return ""
+}
+
+type StreamResultsRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Id            string                 `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *StreamResultsRequest) Reset() {
+	*x = StreamResultsRequest{}
+	mi := &file_dnsvalidator_proto_msgTypes[2]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *StreamResultsRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*StreamResultsRequest) ProtoMessage() {}
+
+func (x *StreamResultsRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_dnsvalidator_proto_msgTypes[2]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use StreamResultsRequest.ProtoReflect.Descriptor instead.
+func (*StreamResultsRequest) Descriptor() ([]byte, []int) {
+	return file_dnsvalidator_proto_rawDescGZIP(), []int{2}
+}
+
+func (x *StreamResultsRequest) GetId() string {
+	if x != nil {
+		return x.Id
+	}
+	return ""
+}
+
+type GetSummaryRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Id            string                 `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GetSummaryRequest) Reset() {
+	*x = GetSummaryRequest{}
+	mi := &file_dnsvalidator_proto_msgTypes[3]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetSummaryRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetSummaryRequest) ProtoMessage() {}
+
+func (x *GetSummaryRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_dnsvalidator_proto_msgTypes[3]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetSummaryRequest.ProtoReflect.Descriptor instead.
+func (*GetSummaryRequest) Descriptor() ([]byte, []int) {
+	return file_dnsvalidator_proto_rawDescGZIP(), []int{3}
+}
+
+func (x *GetSummaryRequest) GetId() string {
+	if x != nil {
+		return x.Id
+	}
+	return ""
+}
+
+// 单台服务器的检查结果，字段与 JSON 输出相同
+type Result struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Server        string                 `protobuf:"bytes,1,opt,name=server,proto3" json:"server,omitempty"`
+	Ip            string                 `protobuf:"bytes,2,opt,name=ip,proto3" json:"ip,omitempty"`
+	Port          string                 `protobuf:"bytes,3,opt,name=port,proto3" json:"port,omitempty"`
+	Transport     string                 `protobuf:"bytes,4,opt,name=transport,proto3" json:"transport,omitempty"`
+	LatencyMs     float64                `protobuf:"fixed64,5,opt,name=latency_ms,json=latencyMs,proto3" json:"latency_ms,omitempty"`
+	Reliability   float64                `protobuf:"fixed64,6,opt,name=reliability,proto3" json:"reliability,omitempty"`
+	Attempts      int32                  `protobuf:"varint,7,opt,name=attempts,proto3" json:"attempts,omitempty"`
+	Retries       int32                  `protobuf:"varint,8,opt,name=retries,proto3" json:"retries,omitempty"`
+	Rcode         string                 `protobuf:"bytes,9,opt,name=rcode,proto3" json:"rcode,omitempty"`
+	Answers       []string               `protobuf:"bytes,10,rep,name=answers,proto3" json:"answers,omitempty"`
+	Flags         []string               `protobuf:"bytes,11,rep,name=flags,proto3" json:"flags,omitempty"`
+	Checks        []string               `protobuf:"bytes,12,rep,name=checks,proto3" json:"checks,omitempty"`
+	Details       map[string]string      `protobuf:"bytes,13,rep,name=details,proto3" json:"details,omitempty" protobuf_key:"bytes,1,opt,name=key" protobuf_val:"bytes,2,opt,name=value"`
+	Protocol      string                 `protobuf:"bytes,14,opt,name=protocol,proto3" json:"protocol,omitempty"`
+	Provider      string                 `protobuf:"bytes,15,opt,name=provider,proto3" json:"provider,omitempty"`
+	Hostname      string                 `protobuf:"bytes,16,opt,name=hostname,proto3" json:"hostname,omitempty"`
+	Source        string                 `protobuf:"bytes,17,opt,name=source,proto3" json:"source,omitempty"`
+	Timestamp     *timestamppb.Timestamp `protobuf:"bytes,18,opt,name=timestamp,proto3" json:"timestamp,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Result) Reset() {
+	*x = Result{}
+	mi := &file_dnsvalidator_proto_msgTypes[4]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Result) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Result) ProtoMessage() {}
+
+func (x *Result) ProtoReflect() protoreflect.Message {
+	mi := &file_dnsvalidator_proto_msgTypes[4]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Result.ProtoReflect.Descriptor instead.
+func (*Result) Descriptor() ([]byte, []int) {
+	return file_dnsvalidator_proto_rawDescGZIP(), []int{4}
+}
+
+func (x *Result) GetServer() string {
+	if x != nil {
+		return x.Server
+	}
+	return ""
+}
+
+func (x *Result) GetIp() string {
+	if x != nil {
+		return x.Ip
+	}
+	return ""
+}
+
+func (x *Result) GetPort() string {
+	if x != nil {
+		return x.Port
+	}
+	return ""
+}
+
+func (x *Result) GetTransport() string {
+	if x != nil {
+		return x.Transport
+	}
+	return ""
+}
+
+func (x *Result) GetLatencyMs() float64 {
+	if x != nil {
+		return x.LatencyMs
+	}
+	return 0
+}
+
+func (x *Result) GetReliability() float64 {
+	if x != nil {
+		return x.Reliability
+	}
+	return 0
+}
+
+func (x *Result) GetAttempts() int32 {
+	if x != nil {
+		return x.Attempts
+	}
+	return 0
+}
+
+func (x *Result) GetRetries() int32 {
+	if x != nil {
+		return x.Retries
+	}
+	return 0
+}
+
+func (x *Result) GetRcode() string {
+	if x != nil {
+		return x.Rcode
+	}
+	return ""
+}
+
+func (x *Result) GetAnswers() []string {
+	if x != nil {
+		return x.Answers
+	}
+	return nil
+}
+
+func (x *Result) GetFlags() []string {
+	if x != nil {
+		return x.Flags
+	}
+	return nil
+}
+
+func (x *Result) GetChecks() []string {
+	if x != nil {
+		return x.Checks
+	}
+	return nil
+}
+
+func (x *Result) GetDetails() map[string]string {
+	if x != nil {
+		return x.Details
+	}
+	return nil
+}
+
+func (x *Result) GetProtocol() string {
+	if x != nil {
+		return x.Protocol
+	}
+	return ""
+}
+
+func (x *Result) GetProvider() string {
+	if x != nil {
+		return x.Provider
+	}
+	return ""
+}
+
+func (x *Result) GetHostname() string {
+	if x != nil {
+		return x.Hostname
+	}
+	return ""
+}
+
+func (x *Result) GetSource() string {
+	if x != nil {
+		return x.Source
+	}
+	return ""
+}
+
+func (x *Result) GetTimestamp() *timestamppb.Timestamp {
+	if x != nil {
+		return x.Timestamp
+	}
+	return nil
+}
+
+// 任务的状态和统计
+type Summary struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Id            string                 `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
+	Status        string                 `protobuf:"bytes,2,opt,name=status,proto3" json:"status,omitempty"`    // queued、running、done、failed 或 canceled
+	Entries       int32                  `protobuf:"varint,3,opt,name=entries,proto3" json:"entries,omitempty"` // 提交的条目数，不含在线列表
+	Created       *timestamppb.Timestamp `protobuf:"bytes,4,opt,name=created,proto3" json:"created,omitempty"`
+	Started       *timestamppb.Timestamp `protobuf:"bytes,5,opt,name=started,proto3" json:"started,omitempty"`
+	Finished      *timestamppb.Timestamp `protobuf:"bytes,6,opt,name=finished,proto3" json:"finished,omitempty"`
+	Tested        int32                  `protobuf:"varint,7,opt,name=tested,proto3" json:"tested,omitempty"`
+	Valid         int32                  `protobuf:"varint,8,opt,name=valid,proto3" json:"valid,omitempty"`
+	Failures      map[string]int32       `protobuf:"bytes,9,rep,name=failures,proto3" json:"failures,omitempty" protobuf_key:"bytes,1,opt,name=key" protobuf_val:"varint,2,opt,name=value"` // 各失败类别的服务器数
+	Error         string                 `protobuf:"bytes,10,opt,name=error,proto3" json:"error,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Summary) Reset() {
+	*x = Summary{}
+	mi := &file_dnsvalidator_proto_msgTypes[5]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Summary) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Summary) ProtoMessage() {}
+
+func (x *Summary) ProtoReflect() protoreflect.Message {
+	mi := &file_dnsvalidator_proto_msgTypes[5]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Summary.ProtoReflect.Descriptor instead.
+func (*Summary) Descriptor() ([]byte, []int) {
+	return file_dnsvalidator_proto_rawDescGZIP(), []int{5}
+}
+
+func (x *Summary) GetId() string {
+	if x != nil {
+		return x.Id
+	}
+	return ""
+}
+
+func (x *Summary) GetStatus() string {
+	if x != nil {
+		return x.Status
+	}
+	return ""
+}
+
+func (x *Summary) GetEntries() int32 {
+	if x != nil {
+		return x.Entries
+	}
+	return 0
+}
+
+func (x *Summary) GetCreated() *timestamppb.Timestamp {
+	if x != nil {
+		return x.Created
+	}
+	return nil
+}
+
+func (x *Summary) GetStarted() *timestamppb.Timestamp {
+	if x != nil {
+		return x.Started
+	}
+	return nil
+}
+
+func (x *Summary) GetFinished() *timestamppb.Timestamp {
+	if x != nil {
+		return x.Finished
+	}
+	return nil
+}
+
+func (x *Summary) GetTested() int32 {
+	if x != nil {
+		return x.Tested
+	}
+	return 0
+}
+
+func (x *Summary) GetValid() int32 {
+	if x != nil {
+		return x.Valid
+	}
+	return 0
+}
+
+func (x *Summary) GetFailures() map[string]int32 {
+	if x != nil {
+		return x.Failures
+	}
+	return nil
+}
+
+func (x *Summary) GetError() string {
+	if x != nil {
+		return x.Error
+	}
+	return ""
+}
+
+var File_dnsvalidator_proto protoreflect.FileDescriptor
+
+const file_dnsvalidator_proto_rawDesc = "" +
+	"\n" +
+	"\x12dnsvalidator.proto\x12\x0fdnsvalidator.v1\x1a\x1fgoogle/protobuf/timestamp.proto\"\xea\x01\n" +
+	"\x10SubmitJobRequest\x12\x18\n" +
+	"\aservers\x18\x01 \x03(\tR\aservers\x12\x12\n" +
+	"\x04urls\x18\x02 \x03(\tR\x04urls\x12\x18\n" +
+	"\adomains\x18\x03 \x03(\tR\adomains\x12\x16\n" +
+	"\x06checks\x18\x04 \x03(\tR\x06checks\x12\x1f\n" +
+	"\vskip_checks\x18\x05 \x03(\tR\n" +
+	"skipChecks\x12\x1c\n" +
+	"\ttransport\x18\x06 \x01(\tR\ttransport\x12\x18\n" +
+	"\atimeout\x18\a \x01(\tR\atimeout\x12\x1d\n" +
+	"\n" +
+	"stop_after\x18\b \x01(\x05R\tstopAfter\"#\n" +
+	"\x11SubmitJobResponse\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\tR\x02id\"&\n" +
+	"\x14StreamResultsRequest\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\tR\x02id\"#\n" +
+	"\x11GetSummaryRequest\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\tR\x02id\"\xd9\x04\n" +
+	"\x06Result\x12\x16\n" +
+	"\x06server\x18\x01 \x01(\tR\x06server\x12\x0e\n" +
+	"\x02ip\x18\x02 \x01(\tR\x02ip\x12\x12\n" +
+	"\x04port\x18\x03 \x01(\tR\x04port\x12\x1c\n" +
+	"\ttransport\x18\x04 \x01(\tR\ttransport\x12\x1d\n" +
+	"\n" +
+	"latency_ms\x18\x05 \x01(\x01R\tlatencyMs\x12 \n" +
+	"\vreliability\x18\x06 \x01(\x01R\vreliability\x12\x1a\n" +
+	"\battempts\x18\a \x01(\x05R\battempts\x12\x18\n" +
+	"\aretries\x18\b \x01(\x05R\aretries\x12\x14\n" +
+	"\x05rcode\x18\t \x01(\tR\x05rcode\x12\x18\n" +
+	"\aanswers\x18\n" +
+	" \x03(\tR\aanswers\x12\x14\n" +
+	"\x05flags\x18\v \x03(\tR\x05flags\x12\x16\n" +
+	"\x06checks\x18\f \x03(\tR\x06checks\x12>\n" +
+	"\adetails\x18\r \x03(\v2$.dnsvalidator.v1.Result.DetailsEntryR\adetails\x12\x1a\n" +
+	"\bprotocol\x18\x0e \x01(\tR\bprotocol\x12\x1a\n" +
+	"\bprovider\x18\x0f \x01(\tR\bprovider\x12\x1a\n" +
+	"\bhostname\x18\x10 \x01(\tR\bhostname\x12\x16\n" +
+	"\x06source\x18\x11 \x01(\tR\x06source\x128\n" +
+	"\ttimestamp\x18\x12 \x01(\v2\x1a.google.protobuf.TimestampR\ttimestamp\x1a:\n" +
+	"\fDetailsEntry\x12\x10\n" +
+	"\x03key\x18\x01 \x01(\tR\x03key\x12\x14\n" +
+	"\x05value\x18\x02 \x01(\tR\x05value:\x028\x01\"\xb4\x03\n" +
+	"\aSummary\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\tR\x02id\x12\x16\n" +
+	"\x06status\x18\x02 \x01(\tR\x06status\x12\x18\n" +
+	"\aentries\x18\x03 \x01(\x05R\aentries\x124\n" +
+	"\acreated\x18\x04 \x01(\v2\x1a.google.protobuf.TimestampR\acreated\x124\n" +
+	"\astarted\x18\x05 \x01(\v2\x1a.google.protobuf.TimestampR\astarted\x126\n" +
+	"\bfinished\x18\x06 \x01(\v2\x1a.google.protobuf.TimestampR\bfinished\x12\x16\n" +
+	"\x06tested\x18\a \x01(\x05R\x06tested\x12\x14\n" +
+	"\x05valid\x18\b \x01(\x05R\x05valid\x12B\n" +
+	"\bfailures\x18\t \x03(\v2&.dnsvalidator.v1.Summary.FailuresEntryR\bfailures\x12\x14\n" +
+	"\x05error\x18\n" +
+	" \x01(\tR\x05error\x1a;\n" +
+	"\rFailuresEntry\x12\x10\n" +
+	"\x03key\x18\x01 \x01(\tR\x03key\x12\x14\n" +
+	"\x05value\x18\x02 \x01(\x05R\x05value:\x028\x012\x81\x02\n" +
+	"\fDNSValidator\x12R\n" +
+	"\tSubmitJob\x12!.dnsvalidator.v1.SubmitJobRequest\x1a\".dnsvalidator.v1.SubmitJobResponse\x12Q\n" +
+	"\rStreamResults\x12%.dnsvalidator.v1.StreamResultsRequest\x1a\x17.dnsvalidator.v1.Result0\x01\x12J\n" +
+	"\n" +
+	"GetSummary\x12\".dnsvalidator.v1.GetSummaryRequest\x1a\x18.dnsvalidator.v1.SummaryB:Z8github.com/badboycxcc/dnsvalidator_go/pkg/dnsvalidatorpbb\x06proto3"
+
+var (
+	file_dnsvalidator_proto_rawDescOnce sync.Once
+	file_dnsvalidator_proto_rawDescData []byte
+)
+
+func file_dnsvalidator_proto_rawDescGZIP() []byte {
+	file_dnsvalidator_proto_rawDescOnce.Do(func() {
+		file_dnsvalidator_proto_rawDescData = protoimpl.X.CompressGZIP(unsafe.Slice(unsafe.StringData(file_dnsvalidator_proto_rawDesc), len(file_dnsvalidator_proto_rawDesc)))
+	})
+	return file_dnsvalidator_proto_rawDescData
+}
+
+var file_dnsvalidator_proto_msgTypes = make([]protoimpl.MessageInfo, 8)
+var file_dnsvalidator_proto_goTypes = []any{
+	(*SubmitJobRequest)(nil),      // 0: dnsvalidator.v1.SubmitJobRequest
+	(*SubmitJobResponse)(nil),     // 1: dnsvalidator.v1.SubmitJobResponse
+	(*StreamResultsRequest)(nil),  // 2: dnsvalidator.v1.StreamResultsRequest
+	(*GetSummaryRequest)(nil),     // 3: dnsvalidator.v1.GetSummaryRequest
+	(*Result)(nil),                // 4: dnsvalidator.v1.Result
+	(*Summary)(nil),               // 5: dnsvalidator.v1.Summary
+	nil,                           // 6: dnsvalidator.v1.Result.DetailsEntry
+	nil,                           // 7: dnsvalidator.v1.Summary.FailuresEntry
+	(*timestamppb.Timestamp)(nil), // 8: google.protobuf.Timestamp
+}
+var file_dnsvalidator_proto_depIdxs = []int32{
+	6, // 0: dnsvalidator.v1.Result.details:type_name -> dnsvalidator.v1.Result.DetailsEntry
+	8, // 1: dnsvalidator.v1.Result.timestamp:type_name -> google.protobuf.Timestamp
+	8, // 2: dnsvalidator.v1.Summary.created:type_name -> google.protobuf.Timestamp
+	8, // 3: dnsvalidator.v1.Summary.started:type_name -> google.protobuf.Timestamp
+	8, // 4: dnsvalidator.v1.Summary.finished:type_name -> google.protobuf.Timestamp
+	7, // 5: dnsvalidator.v1.Summary.failures:type_name -> dnsvalidator.v1.Summary.FailuresEntry
+	0, // 6: dnsvalidator.v1.DNSValidator.SubmitJob:input_type -> dnsvalidator.v1.SubmitJobRequest
+	2, // 7: dnsvalidator.v1.DNSValidator.StreamResults:input_type -> dnsvalidator.v1.StreamResultsRequest
+	3, // 8: dnsvalidator.v1.DNSValidator.GetSummary:input_type -> dnsvalidator.v1.GetSummaryRequest
+	1, // 9: dnsvalidator.v1.DNSValidator.SubmitJob:output_type -> dnsvalidator.v1.SubmitJobResponse
+	4, // 10: dnsvalidator.v1.DNSValidator.StreamResults:output_type -> dnsvalidator.v1.Result
+	5, // 11: dnsvalidator.v1.DNSValidator.GetSummary:output_type -> dnsvalidator.v1.Summary
+	9, // [9:12] is the sub-list for method output_type
+	6, // [6:9] is the sub-list for method input_type
+	6, // [6:6] is the sub-list for extension type_name
+	6, // [6:6] is the sub-list for extension extendee
+	0, // [0:6] is the sub-list for field type_name
+}
+
+func init() { file_dnsvalidator_proto_init() }
+func file_dnsvalidator_proto_init() {
+	if File_dnsvalidator_proto != nil {
+		return
+	}
+	type x struct{}
+	out := protoimpl.TypeBuilder{
+		File: protoimpl.DescBuilder{
+			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
+			RawDescriptor: unsafe.Slice(unsafe.StringData(file_dnsvalidator_proto_rawDesc), len(file_dnsvalidator_proto_rawDesc)),
+			NumEnums:      0,
+			NumMessages:   8,
+			NumExtensions: 0,
+			NumServices:   1,
+		},
+		GoTypes:           file_dnsvalidator_proto_goTypes,
+		DependencyIndexes: file_dnsvalidator_proto_depIdxs,
+		MessageInfos:      file_dnsvalidator_proto_msgTypes,
+	}.Build()
+	File_dnsvalidator_proto = out.File
+	file_dnsvalidator_proto_goTypes = nil
+	file_dnsvalidator_proto_depIdxs = nil
+}
@@ -0,0 +1,210 @@
+// dnsvalidator 的 gRPC 接口，由 dnsvalidator -grpc 提供。
+// 修改后在 pkg/dnsvalidatorpb 下运行 go generate 重新生成 Go 代码
+
+// Code generated by protoc-gen-go-grpc. DO NOT EDIT.
+// versions:
+// - protoc-gen-go-grpc v1.6.2
+// - protoc             (unknown)
+// source: dnsvalidator.proto
+
+package dnsvalidatorpb
+
+import (
+	context "context"
+	grpc "google.golang.org/grpc"
+	codes "google.golang.org/grpc/codes"
+	status "google.golang.org/grpc/status"
+)
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the grpc package it is being compiled against.
+// Requires gRPC-Go v1.64.0 or later.
+const _ = grpc.SupportPackageIsVersion9
+
+const (
+	DNSValidator_SubmitJob_FullMethodName     = "/dnsvalidator.v1.DNSValidator/SubmitJob"
+	DNSValidator_StreamResults_FullMethodName = "/dnsvalidator.v1.DNSValidator/StreamResults"
+	DNSValidator_GetSummary_FullMethodName    = "/dnsvalidator.v1.DNSValidator/GetSummary"
+)
+
+// DNSValidatorClient is the client API for DNSValidator service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
+type DNSValidatorClient interface {
+	// 提交一个检查任务，任务排队后依次运行
+	SubmitJob(ctx context.Context, in *SubmitJobRequest, opts ...grpc.CallOption) (*SubmitJobResponse, error)
+	// 按完成顺序送出任务中通过检查的服务器，先送出已有的结果，任务结束后流结束
+	StreamResults(ctx context.Context, in *StreamResultsRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[Result], error)
+	// 任务状态和统计
+	GetSummary(ctx context.Context, in *GetSummaryRequest, opts ...grpc.CallOption) (*Summary, error)
+}
+
+type dNSValidatorClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewDNSValidatorClient(cc grpc.ClientConnInterface) DNSValidatorClient {
+	return &dNSValidatorClient{cc}
+}
+
+func (c *dNSValidatorClient) SubmitJob(ctx context.Context, in *SubmitJobRequest, opts ...grpc.CallOption) (*SubmitJobResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(SubmitJobResponse)
+	err := c.cc.Invoke(ctx, DNSValidator_SubmitJob_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *dNSValidatorClient) StreamResults(ctx context.Context, in *StreamResultsRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[Result], error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	stream, err := c.cc.NewStream(ctx, &DNSValidator_ServiceDesc.Streams[0], DNSValidator_StreamResults_FullMethodName, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &grpc.GenericClientStream[StreamResultsRequest, Result]{ClientStream: stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type DNSValidator_StreamResultsClient = grpc.ServerStreamingClient[Result]
+
+func (c *dNSValidatorClient) GetSummary(ctx context.Context, in *GetSummaryRequest, opts ...grpc.CallOption) (*Summary, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(Summary)
+	err := c.cc.Invoke(ctx, DNSValidator_GetSummary_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// DNSValidatorServer is the server API for DNSValidator service.
+// All implementations must embed UnimplementedDNSValidatorServer
+// for forward compatibility.
+type DNSValidatorServer interface {
+	// 提交一个检查任务，任务排队后依次运行
+	SubmitJob(context.Context, *SubmitJobRequest) (*SubmitJobResponse, error)
+	// 按完成顺序送出任务中通过检查的服务器，先送出已有的结果，任务结束后流结束
+	StreamResults(*StreamResultsRequest, grpc.ServerStreamingServer[Result]) error
+	// 任务状态和统计
+	GetSummary(context.Context, *GetSummaryRequest) (*Summary, error)
+	mustEmbedUnimplementedDNSValidatorServer()
+}
+
+// UnimplementedDNSValidatorServer must be embedded to have
+// forward compatible implementations.
+//
+// NOTE: this should be embedded by value instead of pointer to avoid a nil
+// pointer dereference when methods are called.
+type UnimplementedDNSValidatorServer struct{}
+
+func (UnimplementedDNSValidatorServer) SubmitJob(context.Context, *SubmitJobRequest) (*SubmitJobResponse, error) {
+	return nil, status.Error(codes.Unimplemented, "method SubmitJob not implemented")
+}
+func (UnimplementedDNSValidatorServer) StreamResults(*StreamResultsRequest, grpc.ServerStreamingServer[Result]) error {
+	return status.Error(codes.Unimplemented, "method StreamResults not implemented")
+}
+func (UnimplementedDNSValidatorServer) GetSummary(context.Context, *GetSummaryRequest) (*Summary, error) {
+	return nil, status.Error(codes.Unimplemented, "method GetSummary not implemented")
+}
+func (UnimplementedDNSValidatorServer) mustEmbedUnimplementedDNSValidatorServer() {}
+func (UnimplementedDNSValidatorServer) testEmbeddedByValue()                      {}
+
+// UnsafeDNSValidatorServer may be embedded to opt out of forward compatibility for this service.
+// Use of this interface is not recommended, as added methods to DNSValidatorServer will
+// result in compilation errors.
+type UnsafeDNSValidatorServer interface {
+	mustEmbedUnimplementedDNSValidatorServer()
+}
+
+func RegisterDNSValidatorServer(s grpc.ServiceRegistrar, srv DNSValidatorServer) {
+	// If the following call panics, it indicates UnimplementedDNSValidatorServer was
+	// embedded by pointer and is nil.  This will cause panics if an
+	// unimplemented method is ever invoked, so we test this at initialization
+	// time to prevent it from happening at runtime later due to I/O.
+	if t, ok := srv.(interface{ testEmbeddedByValue() }); ok {
+		t.testEmbeddedByValue()
+	}
+	s.RegisterService(&DNSValidator_ServiceDesc, srv)
+}
+
+func _DNSValidator_SubmitJob_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(SubmitJobRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(DNSValidatorServer).SubmitJob(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: DNSValidator_SubmitJob_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(DNSValidatorServer).SubmitJob(ctx, req.(*SubmitJobRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _DNSValidator_StreamResults_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(StreamResultsRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(DNSValidatorServer).StreamResults(m, &grpc.GenericServerStream[StreamResultsRequest, Result]{ServerStream: stream})
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type DNSValidator_StreamResultsServer = grpc.ServerStreamingServer[Result]
+
+func _DNSValidator_GetSummary_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetSummaryRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(DNSValidatorServer).GetSummary(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: DNSValidator_GetSummary_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(DNSValidatorServer).GetSummary(ctx, req.(*GetSummaryRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+// DNSValidator_ServiceDesc is the grpc.ServiceDesc for DNSValidator service.
+// It's only intended for direct use with grpc.RegisterService,
+// and not to be introspected or modified (even as a copy)
+var DNSValidator_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "dnsvalidator.v1.DNSValidator",
+	HandlerType: (*DNSValidatorServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "SubmitJob",
+			Handler:    _DNSValidator_SubmitJob_Handler,
+		},
+		{
+			MethodName: "GetSummary",
+			Handler:    _DNSValidator_GetSummary_Handler,
+		},
+	},
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    "StreamResults",
+			Handler:       _DNSValidator_StreamResults_Handler,
+			ServerStreams: true,
+		},
+	},
+	Metadata: "dnsvalidator.proto",
+}
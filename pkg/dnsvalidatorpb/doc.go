@@ -0,0 +1,5 @@
+// dnsvalidatorpb 是 proto/dnsvalidator.proto 生成的 gRPC 客户端和服务端代码，
+// 服务由 dnsvalidator -grpc 提供
+package dnsvalidatorpb
+
+//go:generate protoc -I ../../proto --go_out=. --go_opt=paths=source_relative --go-grpc_out=. --go-grpc_opt=paths=source_relative dnsvalidator.proto